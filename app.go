@@ -0,0 +1,619 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"movie-night/config"
+	"movie-night/model"
+	"movie-night/p2p"
+	"movie-night/pkg/logging"
+	"movie-night/pkg/mpv"
+	"movie-night/pkg/rpc"
+	"movie-night/pkg/web"
+	"movie-night/sync"
+
+	"github.com/anacrolix/torrent"
+	"github.com/gorilla/websocket"
+)
+
+// shutdownTimeout 关闭 HTTP 服务和等待 MPV 退出的最长时间
+const shutdownTimeout = 3 * time.Second
+
+// healthSample 启动后种子健康检查采样下载速度的时长
+const healthSample = 15 * time.Second
+
+// Options 命令行选项
+type Options struct {
+	IsController    bool
+	UserName        string
+	ReplayReactions bool
+	Policy          model.RoomPolicy
+	Playlist        bool
+	RecordPath      string
+}
+
+// App 持有所有组件，负责按顺序启动和关闭
+type App struct {
+	opts   Options
+	cfg    *config.Config
+	logger *slog.Logger
+	base   *slog.Logger // 传给各组件的日志
+
+	ctx    context.Context    // 根 ctx，shutdown 时取消
+	cancel context.CancelFunc // 取消根 ctx
+	runCtx context.Context    // Run 的 ctx，收到信号时取消
+	seed   bool               // 正常看完（MPV 关闭），退出前按策略做种
+
+	p2pClient    *p2p.Client
+	streamServer *p2p.StreamServer
+	mpvCtrl      *mpv.Controller
+	monitor      *mpv.Monitor
+	mqttClient   *sync.MQTTClient
+	recorder     *sync.Recorder
+	presence     *sync.Presence
+	room         *sync.Room
+	votes        *sync.VoteDisplay
+	timeline     *sync.ReactionTimeline
+	reactor      *sync.Reactor
+	subtitles    *sync.SubtitleShare
+	chat         *sync.Chat
+	remote       *sync.Remote
+	keyActions   *sync.KeyActions
+	script       *sync.ScriptBridge
+	rpcServer    *rpc.Server
+	syncplay     *sync.SyncplayClient
+	syncplayHost *sync.SyncplayServer
+
+	stopMPV context.CancelFunc // 强制结束 MPV 进程
+	mpvDone chan struct{}      // MPV 进程已退出
+}
+
+// NewApp 创建应用
+func NewApp(opts Options, cfg *config.Config, logger *slog.Logger) *App {
+	return &App{
+		opts:    opts,
+		cfg:     cfg,
+		logger:  logging.Component(logger, "App"),
+		base:    logger,
+		mpvDone: make(chan struct{}),
+	}
+}
+
+// Run 启动所有组件，直到 ctx 取消（收到信号）或 MPV 退出，然后按顺序关闭
+func (a *App) Run(ctx context.Context) error {
+	a.ctx, a.cancel = context.WithCancel(ctx)
+	a.runCtx = ctx
+	defer a.shutdown()
+
+	if err := a.start(); err != nil {
+		return err
+	}
+
+	fmt.Println("⏳ 运行中，按 Ctrl+C 退出")
+	select {
+	case <-a.ctx.Done():
+		a.logger.Info("收到退出信号")
+	case <-a.mpvDone:
+		a.logger.Info("MPV 已关闭")
+		a.seed = true
+	}
+	return nil
+}
+
+// start 按依赖顺序启动组件（出错时已启动的组件由 shutdown 关闭）
+func (a *App) start() error {
+	cfg := a.cfg
+
+	// 1. 启动 P2P 客户端
+	p2pClient, err := p2p.NewClient(p2p.Config{
+		DataDir:    cfg.DataDir,
+		MaxConns:   cfg.MaxConns,
+		MagnetLink: cfg.MagnetLink,
+
+		MetadataTimeout: cfg.MetadataTimeout,
+
+		UploadLimit:   cfg.UploadLimit,
+		DownloadLimit: cfg.DownloadLimit,
+		Seed:          p2p.SeedPolicy{Ratio: cfg.SeedRatio, Duration: cfg.SeedTime},
+		RoomFirst:     cfg.RoomFirstUpload,
+
+		Storage:   cfg.Storage,
+		CacheSize: cfg.CacheSize,
+		CacheDir:  cfg.CacheDir,
+
+		Logger: a.base,
+	})
+	if err != nil {
+		return fmt.Errorf("P2P 启动失败: %w", err)
+	}
+	a.p2pClient = p2pClient
+
+	// 2. 连接 MQTT（遗嘱：异常退出时通知其他人自己已离开）
+	clientID := fmt.Sprintf("%s-%d", cfg.MQTTClientID, time.Now().Unix())
+	willTopic, willPayload := sync.LeaveMessage(clientID, a.opts.UserName)
+	mqttClient, err := sync.NewMQTTClient(sync.MQTTConfig{
+		Broker:       cfg.MQTTBroker,
+		ClientID:     clientID,
+		Topic:        cfg.MQTTTopic,
+		WillSubtopic: willTopic,
+		WillPayload:  willPayload,
+		Logger:       a.base,
+	})
+	if err != nil {
+		return fmt.Errorf("MQTT 连接失败: %w", err)
+	}
+	a.mqttClient = mqttClient
+
+	// 会话记录（可选，需在订阅之前开启）
+	if a.opts.RecordPath != "" {
+		recorder, err := sync.OpenRecorder(a.opts.RecordPath, a.base)
+		if err != nil {
+			a.logger.Warn("会话记录不可用", "err", err)
+		} else {
+			a.recorder = recorder
+			mqttClient.SetRecorder(recorder)
+		}
+	}
+
+	// 在线状态（投票和房主选举需要知道房间里有谁）
+	role := model.RoleFollower
+	if a.opts.IsController {
+		role = model.RoleHost
+	}
+	a.presence = sync.NewPresence(mqttClient, clientID, a.opts.UserName, role, a.base)
+	// 在心跳里交换 BitTorrent 地址，房间参与者直接互连（Tracker/DHT 不可用时也能获取元数据和数据）
+	a.presence.SetPeerExchange(p2pClient.PeerAddrs(), func(addrs []string) {
+		p2pClient.AddRoomPeers(addrs)
+	})
+	if err := a.presence.Start(); err != nil {
+		a.logger.Warn("在线状态不可用", "err", err)
+	}
+
+	// 3. 获取元数据
+	if err := p2pClient.WaitInfo(a.ctx); err != nil {
+		return err
+	}
+	p2pClient.RegisterMetrics()
+
+	// 4. 获取视频文件
+	videoFile := p2pClient.GetLargestFile()
+	var videoFiles []*torrent.File
+	if a.opts.Playlist {
+		videoFiles = p2pClient.GetVideoFiles()
+		if len(videoFiles) > 0 {
+			videoFile = videoFiles[0]
+		}
+	}
+	if videoFile == nil {
+		return fmt.Errorf("未找到视频文件")
+	}
+	a.logger.Info("视频", "path", videoFile.DisplayPath(), "playlist", len(videoFiles))
+
+	// 5. 启动 HTTP 流服务（后台）
+	a.streamServer = p2p.NewStreamServer(cfg.StreamPort, p2pClient.MediaFile(videoFile), a.base)
+	a.streamServer.SetPlaylist(p2pClient.MediaFiles(videoFiles))
+	subtitleFiles := p2pClient.GetSubtitleFiles()
+	a.streamServer.AddSubtitles(p2p.TorrentFiles(subtitleFiles))
+	go func() {
+		if err := a.streamServer.Start(); err != nil {
+			a.logger.Error("流服务启动失败", "err", err)
+			a.cancel()
+		}
+	}()
+
+	// 6. 启动 MPV（后台，退出时结束整个程序）
+	launchCfg := mpv.LaunchConfig{
+		VideoURL:   a.streamServer.GetURL(),
+		SocketPath: cfg.MPVSocketPath,
+		Title:      getTitle(a.opts.IsController),
+		Logger:     a.base,
+	}
+	if urls := a.streamServer.GetPlaylistURLs(); len(urls) > 1 {
+		launchCfg.VideoURL, launchCfg.Playlist = urls[0], urls[1:]
+	}
+	var mpvCtx context.Context
+	mpvCtx, a.stopMPV = context.WithCancel(context.Background())
+	go func() {
+		defer close(a.mpvDone)
+		if err := mpv.Launch(mpvCtx, launchCfg); err != nil {
+			a.logger.Warn("MPV 退出", "err", err)
+		}
+	}()
+
+	// 7. 等待 MPV 启动
+	time.Sleep(2 * time.Second)
+
+	// 8. 创建 MPV 控制器
+	mpvCtrl, err := mpv.NewController(cfg.MPVSocketPath)
+	if err != nil {
+		return fmt.Errorf("MPV 控制器创建失败: %w", err)
+	}
+	a.mpvCtrl = mpvCtrl
+
+	// 9. 创建 MPV 监听器（监听播放状态）
+	monitor, err := mpv.NewMonitor(cfg.MPVSocketPath, a.base)
+	if err != nil {
+		return fmt.Errorf("创建监听器失败: %w", err)
+	}
+	a.monitor = monitor
+	monitor.Start()
+	a.recorder.Start(monitor.Subscribe())
+
+	// 10. 获取视频时长
+	time.Sleep(1 * time.Second)
+	duration, err := mpvCtrl.GetDuration()
+	if err != nil {
+		a.logger.Warn("无法获取视频时长", "err", err)
+		duration = 0
+	} else {
+		a.logger.Info("视频时长", "seconds", duration)
+	}
+	cfg.VideoDuration = duration
+
+	// 种子健康检查（后台采样下载速度，结果随在线状态分享给房间，房主据此决定是否换片源）
+	go func() {
+		health := p2pClient.CheckHealth(a.ctx, videoFile, p2p.HealthOptions{
+			Sample:    healthSample,
+			Runtime:   time.Duration(duration * float64(time.Second)),
+			Prebuffer: time.Duration(cfg.PrebufferSeconds * float64(time.Second)),
+		})
+		if a.ctx.Err() != nil {
+			return
+		}
+		if health.Healthy() {
+			a.logger.Info("种子健康检查", "result", health.String())
+		} else {
+			a.logger.Warn("片源可能无法流畅播放", "result", health.String())
+		}
+		a.presence.SetHealth(health)
+	}()
+
+	// 11. 加入房间（根据房主声明切换控制端/跟随端）
+	room := sync.NewRoom(sync.RoomConfig{
+		MQTTClient:   mqttClient,
+		MPVCtrl:      mpvCtrl,
+		Monitor:      monitor,
+		Presence:     a.presence,
+		Policy:       a.opts.Policy,
+		Interval:     10 * time.Second,
+		MaxDuration:  cfg.VideoDuration,
+		Intermission: time.Duration(cfg.IntermissionSeconds) * time.Second,
+		Logger:       a.base,
+		// 只按第一项估算偏移（播放列表的后续项由 MPV 正常缓冲）
+		Prebuffer: func(ctx context.Context, position float64, progress func(float64)) error {
+			// 从稍早一点开始缓冲，保证能找到关键帧
+			start := p2p.OffsetForPosition(videoFile, position-2, cfg.VideoDuration)
+			end := p2p.OffsetForPosition(videoFile, position+cfg.PrebufferSeconds, cfg.VideoDuration)
+			if end <= start {
+				return fmt.Errorf("视频时长未知，跳过预缓冲")
+			}
+			return p2pClient.Prebuffer(ctx, videoFile, start, end-start, progress)
+		},
+	})
+	if err := room.Start(a.opts.IsController); err != nil {
+		return fmt.Errorf("加入房间失败: %w", err)
+	}
+	a.room = room
+
+	votes := sync.NewVoteDisplay(mqttClient, mpvCtrl)
+	if err := votes.Start(); err != nil {
+		a.logger.Warn("投票显示不可用", "err", err)
+	} else {
+		a.votes = votes
+	}
+
+	// 12. 表情反应（按播放位置显示，并记录到会话时间轴）
+	timelinePath := filepath.Join(cfg.DataDir, "reactions", p2pClient.GetTorrent().InfoHash().HexString()+".jsonl")
+	timeline, err := sync.OpenReactionTimeline(timelinePath, a.opts.ReplayReactions, a.base)
+	if err != nil {
+		a.logger.Warn("反应时间轴不可用", "err", err)
+		timeline = sync.NewReactionTimeline()
+	}
+	a.timeline = timeline
+
+	reactor := sync.NewReactor(mqttClient, mpv.NewReactionRenderer(mpvCtrl), timeline, monitor.Subscribe(), a.opts.UserName, a.base)
+	if err := reactor.Start(); err != nil {
+		a.logger.Warn("表情反应不可用", "err", err)
+	} else {
+		a.reactor = reactor
+	}
+
+	// 共享字幕（房主选择后分发给所有人）
+	subtitlePaths := make([]string, 0, len(subtitleFiles))
+	for _, f := range subtitleFiles {
+		subtitlePaths = append(subtitlePaths, f.Path())
+	}
+	subtitles := sync.NewSubtitleShare(room, mqttClient, mpvCtrl, subtitlePaths, a.streamServer.SubtitleURL, filepath.Join(cfg.DataDir, "subtitles"), a.base)
+	if err := subtitles.Start(); err != nil {
+		a.logger.Warn("共享字幕不可用", "err", err)
+	} else {
+		a.subtitles = subtitles
+	}
+
+	// 聊天（显示在 MPV 中，遥控页面和终端都可以发送）
+	chat := sync.NewChat(mqttClient, mpvCtrl, a.presence.Self(), a.base)
+	if err := chat.Start(); err != nil {
+		a.logger.Warn("聊天不可用", "err", err)
+	} else {
+		a.chat = chat
+	}
+
+	// 浏览器参与者（没有 MPV 的朋友用浏览器打开流服务的首页观看）和手机遥控页面（凭会话令牌访问）
+	items := []string{videoFile.DisplayPath()}
+	if len(videoFiles) > 1 {
+		items = make([]string, len(videoFiles))
+		for i, f := range videoFiles {
+			items[i] = f.DisplayPath()
+		}
+	}
+	remote := sync.NewRemote(room, a.presence, chat, subtitles, mpvCtrl, items, a.base)
+	remote.SetBufferSource(func(position float64) model.BufferState {
+		stats := p2pClient.Stats(videoFile, position, cfg.VideoDuration)
+		return model.BufferState{
+			DownloadRate:  stats.DownloadRate,
+			Progress:      stats.Progress(),
+			BufferedAhead: stats.BufferedAhead,
+			Peers:         len(stats.Peers),
+		}
+	})
+	remote.Start(monitor.Subscribe())
+	a.remote = remote
+
+	// 本地控制 API（脚本、Stream Deck 等通过 Unix socket 或 HTTP 控制房间）
+	a.rpcServer = rpc.NewServer(remote, a.base)
+	if cfg.RPCSocketPath != "" {
+		if err := a.rpcServer.Listen(cfg.RPCSocketPath); err != nil {
+			a.logger.Warn("控制 API 不可用", "err", err)
+		} else {
+			a.logger.Info("控制 API", "socket", cfg.RPCSocketPath)
+		}
+	}
+
+	// Syncplay 兼容（和使用 Syncplay 的朋友一起看）
+	a.startSyncplay(remote)
+
+	token, err := newRemoteToken()
+	if err != nil {
+		return fmt.Errorf("生成遥控令牌失败: %w", err)
+	}
+	joinToken, err := newRemoteToken()
+	if err != nil {
+		return fmt.Errorf("生成加入令牌失败: %w", err)
+	}
+	a.streamServer.SetWebHandler(web.Handler(web.Config{
+		Join:      a.joinFromBrowser,
+		JoinToken: joinToken,
+		Remote:    remote,
+		RPC:       a.rpcServer.Handler(),
+		Token:     token,
+	}))
+	baseURL := a.streamServer.GetLANURL()
+	remoteURL := baseURL + "/remote?token=" + token
+	a.logger.Info("浏览器观看", "url", baseURL+"/?token="+joinToken)
+	fmt.Printf("📱 手机遥控: %s（输入 remote 显示二维码）\n", remoteURL)
+
+	go runConsole(reactor, room, subtitles, a.presence, chat, remoteURL)
+
+	// 13. MPV 内的按键（通过 client-message 驱动房间动作）
+	bindings := make([]mpv.KeyBinding, 0, len(cfg.KeyBindings))
+	for action, key := range cfg.KeyBindings {
+		bindings = append(bindings, mpv.KeyBinding{Key: key, Action: action})
+	}
+	if err := mpvCtrl.BindKeys(bindings); err != nil {
+		a.logger.Warn("按键绑定失败", "err", err)
+	}
+	keyActions := sync.NewKeyActions(room, reactor, a.presence, mpvCtrl, monitor.Subscribe(), a.base)
+	keyActions.SetStatsSource(func(position float64) mpv.TorrentStats {
+		return torrentStatsView(p2pClient.Stats(videoFile, position, cfg.VideoDuration))
+	})
+	keyActions.Start(monitor.SubscribeMessages())
+	a.keyActions = keyActions
+
+	// 内置脚本（房间菜单：Ctrl+m）
+	a.script = sync.NewScriptBridge(room, a.presence, mpvCtrl, a.base)
+	a.script.Start(monitor.SubscribeMessages())
+
+	return nil
+}
+
+// joinFromBrowser 浏览器参与者用独立的 MQTT 连接加入房间（像其他跟随端一样出现在在线状态中），直到浏览器断开
+func (a *App) joinFromBrowser(conn *websocket.Conn, name string) {
+	cfg := a.cfg
+	clientID := fmt.Sprintf("%s-web-%d", cfg.MQTTClientID, time.Now().UnixNano())
+	willTopic, willPayload := sync.LeaveMessage(clientID, name)
+	mqttClient, err := sync.NewMQTTClient(sync.MQTTConfig{
+		Broker:       cfg.MQTTBroker,
+		ClientID:     clientID,
+		Topic:        cfg.MQTTTopic,
+		WillSubtopic: willTopic,
+		WillPayload:  willPayload,
+		Logger:       a.base,
+	})
+	if err != nil {
+		a.logger.Warn("浏览器参与者无法连接 MQTT", "name", name, "err", err)
+		return
+	}
+	defer mqttClient.Close()
+
+	// 退出时断开浏览器，让参与者离开房间
+	stop := context.AfterFunc(a.ctx, func() { conn.Close() })
+	defer stop()
+
+	participant := sync.NewBrowserParticipant(conn, mqttClient, clientID, name, a.streamServer.GetPaths(), cfg.VideoDuration, a.base)
+	if err := participant.Serve(); err != nil {
+		a.logger.Warn("浏览器参与者退出", "name", name, "err", err)
+	}
+}
+
+// startSyncplay 按配置加入 Syncplay 服务器的房间和/或启动内嵌的 Syncplay 服务器（只在本机是房主时监听），失败时只记录警告
+func (a *App) startSyncplay(remote *sync.Remote) {
+	cfg := sync.SyncplayConfig{
+		Server:   a.cfg.SyncplayServer,
+		Listen:   a.cfg.SyncplayListen,
+		Room:     a.cfg.SyncplayRoom,
+		Password: a.cfg.SyncplayPassword,
+		Name:     a.opts.UserName,
+	}
+
+	if cfg.Server != "" {
+		client := sync.NewSyncplayClient(remote, cfg, a.base)
+		if err := client.Start(); err != nil {
+			a.logger.Warn("Syncplay 不可用", "err", err)
+		} else {
+			a.syncplay = client
+			a.logger.Info("Syncplay 客户端", "server", cfg.Server, "room", cfg.Room)
+		}
+	}
+
+	if cfg.Listen != "" {
+		server := sync.NewSyncplayServer(remote, cfg, a.base)
+		if err := server.Start(); err != nil {
+			a.logger.Warn("Syncplay 服务器不可用", "err", err)
+		} else {
+			a.syncplayHost = server
+			a.logger.Info("Syncplay 服务器（本机是房主时监听）", "listen", cfg.Listen, "room", cfg.Room)
+		}
+	}
+}
+
+// newRemoteToken 生成遥控页面的会话令牌或浏览器参与者的加入令牌（每次启动不同）
+func newRemoteToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// torrentStatsView 把种子统计转换为 MPV 统计面板的内容
+func torrentStatsView(stats p2p.Stats) mpv.TorrentStats {
+	view := mpv.TorrentStats{
+		DownloadRate:  stats.DownloadRate,
+		UploadRate:    stats.UploadRate,
+		Progress:      stats.Progress(),
+		BufferedAhead: stats.BufferedAhead,
+		Playhead:      stats.Playhead,
+		Pieces:        make([]mpv.PieceState, len(stats.Pieces)),
+	}
+	for i, piece := range stats.Pieces {
+		switch {
+		case piece.Complete:
+			view.Pieces[i] = mpv.PieceComplete
+		case piece.Peers > 0:
+			view.Pieces[i] = mpv.PieceAvailable
+		default:
+			view.Pieces[i] = mpv.PieceMissing
+		}
+	}
+	for _, peer := range stats.Peers {
+		name := peer.Addr
+		if peer.Client != "" {
+			name += " (" + peer.Client + ")"
+		}
+		view.Peers = append(view.Peers, mpv.PeerInfo{
+			Name:         name,
+			Room:         peer.Room,
+			Progress:     peer.Progress,
+			DownloadRate: peer.DownloadRate,
+			UploadRate:   peer.UploadRate,
+		})
+	}
+	return view
+}
+
+// shutdown 按顺序关闭：通知离开、停止同步、退出 MPV、关闭 HTTP 服务、做种后关闭种子
+func (a *App) shutdown() {
+	a.logger.Info("正在退出")
+	a.cancel()
+
+	// 1. 通知其他人自己已离开（需在断开 MQTT 之前）
+	if a.presence != nil {
+		a.presence.Leave()
+	}
+
+	// 2. 停止控制 API、房间功能和同步（都会向 MQTT 和 MPV 发送，需在断开之前）
+	if a.keyActions != nil {
+		a.keyActions.Stop()
+	}
+	if a.script != nil {
+		a.script.Stop()
+	}
+	if a.rpcServer != nil {
+		a.rpcServer.Close()
+	}
+	if a.syncplay != nil {
+		a.syncplay.Stop()
+	}
+	if a.syncplayHost != nil {
+		a.syncplayHost.Stop()
+	}
+	if a.remote != nil {
+		a.remote.Stop()
+	}
+	if a.reactor != nil {
+		a.reactor.Stop()
+	}
+	if a.chat != nil {
+		a.chat.Stop()
+	}
+	if a.subtitles != nil {
+		a.subtitles.Stop()
+	}
+	if a.votes != nil {
+		a.votes.Stop()
+	}
+	if a.room != nil {
+		a.room.Stop()
+	}
+	if a.timeline != nil {
+		a.timeline.Close()
+	}
+	if a.recorder != nil {
+		a.recorder.Close()
+	}
+	if a.mqttClient != nil {
+		a.mqttClient.Close()
+	}
+
+	// 3. 退出 MPV（先通过 IPC 请求退出，超时后结束进程）
+	if a.monitor != nil {
+		a.monitor.Stop()
+	}
+	if a.mpvCtrl != nil {
+		a.mpvCtrl.Quit()
+		a.mpvCtrl.Close()
+	}
+	if a.stopMPV != nil {
+		select {
+		case <-a.mpvDone:
+		case <-time.After(shutdownTimeout):
+			a.logger.Warn("MPV 未响应退出命令，强制结束")
+		}
+		a.stopMPV()
+		<-a.mpvDone
+	}
+
+	// 4. 关闭 HTTP 服务
+	if a.streamServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		if err := a.streamServer.Shutdown(ctx); err != nil {
+			a.logger.Warn("流服务关闭失败", "err", err)
+		}
+		cancel()
+	}
+
+	// 5. 做种（可按 Ctrl+C 提前结束），然后关闭种子
+	if a.p2pClient != nil {
+		if a.seed {
+			a.p2pClient.Seed(a.runCtx)
+		}
+		a.p2pClient.Close()
+	}
+	a.logger.Info("已退出")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"movie-night/config"
+	"movie-night/model"
+	"movie-night/pkg/mpv"
+	"movie-night/pkg/mpv/mpvtest"
+	"movie-night/sync"
+)
+
+func TestShutdownStopsComponents(t *testing.T) {
+	server, err := mpvtest.NewServer(filepath.Join(t.TempDir(), "mpv.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	server.Set("duration", 600.0)
+	broker := sync.NewMemoryBroker()
+	before := runtime.NumGoroutine()
+
+	// 按 start 的方式组装房间里的组件（不含 P2P、流服务和 MPV 进程）
+	a := NewApp(Options{IsController: true, UserName: "Alice"}, &config.Config{}, nil)
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	if a.mpvCtrl, err = mpv.NewController(server.SocketPath); err != nil {
+		t.Fatal(err)
+	}
+	if a.monitor, err = mpv.NewMonitor(server.SocketPath, nil); err != nil {
+		t.Fatal(err)
+	}
+	a.monitor.Start()
+	a.mqttClient = sync.NewMQTTClientWithTransport(broker.Connect(), "video/control", nil)
+
+	a.presence = sync.NewPresence(a.mqttClient, "alice", "Alice", model.RoleHost, nil)
+	if err := a.presence.Start(); err != nil {
+		t.Fatal(err)
+	}
+	a.room = sync.NewRoom(sync.RoomConfig{
+		MQTTClient:  a.mqttClient,
+		MPVCtrl:     a.mpvCtrl,
+		Monitor:     a.monitor,
+		Presence:    a.presence,
+		Policy:      model.RoomPolicy{Mode: model.ControlHostOnly},
+		Interval:    time.Hour,
+		MaxDuration: 600,
+	})
+	if err := a.room.Start(true); err != nil {
+		t.Fatal(err)
+	}
+	a.votes = sync.NewVoteDisplay(a.mqttClient, a.mpvCtrl)
+	a.timeline = sync.NewReactionTimeline()
+	a.reactor = sync.NewReactor(a.mqttClient, mpv.NewReactionRenderer(a.mpvCtrl), a.timeline, a.monitor.Subscribe(), "Alice", nil)
+	a.subtitles = sync.NewSubtitleShare(a.room, a.mqttClient, a.mpvCtrl, nil, nil, t.TempDir(), nil)
+	a.chat = sync.NewChat(a.mqttClient, a.mpvCtrl, a.presence.Self(), nil)
+	for _, start := range []func() error{a.votes.Start, a.reactor.Start, a.subtitles.Start, a.chat.Start} {
+		if err := start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	a.remote = sync.NewRemote(a.room, a.presence, a.chat, a.subtitles, a.mpvCtrl, []string{"movie.mkv"}, nil)
+	a.remote.Start(a.monitor.Subscribe())
+	a.keyActions = sync.NewKeyActions(a.room, a.reactor, a.presence, a.mpvCtrl, a.monitor.Subscribe(), nil)
+	a.keyActions.Start(a.monitor.SubscribeMessages())
+	a.script = sync.NewScriptBridge(a.room, a.presence, a.mpvCtrl, nil)
+	a.script.Start(a.monitor.SubscribeMessages())
+
+	// 打开定时刷新的房间成员面板
+	server.SendMessage(mpv.MessageTarget, sync.KeyActionToggleOverlay)
+	if _, err := server.WaitCommand("osd-overlay", time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	a.shutdown()
+
+	// 所有组件都在 MPV 退出之前停止，之后不再向 MPV 发送命令
+	if _, err := server.WaitCommand("quit", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if cmd, err := server.WaitCommand("osd-overlay", 2500*time.Millisecond); err == nil {
+		t.Errorf("command after quit: %v", cmd)
+	}
+
+	// 组件的后台 goroutine 都已退出
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines leaked:\n%s", runtime.NumGoroutine()-before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
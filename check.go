@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"movie-night/config"
+	"movie-night/p2p"
+)
+
+// checkCacheSize 健康检查时的内存缓存上限（不写入下载目录）
+const checkCacheSize = 256 << 20
+
+// runCheck 开场前检查片源：获取元数据，采样 Peer 的分块可用性和下载速度，
+// 估算多久后能开始播放、能否在电影结束前下载完（不健康时退出码为 1）
+func runCheck(args []string) {
+	cfg := config.Default()
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	magnet := flags.String("magnet", cfg.MagnetLink, "要检查的磁力链")
+	sample := flags.Duration("sample", 20*time.Second, "采样下载速度的时长")
+	runtime := flags.Duration("runtime", 0, "片长（如 1h50m），0 表示按 2 小时估算")
+	metadataTimeout := flags.Duration("metadata-timeout", cfg.MetadataTimeout, "获取元数据的超时")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	client, err := p2p.NewClient(p2p.Config{
+		DataDir:         cfg.DataDir,
+		MaxConns:        cfg.MaxConns,
+		MagnetLink:      *magnet,
+		MetadataTimeout: *metadataTimeout,
+		Storage:         p2p.StorageWindow,
+		CacheSize:       checkCacheSize,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	if err := client.WaitInfo(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	file := client.GetLargestFile()
+	if file == nil {
+		fmt.Fprintln(os.Stderr, "❌ 未找到视频文件")
+		os.Exit(1)
+	}
+
+	fmt.Printf("🔍 %s（%.1f MB），采样 %v...\n", file.DisplayPath(), float64(file.Length())/1024/1024, *sample)
+	health := client.CheckHealth(ctx, file, p2p.HealthOptions{
+		Sample:    *sample,
+		Runtime:   *runtime,
+		Prebuffer: time.Duration(cfg.PrebufferSeconds * float64(time.Second)),
+	})
+	fmt.Println(health)
+
+	switch {
+	case health.Missing > 0:
+		fmt.Printf("❌ %d 个分块目前没有任何来源，无法完整播放\n", health.Missing)
+	case !health.FinishesInTime:
+		fmt.Println("⚠️  下载速度跟不上播放，观看中可能需要缓冲")
+	default:
+		fmt.Println("✅ 片源健康")
+	}
+
+	if !health.Healthy() {
+		client.Close()
+		os.Exit(1)
+	}
+}
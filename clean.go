@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"movie-night/config"
+	"movie-night/p2p"
+)
+
+// keepDirs 清理时保留的目录（表情反应时间轴）
+var keepDirs = map[string]bool{"reactions": true}
+
+// runClean 删除已结束会话的数据：DataDir 中已下载完成的种子数据和分块缓存
+// 未下载完成的数据和会话状态（元数据缓存、分块完成状态）默认保留，-all 时除表情反应时间轴外全部删除
+func runClean(args []string) {
+	cfg := config.Default()
+	flags := flag.NewFlagSet("clean", flag.ExitOnError)
+	dataDir := flags.String("data-dir", cfg.DataDir, "下载目录")
+	cacheDir := flags.String("cache-dir", cfg.CacheDir, "分块缓存目录（-storage window 时使用）")
+	all := flags.Bool("all", false, "同时删除未下载完成的数据和会话状态")
+	dryRun := flags.Bool("n", false, "只列出将删除的内容")
+	flags.Parse(args)
+
+	var total int64
+	if *all {
+		total += cleanDir(*dataDir, *dryRun)
+	} else {
+		total += cleanCompleted(*dataDir, *dryRun)
+	}
+	// 分块缓存每次启动时都会重建，总是可以删除
+	total += cleanDir(*cacheDir, *dryRun)
+
+	if *dryRun {
+		fmt.Printf("共 %.1f MB（未删除）\n", float64(total)/1024/1024)
+	} else {
+		fmt.Printf("已释放 %.1f MB\n", float64(total)/1024/1024)
+	}
+}
+
+// cleanCompleted 删除 DataDir 中已下载完成的种子数据，返回释放的大小
+func cleanCompleted(dataDir string, dryRun bool) int64 {
+	session, err := p2p.OpenSession(dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 0
+	}
+	defer session.Close()
+
+	downloads, err := session.Downloads()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 0
+	}
+
+	var total int64
+	for _, d := range downloads {
+		size := diskUsage(d.Path)
+		if size == 0 {
+			continue
+		}
+		if !d.Complete {
+			fmt.Printf("⏭️  %8.1f MB  %s（未下载完成，保留）\n", float64(size)/1024/1024, d.Path)
+			continue
+		}
+
+		if dryRun {
+			fmt.Printf("%8.1f MB  %s\n", float64(size)/1024/1024, d.Path)
+		} else if err := session.Remove(d); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 删除失败: %v\n", err)
+			continue
+		} else {
+			fmt.Printf("🗑️  %8.1f MB  %s\n", float64(size)/1024/1024, d.Path)
+		}
+		total += size
+	}
+	return total
+}
+
+// cleanDir 删除目录下除 keepDirs 以外的所有内容，返回释放的大小
+func cleanDir(dir string, dryRun bool) int64 {
+	if dir == "" {
+		return 0
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		}
+		return 0
+	}
+
+	var total int64
+	for _, entry := range entries {
+		if keepDirs[entry.Name()] {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		size := diskUsage(path)
+
+		if dryRun {
+			fmt.Printf("%8.1f MB  %s\n", float64(size)/1024/1024, path)
+		} else if err := os.RemoveAll(path); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ 删除失败: %v\n", err)
+			continue
+		} else {
+			fmt.Printf("🗑️  %8.1f MB  %s\n", float64(size)/1024/1024, path)
+		}
+		total += size
+	}
+	return total
+}
+
+// diskUsage 文件或目录的总大小
+func diskUsage(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"movie-night/p2p"
+	"movie-night/p2p/p2ptest"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+)
+
+func TestCleanKeepsUnfinishedDownloads(t *testing.T) {
+	dataDir := t.TempDir()
+	session := filepath.Join(dataDir, ".session")
+	completion, err := storage.NewBoltPieceCompletion(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 像播放器一样缓存元数据并记录分块完成状态：finished 全部完成，partial 只完成第一块
+	for name, done := range map[string]int{"finished.mkv": -1, "partial.mkv": 1} {
+		mi := p2ptest.NewTorrent(t, dataDir, name, 1<<20)
+		f, err := os.Create(filepath.Join(session, mi.HashInfoBytes().HexString()+".torrent"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mi.Write(f)
+		f.Close()
+
+		info, _ := mi.UnmarshalInfo()
+		if done < 0 {
+			done = info.NumPieces()
+		}
+		for i := 0; i < done; i++ {
+			completion.Set(metainfo.PieceKey{InfoHash: mi.HashInfoBytes(), Index: i}, true)
+		}
+	}
+	completion.Close()
+	if err := os.MkdirAll(filepath.Join(dataDir, "reactions"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dataDir, name))
+		return err == nil
+	}
+
+	// 默认只删除下载完成的数据
+	runClean([]string{"-data-dir", dataDir, "-cache-dir", ""})
+	if exists("finished.mkv") {
+		t.Error("finished download should be removed")
+	}
+	for _, name := range []string{"partial.mkv", ".session", "reactions"} {
+		if !exists(name) {
+			t.Errorf("%s should be kept without -all", name)
+		}
+	}
+
+	// 删除后不再记为已完成，再次观看时会重新下载
+	s, err := p2p.OpenSession(dataDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	downloads, err := s.Downloads()
+	s.Close()
+	if err != nil || len(downloads) != 2 {
+		t.Fatalf("got %v (%v), want both cached torrents", downloads, err)
+	}
+	for _, d := range downloads {
+		if d.Complete {
+			t.Errorf("%s still marked complete", d.Name)
+		}
+	}
+
+	// -all 删除除表情反应时间轴以外的所有内容
+	runClean([]string{"-data-dir", dataDir, "-cache-dir", "", "-all"})
+	entries, _ := os.ReadDir(dataDir)
+	if len(entries) != 1 || entries[0].Name() != "reactions" {
+		t.Errorf("got %v, want only reactions after -all", entries)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"movie-night/sync"
+)
+
+// 网络模拟：在虚拟时钟上跑房主和若干跟随端，打印每个场景的收敛时间和最大偏差，
+// 用于调整同步阈值（不需要 MPV 和 MQTT Broker）
+func main() {
+	var followers int
+	var seed int64
+	var only string
+	var interval time.Duration
+	var tolerance float64
+	var verbose bool
+	flag.IntVar(&followers, "followers", 4, "跟随端数量")
+	flag.Int64Var(&seed, "seed", 1, "随机种子（相同种子结果相同）")
+	flag.StringVar(&only, "scenario", "", "只运行名称包含该字符串的场景")
+	flag.DurationVar(&interval, "interval", 0, "覆盖房主定时广播的间隔")
+	flag.Float64Var(&tolerance, "tolerance", 0, "覆盖视为已同步的偏差（秒）")
+	flag.BoolVar(&verbose, "v", false, "输出同步组件的日志")
+	flag.Parse()
+
+	logger := slog.Default()
+	if !verbose {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	ran := 0
+	for _, sc := range sync.DefaultScenarios(followers) {
+		if only != "" && !strings.Contains(sc.Name, only) {
+			continue
+		}
+		sc.Seed = seed
+		sc.Logger = logger
+		if interval > 0 {
+			sc.Interval = interval
+		}
+		if tolerance > 0 {
+			sc.Tolerance = tolerance
+		}
+		fmt.Println(sync.RunScenario(sc))
+		ran++
+	}
+
+	if ran == 0 {
+		fmt.Fprintf(os.Stderr, "没有匹配 %q 的场景\n", only)
+		os.Exit(1)
+	}
+}
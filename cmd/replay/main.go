@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"movie-night/config"
+	"movie-night/model"
+	"movie-night/pkg/mpv"
+	"movie-night/pkg/mpv/mpvtest"
+	"movie-night/sync"
+)
+
+// 回放会话记录：把记录中收到的房主状态依次交给同步器，
+// 同步器的命令发到模拟的 MPV，打印出每个状态触发了哪些命令。
+// 同步器使用按记录时间走动的虚拟时钟，记录的本地播放状态在每个房主状态之前写入模拟的 MPV，
+// 因此同一份记录每次回放的结果都相同，并能重现记录时的偏差
+func main() {
+	var recordPath, topic, socketPath string
+	var duration float64
+	flag.StringVar(&recordPath, "file", "", "会话记录文件（-record 生成的 JSONL）")
+	flag.StringVar(&topic, "topic", config.Default().MQTTTopic, "播放状态的主题")
+	flag.StringVar(&socketPath, "socket", filepath.Join(os.TempDir(), "mpv-replay.sock"), "模拟 MPV 的 Socket 路径")
+	flag.Float64Var(&duration, "duration", 0, "模拟的视频时长（秒），0 表示按记录推算")
+	flag.Parse()
+
+	if recordPath == "" {
+		log.Fatal("❌ 请用 -file 指定会话记录")
+	}
+
+	records, err := sync.ReadRecords(recordPath)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Printf("📂 载入 %d 条记录\n", len(records))
+
+	if duration == 0 {
+		duration = estimateDuration(records, topic)
+	}
+
+	// 1. 启动模拟 MPV
+	server, err := mpvtest.NewServer(socketPath)
+	if err != nil {
+		log.Fatalf("❌ 模拟 MPV 启动失败: %v", err)
+	}
+	defer server.Close()
+	server.Set("duration", duration)
+
+	mpvCtrl, err := mpv.NewController(socketPath)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	defer mpvCtrl.Close()
+
+	if len(records) == 0 {
+		return
+	}
+	start := records[0].At
+	clock := sync.NewVirtualClock(time.UnixMilli(start))
+	player := &recordingPlayer{Player: mpvCtrl}
+	syncer := sync.NewSyncer(player, duration, nil)
+	syncer.SetClock(clock)
+
+	// 2. 按记录顺序回放
+	var (
+		replayed   int
+		rejected   int
+		drifts     []float64
+		local      *model.PlayStatus // 最近记录的本地播放状态
+		localAt    time.Time
+		commandCnt = make(map[string]int)
+	)
+
+	for _, record := range records {
+		clock.AdvanceTo(time.UnixMilli(record.At))
+
+		switch {
+		case record.Kind == model.RecordLocal && record.Status != nil:
+			// 模拟的 MPV 回到记录时的本地状态
+			local, localAt = record.Status, clock.Now()
+			server.Set("time-pos", local.Timestamp)
+			server.Set("pause", local.Paused)
+
+		case record.Kind == model.RecordDrift:
+			drifts = append(drifts, record.Drift)
+			fmt.Printf("           📏 记录的偏差: %+.2f秒\n", record.Drift)
+
+		case record.Kind == model.RecordRecv && record.Topic == topic:
+			var status model.PlayStatus
+			if err := json.Unmarshal(record.Payload, &status); err != nil {
+				continue
+			}
+
+			emoji := "▶️"
+			if status.Paused {
+				emoji = "⏸️"
+			}
+			fmt.Printf("[+%8.3fs] 📥 %.2f秒 %s (任期 %d)\n", float64(record.At-start)/1000, status.Timestamp, emoji, status.Term)
+			if local != nil {
+				position := local.Timestamp
+				if !local.Paused {
+					position += clock.Now().Sub(localAt).Seconds() * local.Rate()
+				}
+				fmt.Printf("           🎞️  本地 %.2f秒 (偏差 %+.2f秒)\n", position, position-status.Timestamp)
+			}
+
+			replayed++
+			if err := syncer.Apply(status); err != nil {
+				rejected++
+				fmt.Printf("           ⚠️  拒绝: %v\n", err)
+				continue
+			}
+
+			for _, cmd := range player.take() {
+				name := fmt.Sprint(cmd[0])
+				commandCnt[name]++
+				fmt.Printf("           → %v\n", cmd)
+			}
+		}
+	}
+
+	// 3. 汇总
+	fmt.Println("\n📊 回放汇总")
+	fmt.Printf("   状态: %d 条 (拒绝 %d)\n", replayed, rejected)
+
+	names := make([]string, 0, len(commandCnt))
+	for name := range commandCnt {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("   命令 %s: %d 次\n", name, commandCnt[name])
+	}
+
+	if len(drifts) > 0 {
+		var sum, worst float64
+		for _, d := range drifts {
+			sum += math.Abs(d)
+			if math.Abs(d) > math.Abs(worst) {
+				worst = d
+			}
+		}
+		fmt.Printf("   记录的偏差: %d 次, 平均 %.2f秒, 最大 %+.2f秒\n", len(drifts), sum/float64(len(drifts)), worst)
+	}
+}
+
+// estimateDuration 按记录中出现过的最大位置推算视频时长
+func estimateDuration(records []model.Record, topic string) float64 {
+	var max float64
+	for _, record := range records {
+		var status model.PlayStatus
+		switch {
+		case record.Kind == model.RecordLocal && record.Status != nil:
+			status = *record.Status
+		case record.Kind == model.RecordRecv && record.Topic == topic:
+			json.Unmarshal(record.Payload, &status)
+		default:
+			continue
+		}
+		if status.Timestamp > max {
+			max = status.Timestamp
+		}
+	}
+	return math.Ceil(max) + 1
+}
+
+// recordingPlayer 记录同步器发出的命令（调用时同步记录，不依赖模拟 MPV 收到命令的时机）
+type recordingPlayer struct {
+	sync.Player
+	commands [][]interface{}
+}
+
+// take 取出记录的命令
+func (p *recordingPlayer) take() [][]interface{} {
+	commands := p.commands
+	p.commands = nil
+	return commands
+}
+
+func (p *recordingPlayer) record(cmd ...interface{}) {
+	p.commands = append(p.commands, cmd)
+}
+
+func (p *recordingPlayer) Seek(seconds float64, mode string) error {
+	p.record("seek", seconds, mode)
+	return p.Player.Seek(seconds, mode)
+}
+
+func (p *recordingPlayer) Pause() error {
+	p.record("set_property", "pause", true)
+	return p.Player.Pause()
+}
+
+func (p *recordingPlayer) Play() error {
+	p.record("set_property", "pause", false)
+	return p.Player.Play()
+}
+
+func (p *recordingPlayer) ShowText(text string, duration int) error {
+	p.record("show-text", text, duration)
+	return p.Player.ShowText(text, duration)
+}
+
+func (p *recordingPlayer) SetProperty(name string, value interface{}) error {
+	p.record("set_property", name, value)
+	return p.Player.SetProperty(name, value)
+}
+
+func (p *recordingPlayer) SetSpeed(speed float64) error {
+	p.record("set_property", "speed", speed)
+	return p.Player.SetSpeed(speed)
+}
+
+func (p *recordingPlayer) SetAudioTrack(id string) error {
+	p.record("set_property", "aid", id)
+	return p.Player.SetAudioTrack(id)
+}
+
+func (p *recordingPlayer) SetChapter(index int) error {
+	p.record("set_property", "chapter", index)
+	return p.Player.SetChapter(index)
+}
+
+func (p *recordingPlayer) SetPlaylistPos(index int) error {
+	p.record("set_property", "playlist-pos", index)
+	return p.Player.SetPlaylistPos(index)
+}
+
+func (p *recordingPlayer) PlaylistNext() error {
+	p.record("playlist-next", "force")
+	return p.Player.PlaylistNext()
+}
@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 // Config 应用配置
 type Config struct {
 	// P2P 配置
@@ -7,13 +9,51 @@ type Config struct {
 	DataDir    string
 	MaxConns   int
 
+	// 获取元数据的超时（Tracker 失效时不会一直等下去），0 表示一直等待
+	MetadataTimeout time.Duration
+
+	// 带宽限制（字节/秒，0 表示不限）
+	UploadLimit   int64
+	DownloadLimit int64
+
+	// 播放结束后做种：达到分享率或时长后停止，都为 0 表示立即停止
+	SeedRatio float64
+	SeedTime  time.Duration
+
+	// 房间参与者缺数据时优先上传给他们
+	RoomFirstUpload bool
+
+	// 存储模式：full 完整下载到 DataDir，window 只保留播放位置附近不超过 CacheSize 字节的分块
+	// （CacheDir 为空时缓存在内存中）
+	Storage   string
+	CacheSize int64
+	CacheDir  string
+
 	// HTTP 配置
 	StreamPort int
 
+	// 本地控制 API 的 Unix socket 路径（为空时不提供）
+	RPCSocketPath string
+
+	// Syncplay 兼容：SyncplayServer 为要加入的 Syncplay 服务器（host:port），SyncplayListen 为内嵌服务器的监听地址，都为空时不启用
+	SyncplayServer   string
+	SyncplayListen   string
+	SyncplayRoom     string
+	SyncplayPassword string
+
 	// MPV 配置
 	MPVSocketPath string
 	VideoDuration float64
 
+	// 中途加入时预缓冲的时长（秒）
+	PrebufferSeconds float64
+
+	// 播放结束后切到下一项前的幕间时长（秒）
+	IntermissionSeconds int
+
+	// MPV 按键（动作 -> 按键名）
+	KeyBindings map[string]string
+
 	// MQTT 配置
 	MQTTBroker   string
 	MQTTClientID string
@@ -27,14 +67,35 @@ func Default() *Config {
 		MagnetLink: "magnet:?xt=urn:btih:JEJJEE6LGDVRMHT7XVJGJ74BKVW6WL2M&dn=&tr=http%3A%2F%2F104.143.10.186%3A8000%2Fannounce&tr=udp%3A%2F%2F104.143.10.186%3A8000%2Fannounce",
 		DataDir:    "./downloads",
 		MaxConns:   50,
+		Storage:    "full",
+		CacheSize:  2 << 30,
+
+		MetadataTimeout: 2 * time.Minute,
 
 		// HTTP
 		StreamPort: 8888,
 
+		RPCSocketPath: "/tmp/movie-night.sock",
+
+		SyncplayRoom: "movie-night",
+
 		// MPV
 		MPVSocketPath: "/tmp/mpv-socket",
 		VideoDuration: 0, // 0 表示不限制
 
+		PrebufferSeconds:    30,
+		IntermissionSeconds: 10,
+
+		KeyBindings: map[string]string{
+			"request-pause":  "Ctrl+p",
+			"toggle-overlay": "Ctrl+o",
+			"toggle-stats":   "Ctrl+t",
+			"ready":          "Ctrl+r",
+			"react-laugh":    "Ctrl+1",
+			"react-shock":    "Ctrl+2",
+			"react-applause": "Ctrl+3",
+		},
+
 		// MQTT
 		MQTTBroker:   "tcp://broker-cn.emqx.io:1883",
 		MQTTClientID: "video-client",
@@ -1,141 +1,233 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
-	"time"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 
 	"movie-night/config"
-	"movie-night/p2p"
-	"movie-night/pkg/mpv"
+	"movie-night/model"
+	"movie-night/pkg/logging"
 	"movie-night/sync"
+
+	"github.com/skip2/go-qrcode"
 )
 
 func main() {
+	// 子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "check":
+			runCheck(os.Args[2:])
+			return
+		case "clean":
+			runClean(os.Args[2:])
+			return
+		}
+	}
+
 	// ===== 解析命令行参数 =====
 	var isController bool
+	var userName string
+	var replayReactions bool
+	var controlMode string
+	var quorum float64
+	var trackSync string
+	var playlist bool
+	var recordPath string
+	var logCfg logging.Config
+	cfg := config.Default()
 	flag.BoolVar(&isController, "controller", false, "作为控制端（房主）运行")
+	flag.StringVar(&userName, "name", defaultUserName(), "显示给其他人的名称")
+	flag.BoolVar(&replayReactions, "replay-reactions", false, "重看时回放本片之前的表情反应")
+	flag.StringVar(&controlMode, "control", string(model.ControlHostOnly), "房间控制模式（房主设置）: host-only / anyone / vote")
+	flag.Float64Var(&quorum, "quorum", 0.5, "投票模式下需要同意的人数比例 (0-1]")
+	flag.StringVar(&trackSync, "sync", "speed", "全房间同步的播放属性（房主设置）: speed,aid,sid 的组合，none 表示都不同步")
+	flag.BoolVar(&playlist, "playlist", false, "按文件名顺序播放种子中的所有视频（默认只播放最大的文件）")
+	flag.StringVar(&recordPath, "record", "", "把同步消息、本地播放状态和偏差记录到该 JSONL 文件（用 cmd/replay 回放）")
+	flag.Int64Var(&cfg.UploadLimit, "upload-limit", 0, "上传限速（KiB/s），0 表示不限")
+	flag.Int64Var(&cfg.DownloadLimit, "download-limit", 0, "下载限速（KiB/s），0 表示不限")
+	flag.Float64Var(&cfg.SeedRatio, "seed-ratio", 0, "播放结束后做种到该分享率")
+	flag.DurationVar(&cfg.SeedTime, "seed-time", 0, "播放结束后做种的最长时间（如 30m），与 -seed-ratio 都为 0 时立即退出")
+	flag.BoolVar(&cfg.RoomFirstUpload, "room-first", false, "房间参与者缺数据时优先上传给他们（断开只下载不上传的外部连接）")
+	flag.DurationVar(&cfg.MetadataTimeout, "metadata-timeout", cfg.MetadataTimeout, "获取元数据的超时，0 表示一直等待")
+	flag.StringVar(&cfg.Storage, "storage", cfg.Storage, "存储模式: full（完整下载）/ window（只保留播放位置附近的分块）")
+	flag.Int64Var(&cfg.CacheSize, "cache-size", cfg.CacheSize>>20, "window 模式的缓存上限（MiB）")
+	flag.StringVar(&cfg.CacheDir, "cache-dir", "", "window 模式的缓存目录，为空时缓存在内存中")
+	flag.StringVar(&cfg.RPCSocketPath, "rpc-socket", cfg.RPCSocketPath, "本地控制 API（JSON-RPC）的 Unix socket 路径，为空时不提供")
+	flag.StringVar(&cfg.SyncplayServer, "syncplay", "", "以 Syncplay 用户身份加入该 Syncplay 服务器（host:port）的房间")
+	flag.StringVar(&cfg.SyncplayListen, "syncplay-listen", "", "内嵌 Syncplay 服务器的监听地址（如 :8999），只在本机是房主时监听，Syncplay 用户可直接连进本房间")
+	flag.StringVar(&cfg.SyncplayRoom, "syncplay-room", cfg.SyncplayRoom, "Syncplay 房间名")
+	flag.StringVar(&cfg.SyncplayPassword, "syncplay-password", "", "Syncplay 服务器密码")
+	flag.StringVar(&logCfg.Level, "log-level", "info", "日志级别: debug / info / warn / error")
+	flag.BoolVar(&logCfg.JSON, "log-json", false, "以 JSON 格式输出日志")
+	flag.StringVar(&logCfg.File, "log-file", "", "同时把日志写入该文件")
 	flag.Parse()
+	cfg.UploadLimit *= 1024
+	cfg.DownloadLimit *= 1024
+	cfg.CacheSize <<= 20
+
+	// 日志需在创建任何组件之前设置
+	logger, logCloser, err := logging.New(logCfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "日志初始化失败: %v\n", err)
+		os.Exit(2)
+	}
+	defer logCloser.Close()
+	slog.SetDefault(logger)
+
+	policy := model.RoomPolicy{Mode: model.ControlMode(controlMode), Quorum: quorum}
+	if !policy.Mode.IsValid() {
+		fatal("未知的控制模式", "mode", controlMode)
+	}
+	tracks, err := model.ParseTrackSync(trackSync)
+	if err != nil {
+		fatal("同步属性无效", "err", err)
+	}
+	policy.Sync = tracks
 
 	if isController {
-		fmt.Println("🎬 运行模式: 控制端（房主）\n")
+		fmt.Println("🎬 运行模式: 控制端（房主）")
 	} else {
-		fmt.Println("🎬 运行模式: 跟随端（观众）\n")
+		fmt.Println("🎬 运行模式: 跟随端（观众）")
 	}
 
-	// 1. 加载配置
-	cfg := config.Default()
+	// Ctrl+C / SIGTERM 时按顺序关闭所有组件
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// 2. 启动 P2P 客户端
-	p2pClient, err := p2p.NewClient(p2p.Config{
-		DataDir:    cfg.DataDir,
-		MaxConns:   cfg.MaxConns,
-		MagnetLink: cfg.MagnetLink,
-	})
-	if err != nil {
-		log.Fatalf("❌ P2P 启动失败: %v", err)
+	app := NewApp(Options{
+		IsController:    isController,
+		UserName:        userName,
+		ReplayReactions: replayReactions,
+		Policy:          policy,
+		Playlist:        playlist,
+		RecordPath:      recordPath,
+	}, cfg, logger)
+	if err := app.Run(ctx); err != nil {
+		logger.Error("启动失败", "err", err)
+		logCloser.Close()
+		os.Exit(1)
 	}
-	defer p2pClient.Close()
+}
 
-	// 3. 获取视频文件
-	videoFile := p2pClient.GetLargestFile()
-	if videoFile == nil {
-		log.Fatal("❌ 未找到视频文件")
-	}
-	fmt.Printf("📹 视频: %s\n\n", videoFile.DisplayPath())
+// fatal 记录错误并退出
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
 
-	// 4. 启动 HTTP 流服务（后台）
-	streamServer := p2p.NewStreamServer(cfg.StreamPort, videoFile)
-	go func() {
-		if err := streamServer.Start(); err != nil {
-			log.Fatal(err)
+// runConsole 终端指令（表情反应、跳过请求、转让房主、字幕、片源健康）
+func runConsole(reactor *sync.Reactor, room *sync.Room, subtitles *sync.SubtitleShare, presence *sync.Presence, chat *sync.Chat, remoteURL string) {
+	fmt.Println("💡 输入 laugh / shock / applause 发送表情反应")
+	fmt.Println("💡 输入 skip <秒> 请求跳过（需房间允许）")
+	fmt.Println("💡 房主输入 host <名称> 转让房主")
+	fmt.Println("💡 房主输入 sync <speed|aid|sid> <on|off> 开关全房间同步的播放属性")
+	fmt.Println("💡 输入 sub list 查看字幕，sub <轨道> / sub file <序号> / sub load <路径> / sub off 选择字幕，sub room 跟随房间")
+	fmt.Println("💡 输入 health 查看每个人的片源健康检查结果")
+	fmt.Println("💡 输入 say <内容> 发送聊天消息，remote 显示手机遥控页面的二维码")
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		input := strings.TrimSpace(scanner.Text())
+		if input == "" {
+			continue
 		}
-	}()
-
-	// 5. 启动 MPV（后台）
-	go func() {
-		if err := mpv.Launch(mpv.LaunchConfig{
-			VideoURL:   streamServer.GetURL(),
-			SocketPath: cfg.MPVSocketPath,
-			Title:      getTitle(isController),
-		}); err != nil {
-			log.Printf("MPV 退出: %v", err)
+
+		if arg, ok := strings.CutPrefix(input, "skip "); ok {
+			seconds, err := strconv.ParseFloat(strings.TrimSpace(arg), 64)
+			if err != nil {
+				fmt.Println("❌ 时间格式错误")
+				continue
+			}
+			if err := room.Request(model.ActionSkip, seconds); err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+			continue
 		}
-		os.Exit(0)
-	}()
 
-	// 6. 等待 MPV 启动
-	time.Sleep(2 * time.Second)
+		if arg, ok := strings.CutPrefix(input, "host "); ok {
+			if err := room.TransferHost(strings.TrimSpace(arg)); err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+			continue
+		}
 
-	// 7. 创建 MPV 控制器
-	mpvCtrl, err := mpv.NewController(cfg.MPVSocketPath)
-	if err != nil {
-		log.Fatalf("❌ MPV 控制器创建失败: %v", err)
-	}
-	defer mpvCtrl.Close()
+		if arg, ok := strings.CutPrefix(input, "sync "); ok {
+			name, state, _ := strings.Cut(strings.TrimSpace(arg), " ")
+			tracks := room.Policy().Sync
+			if err := tracks.Set(name, strings.TrimSpace(state) != "off"); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				continue
+			}
+			if err := room.SetTrackSync(tracks); err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+			continue
+		}
 
-	// ===== 8. 创建 MPV 监听器（监听播放状态）=====
-	monitor, err := mpv.NewMonitor(cfg.MPVSocketPath)
-	if err != nil {
-		log.Fatalf("❌ 创建监听器失败: %v", err)
-	}
-	defer monitor.Stop()
-	monitor.Start()
+		if arg, ok := strings.CutPrefix(input, "say "); ok {
+			if err := chat.Send(arg); err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+			continue
+		}
 
-	// 9. 获取视频时长
-	time.Sleep(1 * time.Second)
-	duration, err := mpvCtrl.GetDuration()
-	if err != nil {
-		log.Printf("⚠️  无法获取视频时长: %v", err)
-		duration = 0
-	} else {
-		fmt.Printf("📹 时长: %.0f秒 (%.1f分钟)\n\n", duration, duration/60)
+		if input == "remote" {
+			printQRCode(remoteURL)
+			continue
+		}
+
+		if input == "health" {
+			printHealth(presence)
+			continue
+		}
+
+		if input == "sub" || input == "sub list" {
+			printSubtitles(subtitles)
+			continue
+		}
+
+		if strings.HasPrefix(input, "sub ") {
+			if err := subtitles.Command(strings.TrimPrefix(input, "sub")); err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+			continue
+		}
+
+		if err := reactor.Send(model.ReactionKind(input)); err != nil {
+			fmt.Printf("❌ %v\n", err)
+		}
 	}
-	cfg.VideoDuration = duration
-
-	// 10. 连接 MQTT
-	mqttClient, err := sync.NewMQTTClient(sync.MQTTConfig{
-		Broker:   cfg.MQTTBroker,
-		ClientID: fmt.Sprintf("%s-%d", cfg.MQTTClientID, time.Now().Unix()),
-		Topic:    cfg.MQTTTopic,
-	})
+}
+
+// printQRCode 在终端打印地址的二维码（手机扫码打开）
+func printQRCode(url string) {
+	code, err := qrcode.New(url, qrcode.Low)
 	if err != nil {
-		log.Fatalf("❌ MQTT 连接失败: %v", err)
+		fmt.Printf("❌ 生成二维码失败: %v\n", err)
+		return
 	}
-	defer mqttClient.Close()
+	fmt.Print(code.ToSmallString(false))
+	fmt.Println(url)
+}
 
-	// ===== 11. 根据角色启动不同逻辑 =====
-	if isController {
-		// ===== 传入原始 client 和 topic =====
-		// 需要修改 NewMQTTClient 返回原始 client
-		// 或者创建一个 GetClient() 方法
-
-		// 方式 A：修改 MQTTClient 添加 GetClient 方法
-		controller := sync.NewController(
-			mqttClient.GetClient(), // 获取原始 client
-			cfg.MQTTTopic,
-			monitor,
-			10*time.Second,
-		)
-		go controller.Start()
-	} else {
-		follower := sync.NewFollower(mpvCtrl, mqttClient, cfg.VideoDuration)
-		follower.Start()
+// defaultUserName 默认显示名称
+func defaultUserName() string {
+	if name := os.Getenv("USER"); name != "" {
+		return name
 	}
-
-	// 12. 启动 P2P 统计推送
-	statsPusher := p2p.NewStatsPusher(p2pClient.GetTorrent(), cfg.MPVSocketPath)
-	go func() {
-		if err := statsPusher.Start(); err != nil {
-			log.Printf("⚠️  统计推送失败: %v", err)
-		}
-	}()
-
-	// 13. 保持运行
-	fmt.Println("⏳ 运行中，按 Ctrl+C 退出\n")
-	select {}
+	if host, err := os.Hostname(); err == nil {
+		return host
+	}
+	return "guest"
 }
 
 // getTitle 获取窗口标题
@@ -145,3 +237,38 @@ func getTitle(isController bool) string {
 	}
 	return "P2P 同步播放器（跟随端）"
 }
+
+// printHealth 打印每个参与者的片源健康检查结果
+func printHealth(presence *sync.Presence) {
+	for _, p := range presence.Participants() {
+		switch {
+		case p.Health == nil:
+			fmt.Printf("⏳ %s: 检查中\n", p.Name)
+		case p.Health.Healthy():
+			fmt.Printf("✅ %s: %s\n", p.Name, p.Health)
+		default:
+			fmt.Printf("⚠️  %s: %s\n", p.Name, p.Health)
+		}
+	}
+}
+
+// printSubtitles 打印可选的字幕
+func printSubtitles(subtitles *sync.SubtitleShare) {
+	fmt.Printf("💬 房间字幕: %s\n", subtitles.Current().Describe())
+
+	tracks, err := subtitles.Tracks()
+	if err != nil {
+		fmt.Printf("⚠️  获取字幕轨道失败: %v\n", err)
+	}
+	for _, t := range tracks {
+		mark := " "
+		if t.Selected {
+			mark = "*"
+		}
+		fmt.Printf("  %s sub %d    %s\n", mark, t.ID, t.Label())
+	}
+
+	for i, path := range subtitles.Files() {
+		fmt.Printf("    sub file %d    %s\n", i+1, path)
+	}
+}
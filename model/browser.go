@@ -0,0 +1,29 @@
+package model
+
+// BrowserMessageKind 浏览器参与者与本程序之间的消息类型（WebSocket）
+type BrowserMessageKind string
+
+const (
+	// 本程序 -> 浏览器
+	BrowserLoad    BrowserMessageKind = "load"    // 播放 Src
+	BrowserSeek    BrowserMessageKind = "seek"    // 跳转到 Position（设置 currentTime）
+	BrowserPause   BrowserMessageKind = "pause"   // 暂停
+	BrowserPlay    BrowserMessageKind = "play"    // 播放
+	BrowserRate    BrowserMessageKind = "rate"    // 设置 playbackRate（房间的播放速度）
+	BrowserText    BrowserMessageKind = "text"    // 显示提示 Text，持续 Duration 毫秒
+	BrowserOverlay BrowserMessageKind = "overlay" // 显示常驻的 Text（为空时隐藏），如幕间倒计时
+
+	// 浏览器 -> 本程序
+	BrowserStatus BrowserMessageKind = "status" // 本地播放状态（定期及状态变化时发送）
+)
+
+// BrowserMessage 浏览器参与者的消息
+type BrowserMessage struct {
+	Kind     BrowserMessageKind `json:"kind"`
+	Src      string             `json:"src,omitempty"`
+	Position float64            `json:"position,omitempty"` // 播放位置（秒）
+	Paused   bool               `json:"paused,omitempty"`
+	Duration float64            `json:"duration,omitempty"` // status: 视频时长（秒）；text: 显示时长（毫秒）
+	Rate     float64            `json:"rate,omitempty"`
+	Text     string             `json:"text,omitempty"`
+}
@@ -0,0 +1,11 @@
+package model
+
+// CatchUpProgress 中途加入者的追赶进度（跟随端 -> 房主）
+type CatchUpProgress struct {
+	ID      string  `json:"id"`      // 参与者 ID
+	Name    string  `json:"name"`    // 参与者名称
+	Target  float64 `json:"target"`  // 预缓冲的目标位置（秒）
+	Percent int     `json:"percent"` // 预缓冲进度 (0-100)
+	Done    bool    `json:"done"`    // 已完成并开始播放
+	Error   string  `json:"error,omitempty"`
+}
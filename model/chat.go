@@ -0,0 +1,12 @@
+package model
+
+// MaxChatLength 聊天消息的最大长度（字符）
+const MaxChatLength = 300
+
+// ChatMessage 聊天消息（参与者 -> 所有人）
+type ChatMessage struct {
+	From   string `json:"from"`    // 发送者 ID
+	Name   string `json:"name"`    // 发送者名称
+	Text   string `json:"text"`    // 内容
+	SentAt int64  `json:"sent_at"` // 发送时间（Unix 毫秒）
+}
@@ -0,0 +1,114 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ControlMode 房间控制模式
+type ControlMode string
+
+const (
+	ControlHostOnly ControlMode = "host-only" // 只有房主能控制
+	ControlAnyone   ControlMode = "anyone"    // 任何人的暂停/跳转都会同步给所有人
+	ControlVote     ControlMode = "vote"      // 暂停/跳过需要投票达到法定人数
+)
+
+// IsValid 检查是否为支持的控制模式
+func (m ControlMode) IsValid() bool {
+	switch m {
+	case ControlHostOnly, ControlAnyone, ControlVote:
+		return true
+	}
+	return false
+}
+
+// RoomPolicy 房间控制策略（由房主发布）
+type RoomPolicy struct {
+	Mode   ControlMode `json:"mode"`
+	Quorum float64     `json:"quorum"` // 投票模式下需要同意的比例 (0-1]
+	Sync   TrackSync   `json:"sync"`   // 需要全房间同步的播放属性
+}
+
+// TrackSync 除进度外需要全房间同步的播放属性
+type TrackSync struct {
+	Speed    bool `json:"speed,omitempty"`    // 播放速度
+	Audio    bool `json:"audio,omitempty"`    // 音轨
+	Subtitle bool `json:"subtitle,omitempty"` // 字幕轨道（只适合内嵌字幕，外挂字幕的 ID 各端可能不同）
+}
+
+// ParseTrackSync 解析逗号分隔的同步属性，如 "speed,aid,sid"（"none" 或空表示都不同步）
+func ParseTrackSync(value string) (TrackSync, error) {
+	var ts TrackSync
+	for _, name := range strings.Split(value, ",") {
+		if err := ts.Set(strings.TrimSpace(name), true); err != nil {
+			return TrackSync{}, err
+		}
+	}
+	return ts, nil
+}
+
+// Set 开关某个同步属性（speed / aid / sid）
+func (ts *TrackSync) Set(name string, enabled bool) error {
+	switch name {
+	case "", "none":
+	case "speed":
+		ts.Speed = enabled
+	case "aid", "audio":
+		ts.Audio = enabled
+	case "sid", "subtitle":
+		ts.Subtitle = enabled
+	default:
+		return fmt.Errorf("未知的同步属性: %s", name)
+	}
+	return nil
+}
+
+// String 逗号分隔的已开启属性
+func (ts TrackSync) String() string {
+	var names []string
+	if ts.Speed {
+		names = append(names, "speed")
+	}
+	if ts.Audio {
+		names = append(names, "aid")
+	}
+	if ts.Subtitle {
+		names = append(names, "sid")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ",")
+}
+
+// ControlAction 控制动作
+type ControlAction string
+
+const (
+	ActionPause  ControlAction = "pause"  // 暂停
+	ActionResume ControlAction = "resume" // 继续播放
+	ActionSeek   ControlAction = "seek"   // 跳转到 Position
+	ActionSkip   ControlAction = "skip"   // 向前跳过 Position 秒
+)
+
+// ControlRequest 参与者发给房主的控制请求
+type ControlRequest struct {
+	Action   ControlAction `json:"action"`
+	Position float64       `json:"position,omitempty"` // seek: 目标位置；skip: 跳过秒数
+	From     string        `json:"from"`               // 请求者 ID
+	Name     string        `json:"name"`               // 请求者名称
+	SentAt   int64         `json:"sent_at"`            // 发送时间（Unix 毫秒）
+}
+
+// VoteState 进行中的投票（由房主广播）
+type VoteState struct {
+	Action   ControlAction `json:"action"`
+	Position float64       `json:"position,omitempty"`
+	Proposer string        `json:"proposer"` // 发起人名称
+	Voters   []string      `json:"voters"`   // 已同意的参与者名称
+	Needed   int           `json:"needed"`   // 需要的票数
+	Deadline int64         `json:"deadline"` // 截止时间（Unix 毫秒）
+	Resolved bool          `json:"resolved"` // 已结束（通过或过期）
+	Passed   bool          `json:"passed"`   // 是否通过
+}
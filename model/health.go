@@ -0,0 +1,41 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// SwarmHealth 种子健康检查结果（随在线状态分享给房间，房主据此决定是否换片源）
+type SwarmHealth struct {
+	Peers            int     `json:"peers"`             // 已连接的 Peer
+	Seeds            int     `json:"seeds"`             // 其中拥有完整种子的
+	Pieces           int     `json:"pieces"`            // 所选文件的分块数
+	Missing          int     `json:"missing"`           // 本地没有、也没有任何 Peer 拥有的分块数
+	MinAvailability  int     `json:"min_availability"`  // 最稀有分块的副本数（本地已有的分块不计）
+	MeanAvailability float64 `json:"mean_availability"` // 平均副本数
+	DownloadRate     float64 `json:"download_rate"`     // 采样期间的下载速度（字节/秒）
+
+	StartupSeconds float64 `json:"startup_seconds"`  // 预计多久后可以开始播放（-1 表示无法估计）
+	FinishSeconds  float64 `json:"finish_seconds"`   // 预计多久下载完整个文件（-1 表示无法估计）
+	FinishesInTime bool    `json:"finishes_in_time"` // 能否在电影结束前下载完
+
+	CheckedAt int64 `json:"checked_at"` // 检查时间（Unix 毫秒）
+}
+
+// Healthy 是否可以正常观看（所有分块都有来源，且能在结束前下载完）
+func (h SwarmHealth) Healthy() bool {
+	return h.Missing == 0 && h.FinishesInTime
+}
+
+// String 一行摘要
+func (h SwarmHealth) String() string {
+	estimate := func(seconds float64) string {
+		if seconds < 0 {
+			return "未知"
+		}
+		return (time.Duration(seconds) * time.Second).String()
+	}
+	return fmt.Sprintf("%d peers (%d seeds) | 最稀有 %d 份，平均 %.1f 份，缺失 %d/%d 块 | %.2f MB/s | 开播 %s，下完 %s",
+		h.Peers, h.Seeds, h.MinAvailability, h.MeanAvailability, h.Missing, h.Pieces,
+		h.DownloadRate/1024/1024, estimate(h.StartupSeconds), estimate(h.FinishSeconds))
+}
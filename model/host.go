@@ -0,0 +1,25 @@
+package model
+
+// 换房主的原因
+const (
+	HostReasonStart    = "start"    // 以控制端启动
+	HostReasonTransfer = "transfer" // 房主主动转让
+	HostReasonElection = "election" // 房主离线后自动选举
+)
+
+// HostClaim 房主声明（保留消息，任期更大的声明生效）
+type HostClaim struct {
+	ID        string `json:"id"`         // 房主 ID
+	Name      string `json:"name"`       // 房主名称
+	Term      int    `json:"term"`       // 任期
+	Reason    string `json:"reason"`     // 原因
+	ClaimedAt int64  `json:"claimed_at"` // 声明时间（Unix 毫秒）
+}
+
+// Supersedes 判断是否应替换当前声明：任期更大，或同一任期下 ID 更小（并发选举时的决胜规则）
+func (c HostClaim) Supersedes(current HostClaim) bool {
+	if c.Term != current.Term {
+		return c.Term > current.Term
+	}
+	return current.ID == "" || (c.ID != current.ID && c.ID < current.ID)
+}
@@ -0,0 +1,24 @@
+package model
+
+// 参与者角色
+const (
+	RoleHost     = "host"     // 房主（控制端）
+	RoleFollower = "follower" // 观众（跟随端）
+)
+
+// Participant 房间参与者（在线状态心跳）
+type Participant struct {
+	ID       string `json:"id"`        // 唯一 ID（MQTT ClientID）
+	Name     string `json:"name"`      // 显示名称
+	Role     string `json:"role"`      // 角色
+	JoinedAt int64  `json:"joined_at"` // 加入时间（Unix 毫秒）
+	LastSeen int64  `json:"last_seen"` // 最近心跳时间（Unix 毫秒）
+	Left     bool   `json:"left"`      // 是否已主动离开
+	Ready    bool   `json:"ready"`     // 是否已准备好
+
+	Drift   *float64 `json:"drift,omitempty"`   // 跟随端最近测得的偏差（秒，本地 - 房主）
+	Browser bool     `json:"browser,omitempty"` // 通过浏览器加入（只能跟随，不参与房主选举）
+
+	PeerAddrs []string     `json:"peer_addrs,omitempty"` // BitTorrent 监听地址（host:port），房间内互相直连
+	Health    *SwarmHealth `json:"health,omitempty"`     // 最近一次种子健康检查的结果
+}
@@ -0,0 +1,31 @@
+package model
+
+// PlaybackEventKind 播放事件类型
+type PlaybackEventKind string
+
+const (
+	EventChapter            PlaybackEventKind = "chapter"             // 房主跳到了某个章节
+	EventEOF                PlaybackEventKind = "eof"                 // 当前视频播放结束（有下一项时进入幕间倒计时）
+	EventIntermissionCancel PlaybackEventKind = "intermission-cancel" // 房主在幕间结束前跳回了视频
+	EventNext               PlaybackEventKind = "next"                // 切换到播放列表中的另一项
+)
+
+// PlaybackEvent 房主发出的播放事件（房主 -> 所有人）
+// 进度由 PlayStatus 同步，这里只携带时间戳表达不了的跳转和结束
+type PlaybackEvent struct {
+	Kind     PlaybackEventKind `json:"kind"`
+	Item     int               `json:"item"`               // 播放列表位置
+	Chapter  int               `json:"chapter,omitempty"`  // EventChapter: 章节序号
+	Position float64           `json:"position,omitempty"` // EventChapter: 跳转后的播放位置
+	HasNext  bool              `json:"has_next,omitempty"` // EventEOF: 播放列表中是否还有下一项
+	Deadline int64             `json:"deadline,omitempty"` // EventEOF: 幕间结束、切到下一项的时间（Unix 毫秒）
+	Host     string            `json:"host,omitempty"`
+	Term     int               `json:"term,omitempty"`
+	SentAt   int64             `json:"sent_at"`
+}
+
+// Chapter 章节（来自 MPV 的 chapter-list）
+type Chapter struct {
+	Title string  `json:"title"`
+	Time  float64 `json:"time"` // 开始时间（秒）
+}
@@ -0,0 +1,31 @@
+package model
+
+// ReactionKind 表情反应类型
+type ReactionKind string
+
+const (
+	ReactionLaugh    ReactionKind = "laugh"    // 大笑
+	ReactionShock    ReactionKind = "shock"    // 震惊
+	ReactionApplause ReactionKind = "applause" // 鼓掌
+)
+
+// ReactionKinds 所有支持的反应类型（按快捷键顺序）
+var ReactionKinds = []ReactionKind{ReactionLaugh, ReactionShock, ReactionApplause}
+
+// IsValid 检查是否为支持的反应类型
+func (k ReactionKind) IsValid() bool {
+	for _, kind := range ReactionKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Reaction 带播放位置的表情反应
+type Reaction struct {
+	Kind     ReactionKind `json:"kind"`     // 反应类型
+	Sender   string       `json:"sender"`   // 发送者名称
+	Position float64      `json:"position"` // 发送时的播放位置（秒）
+	SentAt   int64        `json:"sent_at"`  // 发送时间（Unix 毫秒）
+}
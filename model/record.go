@@ -0,0 +1,23 @@
+package model
+
+import "encoding/json"
+
+// RecordKind 会话记录的条目类型
+type RecordKind string
+
+const (
+	RecordSend  RecordKind = "send"  // 发出的 MQTT 消息
+	RecordRecv  RecordKind = "recv"  // 收到的 MQTT 消息
+	RecordLocal RecordKind = "local" // 本地 MPV 的播放状态采样
+	RecordDrift RecordKind = "drift" // 收到房主状态时本地与房主的偏差
+)
+
+// Record 会话记录中的一行（JSONL）
+type Record struct {
+	At      int64           `json:"at"` // 记录时间（Unix 毫秒）
+	Kind    RecordKind      `json:"kind"`
+	Topic   string          `json:"topic,omitempty"`   // send / recv: 完整主题
+	Payload json.RawMessage `json:"payload,omitempty"` // send / recv: 原始消息
+	Status  *PlayStatus     `json:"status,omitempty"`  // local: 播放状态
+	Drift   float64         `json:"drift,omitempty"`   // drift: 本地位置 - 房主位置（秒），正数表示超前
+}
@@ -0,0 +1,56 @@
+package model
+
+// RoomState 手机遥控页面显示的房间概况
+type RoomState struct {
+	Self         string        `json:"self"`     // 自己的 ID
+	Host         HostClaim     `json:"host"`     // 当前房主
+	IsHost       bool          `json:"is_host"`  // 自己是否是房主
+	Policy       RoomPolicy    `json:"policy"`   // 房间策略
+	Status       PlayStatus    `json:"status"`   // 本地播放状态
+	Duration     float64       `json:"duration"` // 当前项的时长（秒，未知时为 0）
+	Items        []string      `json:"items"`    // 播放列表各项的名称
+	Participants []Participant `json:"participants"`
+	Chat         []ChatMessage `json:"chat"` // 最近的聊天消息
+
+	Subtitle  string           `json:"subtitle"`  // 房间当前的字幕
+	Subtitles []SubtitleOption `json:"subtitles"` // 可选的字幕
+
+	Buffer *BufferState `json:"buffer,omitempty"` // 本机的下载和缓冲情况
+}
+
+// SubtitleOption 遥控页面上可选的一个字幕
+type SubtitleOption struct {
+	Command  string `json:"command"` // 选择该字幕的指令（同终端 sub 指令的参数）
+	Label    string `json:"label"`
+	Selected bool   `json:"selected,omitempty"` // 本地正在使用
+}
+
+// BufferState 本机的下载和缓冲情况
+type BufferState struct {
+	DownloadRate  float64 `json:"download_rate"`  // 下载速度（字节/秒）
+	Progress      float64 `json:"progress"`       // 下载进度 (0-1)
+	BufferedAhead float64 `json:"buffered_ahead"` // 播放位置之后已缓冲的秒数（未知时为 -1）
+	Peers         int     `json:"peers"`          // 已连接的 Peer 数
+}
+
+// RoomEventKind 房间事件类型（控制 API 的事件流）
+type RoomEventKind string
+
+const (
+	RoomEventStatus RoomEventKind = "status" // 本地播放状态变化：暂停/继续、跳转、切换视频
+	RoomEventChat   RoomEventKind = "chat"   // 收到聊天消息
+	RoomEventJoin   RoomEventKind = "join"   // 参与者加入
+	RoomEventLeave  RoomEventKind = "leave"  // 参与者离开
+	RoomEventHost   RoomEventKind = "host"   // 房主变化
+)
+
+// RoomEvent 房间事件，按 Kind 只携带对应的字段
+type RoomEvent struct {
+	Kind        RoomEventKind `json:"kind"`
+	Status      *PlayStatus   `json:"status,omitempty"`
+	Seek        bool          `json:"seek,omitempty"` // status 事件是一次跳转
+	Chat        *ChatMessage  `json:"chat,omitempty"`
+	Participant *Participant  `json:"participant,omitempty"`
+	Host        *HostClaim    `json:"host,omitempty"`
+	At          int64         `json:"at"` // 事件时间（Unix 毫秒）
+}
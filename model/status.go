@@ -2,8 +2,28 @@ package model
 
 // PlayStatus 播放状态
 type PlayStatus struct {
-	Timestamp float64 `json:"timestamp"` // 当前播放位置（秒）
-	Paused    bool    `json:"paused"`    // 是否暂停
+	Timestamp float64 `json:"timestamp"`         // 当前播放位置（秒）
+	Paused    bool    `json:"paused"`            // 是否暂停
+	Host      string  `json:"host,omitempty"`    // 发布者（房主）ID
+	Term      int     `json:"term,omitempty"`    // 房主任期，换房主时递增
+	SentAt    int64   `json:"sent_at,omitempty"` // 发送时间（Unix 毫秒），用于推算保留消息的当前位置
+
+	Item    int  `json:"item,omitempty"`    // 播放列表位置
+	Chapter int  `json:"chapter,omitempty"` // 当前章节（没有章节时为 -1）
+	EOF     bool `json:"eof,omitempty"`     // 已播放到结尾
+
+	// 以下字段只在房间开启对应同步时携带（见 TrackSync）
+	Speed float64 `json:"speed,omitempty"` // 播放速度
+	Aid   string  `json:"aid,omitempty"`   // 音轨 ID（"no" 表示关闭）
+	Sid   string  `json:"sid,omitempty"`   // 字幕轨道 ID（"no" 表示关闭）
+}
+
+// Rate 播放速度（未携带时为 1）
+func (s PlayStatus) Rate() float64 {
+	if s.Speed <= 0 {
+		return 1
+	}
+	return s.Speed
 }
 
 // IsZero 检查是否为零值
@@ -0,0 +1,38 @@
+package model
+
+import "strconv"
+
+// SubtitleSource 字幕来源
+type SubtitleSource string
+
+const (
+	SubtitleOff     SubtitleSource = "off"     // 关闭字幕
+	SubtitleTrack   SubtitleSource = "track"   // 视频内嵌的字幕轨道
+	SubtitleTorrent SubtitleSource = "torrent" // 种子中的字幕文件（各自从本地流服务加载）
+	SubtitleUpload  SubtitleSource = "upload"  // 房主上传的本地字幕（内容随消息分发）
+)
+
+// MaxSubtitleUpload 上传字幕的大小上限
+const MaxSubtitleUpload = 1 << 20
+
+// SubtitleChoice 房间的字幕选择（房主 -> 所有人，保留消息）
+type SubtitleChoice struct {
+	Source  SubtitleSource `json:"source"`
+	TrackID int            `json:"track_id,omitempty"` // Source 为 track 时的轨道 ID
+	Path    string         `json:"path,omitempty"`     // Source 为 torrent 时是种子内路径，为 upload 时是文件名
+	Data    []byte         `json:"data,omitempty"`     // Source 为 upload 时的字幕内容
+	By      string         `json:"by,omitempty"`       // 选择者名称
+	SetAt   int64          `json:"set_at"`             // 选择时间（毫秒）
+}
+
+// Describe 字幕选择的简短描述
+func (c SubtitleChoice) Describe() string {
+	switch c.Source {
+	case SubtitleTrack:
+		return "内嵌字幕 #" + strconv.Itoa(c.TrackID)
+	case SubtitleTorrent, SubtitleUpload:
+		return c.Path
+	default:
+		return "关闭"
+	}
+}
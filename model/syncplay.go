@@ -0,0 +1,104 @@
+package model
+
+// Syncplay JSON 行协议的消息内容。每行是一个 JSON 对象，键为消息类型（Hello / Set / List / State / Chat / Error / TLS），
+// 值为下面对应的结构
+
+// SyncplayHello 握手：客户端发送名称、房间和密码，服务器回复确认后的名称和房间
+type SyncplayHello struct {
+	Username    string         `json:"username"`
+	Password    string         `json:"password,omitempty"` // 密码的 MD5（十六进制）
+	Room        SyncplayRoom   `json:"room"`
+	Version     string         `json:"version"`               // 协议版本
+	RealVersion string         `json:"realversion,omitempty"` // 软件版本
+	MOTD        string         `json:"motd,omitempty"`        // 服务器欢迎消息
+	Features    map[string]any `json:"features,omitempty"`
+}
+
+// SyncplayRoom 房间
+type SyncplayRoom struct {
+	Name string `json:"name"`
+}
+
+// SyncplayState 播放状态同步（双方约每秒交换一次，状态变化时立即发送）
+type SyncplayState struct {
+	PlayState        *SyncplayPlayState `json:"playstate,omitempty"`
+	Ping             *SyncplayPing      `json:"ping,omitempty"`
+	IgnoringOnTheFly *SyncplayIgnoring  `json:"ignoringOnTheFly,omitempty"`
+}
+
+// SyncplayPlayState 播放位置和暂停状态
+type SyncplayPlayState struct {
+	Position float64 `json:"position"`
+	Paused   bool    `json:"paused"`
+	DoSeek   bool    `json:"doSeek,omitempty"` // 这是一次跳转
+	SetBy    string  `json:"setBy,omitempty"`  // 最近改变状态的用户（服务器 -> 客户端）
+}
+
+// SyncplayPing 延迟测量：双方回传对方的时间戳（Unix 秒）
+type SyncplayPing struct {
+	LatencyCalculation       float64 `json:"latencyCalculation,omitempty"`       // 服务器的时间戳（客户端原样回传）
+	ServerRtt                float64 `json:"serverRtt"`                          // 服务器测得的往返时间
+	ClientLatencyCalculation float64 `json:"clientLatencyCalculation,omitempty"` // 客户端的时间戳（服务器回传时加上处理时间）
+	ClientRtt                float64 `json:"clientRtt,omitempty"`                // 客户端测得的往返时间
+}
+
+// SyncplayIgnoring 状态变化的确认计数：一方改变状态时计数加一，在对方回传之前忽略对方发来的旧状态
+type SyncplayIgnoring struct {
+	Server int `json:"server,omitempty"`
+	Client int `json:"client,omitempty"`
+}
+
+// SyncplayFile 正在播放的文件（名称和大小可能是哈希后的字符串）
+type SyncplayFile struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration"`
+	Size     any     `json:"size"` // 0 表示未知（不参与比较）
+}
+
+// SyncplaySet 设置：客户端上报文件、准备状态或换房间，服务器通知用户加入、离开和文件变化
+type SyncplaySet struct {
+	Room  *SyncplayRoom           `json:"room,omitempty"`
+	File  *SyncplayFile           `json:"file,omitempty"`
+	User  map[string]SyncplayUser `json:"user,omitempty"`
+	Ready *SyncplayReady          `json:"ready,omitempty"`
+}
+
+// SyncplayUser 用户的变化
+type SyncplayUser struct {
+	Room  *SyncplayRoom  `json:"room,omitempty"`
+	File  *SyncplayFile  `json:"file,omitempty"`
+	Event *SyncplayEvent `json:"event,omitempty"`
+}
+
+// SyncplayEvent 用户加入或离开
+type SyncplayEvent struct {
+	Joined bool `json:"joined,omitempty"`
+	Left   bool `json:"left,omitempty"`
+}
+
+// SyncplayReady 准备状态
+type SyncplayReady struct {
+	Username          string `json:"username,omitempty"` // 服务器通知时携带
+	IsReady           bool   `json:"isReady"`
+	ManuallyInitiated bool   `json:"manuallyInitiated"`
+}
+
+// SyncplayListUser 用户列表（List 消息：房间 -> 用户名 -> 状态）中的一个用户
+type SyncplayListUser struct {
+	Position   float64        `json:"position"`
+	File       any            `json:"file"` // *SyncplayFile，没有文件时为空对象
+	Controller bool           `json:"controller"`
+	IsReady    bool           `json:"isReady"`
+	Features   map[string]any `json:"features"`
+}
+
+// SyncplayChat 聊天（服务器 -> 客户端；客户端发送时只有消息文本）
+type SyncplayChat struct {
+	Username string `json:"username"`
+	Message  string `json:"message"`
+}
+
+// SyncplayError 错误（收到后客户端会断开）
+type SyncplayError struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,132 @@
+package p2p
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// SeedPolicy 播放结束后的做种策略，两个条件都为 0 表示立即停止
+type SeedPolicy struct {
+	Ratio    float64       // 分享率（上传量 / 种子大小）达到该值后停止，0 表示不限
+	Duration time.Duration // 做种该时间后停止，0 表示不限
+}
+
+// Enabled 是否需要做种
+func (p SeedPolicy) Enabled() bool {
+	return p.Ratio > 0 || p.Duration > 0
+}
+
+// seedCheckInterval 做种时检查分享率的间隔
+const seedCheckInterval = 5 * time.Second
+
+// Seed 按做种策略继续上传，直到达到分享率或时间限制，或 ctx 取消
+func (c *Client) Seed(ctx context.Context) {
+	if !c.seed.Enabled() {
+		return
+	}
+	if c.seed.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.seed.Duration)
+		defer cancel()
+	}
+
+	c.logger.Info("做种", "ratio", c.seed.Ratio, "duration", c.seed.Duration)
+	ticker := time.NewTicker(seedCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		ratio := c.Ratio()
+		if c.seed.Ratio > 0 && ratio >= c.seed.Ratio {
+			c.logger.Info("已达到分享率，停止做种", "ratio", ratio)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			c.logger.Info("停止做种", "ratio", ratio)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ratio 分享率（本次运行的上传量 / 种子大小）
+func (c *Client) Ratio() float64 {
+	length := c.torrent.Length()
+	if length <= 0 {
+		return 0
+	}
+	stats := c.torrent.Stats()
+	return float64(stats.BytesWrittenData.Int64()) / float64(length)
+}
+
+// roomFirstInterval 优先上传给房间时检查连接的间隔
+const roomFirstInterval = 5 * time.Second
+
+// roomFirstLoop 定期调用 preferRoomPeers，直到客户端关闭
+func (c *Client) roomFirstLoop() {
+	ticker := time.NewTicker(roomFirstInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case <-ticker.C:
+			c.preferRoomPeers()
+		}
+	}
+}
+
+// preferRoomPeers 房间参与者还缺数据时，断开只从我们这里下载、不给我们数据的外部连接，
+// 把上传带宽留给房间（BitTorrent 客户端只有全局限速，无法按连接分配）
+func (c *Client) preferRoomPeers() {
+	if c.torrent.Info() == nil {
+		return
+	}
+	pieces := c.torrent.NumPieces()
+	conns := c.torrent.PeerConns()
+
+	roomNeeds := false
+	for _, pc := range conns {
+		if c.isRoomPeer(pc.RemoteAddr.String()) && pc.Stats().RemotePieceCount < pieces {
+			roomNeeds = true
+			break
+		}
+	}
+	if !roomNeeds {
+		return
+	}
+
+	dropped := 0
+	for _, pc := range conns {
+		if c.isRoomPeer(pc.RemoteAddr.String()) {
+			continue
+		}
+		if stats := pc.Stats(); stats.LastWriteUploadRate > 0 && stats.DownloadRate == 0 {
+			pc.Close()
+			dropped++
+		}
+	}
+	if dropped > 0 {
+		c.logger.Debug("为房间参与者让出上传带宽", "dropped", dropped)
+	}
+}
+
+// isRoomPeer 远端地址是否属于房间参与者（只比较 IP，入站连接的端口是随机的）
+func (c *Client) isRoomPeer(remote string) bool {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		return false
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.roomIPs[ip.Unmap()]
+}
@@ -0,0 +1,164 @@
+package p2p
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"movie-night/p2p/p2ptest"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+// newSeedingClient 用 dataDir 中已有的完整数据和缓存的元数据创建做种的客户端
+func newSeedingClient(t *testing.T, mi *metainfo.MetaInfo, cfg Config) *Client {
+	t.Helper()
+	path := filepath.Join(cfg.DataDir, sessionDirName, mi.HashInfoBytes().HexString()+".torrent")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mi.Write(f)
+	f.Close()
+
+	cfg.MagnetLink = "magnet:?xt=urn:btih:" + mi.HashInfoBytes().HexString()
+	cfg.Seed = SeedPolicy{Ratio: 100}
+	c, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.torrent.VerifyData(); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// waitComplete 等待种子下载完成，返回耗时
+func waitComplete(t *testing.T, done <-chan struct{}, timeout time.Duration) time.Duration {
+	t.Helper()
+	start := time.Now()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatalf("download not complete after %s", timeout)
+	}
+	return time.Since(start)
+}
+
+func TestBandwidthLimits(t *testing.T) {
+	// 2 MiB 的数据，限速 512 KiB/s：突发的 1 MiB 之外至少需要 2 秒
+	const size = 2 << 20
+	const limit = 512 << 10
+
+	t.Run("upload", func(t *testing.T) {
+		dir := t.TempDir()
+		mi := p2ptest.NewTorrent(t, dir, "movie.mkv", size)
+		c := newSeedingClient(t, mi, Config{DataDir: dir, MaxConns: 10, ListenPort: 42180, UploadLimit: limit})
+
+		peer := p2ptest.NewPeer(t, "127.0.0.1", mi, t.TempDir())
+		peer.Connect("127.0.0.1:42180")
+		if elapsed := waitComplete(t, peer.Torrent.Complete().On(), 30*time.Second); elapsed < time.Second {
+			t.Errorf("upload limit not applied: %d bytes uploaded in %s", size, elapsed)
+		}
+		if c.Ratio() < 1 {
+			t.Errorf("ratio %.2f, want at least 1 after a full upload", c.Ratio())
+		}
+	})
+
+	t.Run("download", func(t *testing.T) {
+		dir := t.TempDir()
+		mi := p2ptest.NewTorrent(t, dir, "movie.mkv", size)
+		seeder := p2ptest.NewPeer(t, "127.0.0.1", mi, dir)
+
+		c, err := NewClient(Config{
+			DataDir:       t.TempDir(),
+			MaxConns:      10,
+			MagnetLink:    "magnet:?xt=urn:btih:" + mi.HashInfoBytes().HexString(),
+			ListenPort:    42181,
+			DownloadLimit: limit,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		c.AddRoomPeers([]string{seeder.Addr()})
+		if err := c.WaitInfo(t.Context()); err != nil {
+			t.Fatal(err)
+		}
+		c.torrent.DownloadAll()
+		if elapsed := waitComplete(t, c.torrent.Complete().On(), 30*time.Second); elapsed < time.Second {
+			t.Errorf("download limit not applied: %d bytes downloaded in %s", size, elapsed)
+		}
+	})
+}
+
+func TestRoomFirstDropsOutsideDownloaders(t *testing.T) {
+	// 上传限速让房间参与者的下载持续一段时间
+	dir := t.TempDir()
+	mi := p2ptest.NewTorrent(t, dir, "movie.mkv", 4<<20)
+	c := newSeedingClient(t, mi, Config{DataDir: dir, MaxConns: 10, ListenPort: 42182, UploadLimit: 256 << 10})
+
+	// findConn 等待满足条件的连接
+	findConn := func(match func(pc *torrent.PeerConn) bool) *torrent.PeerConn {
+		t.Helper()
+		deadline := time.Now().Add(10 * time.Second)
+		for time.Now().Before(deadline) {
+			for _, pc := range c.torrent.PeerConns() {
+				if match(pc) {
+					return pc
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatal("connection not established")
+		return nil
+	}
+	// connected 连接是否还在（被断开的 Peer 重连后是新的连接；连接在关闭后稍晚才移除）
+	connected := func(conn *torrent.PeerConn) bool {
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			found := false
+			for _, pc := range c.torrent.PeerConns() {
+				found = found || pc == conn
+			}
+			if !found {
+				return false
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		return true
+	}
+
+	// 外部 Peer 只从我们这里下载
+	outside := p2ptest.NewPeer(t, "127.0.0.1", mi, t.TempDir())
+	outside.Connect("127.0.0.1:42182")
+	out := findConn(func(pc *torrent.PeerConn) bool {
+		return !c.isRoomPeer(pc.RemoteAddr.String()) && pc.Stats().LastWriteUploadRate > 0
+	})
+
+	// 房间里没人缺数据时不断开
+	c.preferRoomPeers()
+	if !connected(out) {
+		t.Fatal("outside peer dropped without a room participant in need")
+	}
+
+	// 房间参与者缺数据：断开外部的只下载连接，保留房间参与者
+	room := p2ptest.NewPeer(t, "127.0.0.2", mi, t.TempDir())
+	c.AddRoomPeers([]string{room.Addr()})
+	in := findConn(func(pc *torrent.PeerConn) bool {
+		return c.isRoomPeer(pc.RemoteAddr.String()) && pc.Stats().RemotePieceCount < c.torrent.NumPieces()
+	})
+	c.preferRoomPeers()
+	if connected(out) {
+		t.Error("outside download-only peer should be dropped while the room needs data")
+	}
+	if !connected(in) {
+		t.Error("room participant should stay connected")
+	}
+}
@@ -2,15 +2,42 @@ package p2p
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
+	"net/netip"
+	"path/filepath"
 	"sort"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"movie-night/pkg/logging"
 
 	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/metainfo"
+	"golang.org/x/time/rate"
 )
 
 // Client P2P 客户端
 type Client struct {
 	client  *torrent.Client
 	torrent *torrent.Torrent
+
+	dataDir         string
+	metadataTimeout time.Duration
+	cachedInfo      bool // 元数据来自缓存
+
+	storage io.Closer      // 需要在客户端关闭后关闭的存储
+	window  *windowStorage // StorageWindow 模式的缓存（其他模式为空）
+	seed    SeedPolicy
+	stopCh  chan struct{}
+	metrics gosync.Once // 指标只注册一次
+
+	mu        gosync.Mutex
+	roomPeers map[string]bool     // 房间参与者的地址（已加入为直连 Peer）
+	roomIPs   map[netip.Addr]bool // 房间参与者的 IP
+	lastRates rateSample          // 上一次 Stats 时的累计流量
+	logger    *slog.Logger
 }
 
 // Config P2P 配置
@@ -18,19 +45,64 @@ type Config struct {
 	DataDir    string
 	MaxConns   int
 	MagnetLink string
+	ListenPort int // BitTorrent 监听端口，0 表示默认端口
+
+	UploadLimit   int64      // 上传限速（字节/秒），0 表示不限
+	DownloadLimit int64      // 下载限速（字节/秒），0 表示不限
+	Seed          SeedPolicy // 播放结束后的做种策略
+	RoomFirst     bool       // 房间参与者缺数据时优先上传给他们
+
+	MetadataTimeout time.Duration // 获取元数据的超时，0 表示一直等待
+
+	Storage   string // 存储模式：StorageFull（默认）或 StorageWindow
+	CacheSize int64  // StorageWindow 的缓存上限（字节）
+	CacheDir  string // StorageWindow 的缓存目录，为空时缓存在内存中
+
+	Logger *slog.Logger // 日志（为空时使用 slog.Default()）
 }
 
-// NewClient 创建 P2P 客户端
+// NewClient 创建 P2P 客户端并添加磁力链（元数据在后台获取，见 WaitInfo）
 func NewClient(cfg Config) (*Client, error) {
 	// 配置 torrent 客户端
 	tcfg := torrent.NewDefaultClientConfig()
 	tcfg.DataDir = cfg.DataDir
 	tcfg.EstablishedConnsPerTorrent = cfg.MaxConns
 	tcfg.DisableAggressiveUpload = true
+	if cfg.ListenPort > 0 {
+		tcfg.ListenPort = cfg.ListenPort
+	}
+	tcfg.Seed = cfg.Seed.Enabled()
+	if cfg.UploadLimit > 0 {
+		tcfg.UploadRateLimiter = rate.NewLimiter(rate.Limit(cfg.UploadLimit), 0)
+	}
+	if cfg.DownloadLimit > 0 {
+		tcfg.DownloadRateLimiter = rate.NewLimiter(rate.Limit(cfg.DownloadLimit), 0)
+	}
+
+	logger := logging.Component(cfg.Logger, "P2P")
+	var window *windowStorage
+	var closer io.Closer
+	switch cfg.Storage {
+	case "", StorageFull:
+		fileStorage := openFileStorage(cfg.DataDir, logger)
+		tcfg.DefaultStorage = fileStorage
+		closer = fileStorage
+	case StorageWindow:
+		if cfg.CacheSize <= 0 {
+			return nil, fmt.Errorf("缓存上限必须大于 0")
+		}
+		window = newWindowStorage(cfg.CacheSize, cfg.CacheDir)
+		tcfg.DefaultStorage = window
+	default:
+		return nil, fmt.Errorf("未知的存储模式: %s", cfg.Storage)
+	}
 
-	fmt.Println("🚀 [P2P] 启动引擎...")
+	logger.Info("启动引擎", "upload_limit", cfg.UploadLimit, "download_limit", cfg.DownloadLimit)
 	client, err := torrent.NewClient(tcfg)
 	if err != nil {
+		if closer != nil {
+			closer.Close()
+		}
 		return nil, fmt.Errorf("创建客户端失败: %w", err)
 	}
 
@@ -38,16 +110,36 @@ func NewClient(cfg Config) (*Client, error) {
 	t, err := client.AddMagnet(cfg.MagnetLink)
 	if err != nil {
 		client.Close()
+		if closer != nil {
+			closer.Close()
+		}
 		return nil, fmt.Errorf("添加磁力链失败: %w", err)
 	}
 
-	fmt.Println("🔍 [P2P] 获取元数据...")
-	<-t.GotInfo()
-
-	return &Client{
-		client:  client,
-		torrent: t,
-	}, nil
+	c := &Client{
+		client:          client,
+		torrent:         t,
+		dataDir:         cfg.DataDir,
+		metadataTimeout: cfg.MetadataTimeout,
+		storage:         closer,
+		window:          window,
+		seed:            cfg.Seed,
+		stopCh:          make(chan struct{}),
+		roomPeers:       make(map[string]bool),
+		roomIPs:         make(map[netip.Addr]bool),
+		logger:          logger,
+	}
+	c.cachedInfo = c.loadMetadata()
+	if cfg.RoomFirst {
+		go c.roomFirstLoop()
+	}
+	if window != nil {
+		// 淘汰的分块需要告诉客户端，之后才会重新下载，也不再提供给其他 Peer
+		window.setOnEvict(func(_ metainfo.Hash, index int) {
+			t.Piece(index).UpdateCompletion()
+		})
+	}
+	return c, nil
 }
 
 // GetLargestFile 获取最大的文件（视频）
@@ -64,6 +156,32 @@ func (c *Client) GetLargestFile() *torrent.File {
 	return files[0]
 }
 
+// videoExts 视为视频的文件格式
+var videoExts = map[string]bool{
+	".mkv":  true,
+	".mp4":  true,
+	".avi":  true,
+	".mov":  true,
+	".webm": true,
+	".m4v":  true,
+	".ts":   true,
+}
+
+// GetVideoFiles 获取所有视频文件（按路径排序，剧集一般按文件名排列）
+func (c *Client) GetVideoFiles() []*torrent.File {
+	var videos []*torrent.File
+	for _, f := range c.torrent.Files() {
+		if videoExts[strings.ToLower(filepath.Ext(f.Path()))] {
+			videos = append(videos, f)
+		}
+	}
+
+	sort.Slice(videos, func(i, j int) bool {
+		return videos[i].Path() < videos[j].Path()
+	})
+	return videos
+}
+
 // GetTorrent 获取原始 Torrent 对象（用于统计）
 func (c *Client) GetTorrent() *torrent.Torrent {
 	return c.torrent
@@ -71,6 +189,10 @@ func (c *Client) GetTorrent() *torrent.Torrent {
 
 // Close 关闭客户端
 func (c *Client) Close() error {
+	close(c.stopCh)
 	c.client.Close()
+	if c.storage != nil {
+		c.storage.Close()
+	}
 	return nil
 }
@@ -0,0 +1,120 @@
+package p2p
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"movie-night/model"
+
+	"github.com/anacrolix/torrent"
+)
+
+// assumedRuntime 不知道片长时假设的时长（用于估算码率）
+const assumedRuntime = 2 * time.Hour
+
+// HealthOptions 健康检查参数
+type HealthOptions struct {
+	Sample    time.Duration // 采样下载速度的时长
+	Runtime   time.Duration // 片长，0 表示未知（按 assumedRuntime 估算码率）
+	Prebuffer time.Duration // 开始播放前需要缓冲的时长
+}
+
+// CheckHealth 采样 Peer 的分块可用性和下载速度，估算多久后能开始播放、能否在电影结束前下载完
+// 采样期间会优先下载文件开头（需在 WaitInfo 之后调用，阻塞 opts.Sample）
+func (c *Client) CheckHealth(ctx context.Context, file *torrent.File, opts HealthOptions) model.SwarmHealth {
+	runtime := opts.Runtime
+	if runtime <= 0 {
+		runtime = assumedRuntime
+	}
+	bitrate := float64(file.Length()) / runtime.Seconds()
+
+	// 读取文件开头，让客户端开始下载，从而测得真实的下载速度
+	readCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	reader := file.NewReader()
+	reader.SetContext(readCtx)
+	reader.SetResponsive()
+	go func() {
+		defer reader.Close()
+		io.Copy(io.Discard, io.LimitReader(reader, int64(bitrate*opts.Prebuffer.Seconds())+1))
+	}()
+
+	start := time.Now()
+	startBytes := c.usefulBytes()
+	select {
+	case <-ctx.Done():
+	case <-time.After(opts.Sample):
+	}
+	elapsed := time.Since(start).Seconds()
+
+	health := c.availability(file)
+	health.CheckedAt = time.Now().UnixMilli()
+	if elapsed > 0 {
+		health.DownloadRate = float64(c.usefulBytes()-startBytes) / elapsed
+	}
+
+	health.StartupSeconds, health.FinishSeconds = -1, -1
+	completed := file.BytesCompleted()
+	if completed >= file.Length() {
+		health.StartupSeconds, health.FinishSeconds = 0, 0
+	} else if health.DownloadRate > 0 {
+		needed := bitrate*opts.Prebuffer.Seconds() - float64(completed)
+		health.StartupSeconds = max(needed, 0) / health.DownloadRate
+		health.FinishSeconds = float64(file.Length()-completed) / health.DownloadRate
+	}
+	health.FinishesInTime = health.Missing == 0 && health.FinishSeconds >= 0 &&
+		health.FinishSeconds <= health.StartupSeconds+runtime.Seconds()
+	return health
+}
+
+// usefulBytes 已下载的有效数据量
+func (c *Client) usefulBytes() int64 {
+	stats := c.torrent.Stats()
+	return stats.BytesReadUsefulData.Int64()
+}
+
+// availability 统计所选文件每个分块在已连接 Peer 中的副本数
+func (c *Client) availability(file *torrent.File) model.SwarmHealth {
+	conns := c.torrent.PeerConns()
+	total := uint64(c.torrent.NumPieces())
+
+	health := model.SwarmHealth{Peers: len(conns)}
+	for _, pc := range conns {
+		if pc.PeerPieces().GetCardinality() >= total {
+			health.Seeds++
+		}
+	}
+
+	begin, end := file.BeginPieceIndex(), file.EndPieceIndex()
+	health.Pieces = end - begin
+	counted, sum := 0, 0
+	health.MinAvailability = -1
+	for i := begin; i < end; i++ {
+		if c.torrent.Piece(i).State().Complete {
+			continue
+		}
+		n := 0
+		for _, pc := range conns {
+			if pc.PeerPieces().Contains(uint32(i)) {
+				n++
+			}
+		}
+		if n == 0 {
+			health.Missing++
+		}
+		if health.MinAvailability < 0 || n < health.MinAvailability {
+			health.MinAvailability = n
+		}
+		counted++
+		sum += n
+	}
+
+	if counted > 0 {
+		health.MeanAvailability = float64(sum) / float64(counted)
+	} else {
+		// 本地已有整个文件
+		health.MinAvailability = 0
+	}
+	return health
+}
@@ -0,0 +1,72 @@
+package p2p
+
+import (
+	"io"
+
+	"github.com/anacrolix/torrent"
+)
+
+// MediaFile 可以通过 HTTP 流式提供的文件（种子中的文件，测试中可用 p2ptest.File）
+type MediaFile interface {
+	Path() string
+	DisplayPath() string
+	Length() int64
+	// Open 打开读取器，数据未下载完时读取会等待
+	Open() io.ReadSeekCloser
+	// Download 优先下载整个文件
+	Download()
+}
+
+// torrentFile 种子中的文件
+type torrentFile struct {
+	*torrent.File
+	playhead func(offset int64) // 读取时报告种子内的偏移（可为空）
+}
+
+// Open 打开边下边播的读取器
+func (f torrentFile) Open() io.ReadSeekCloser {
+	reader := f.NewReader()
+	reader.SetResponsive()
+	if f.playhead == nil {
+		return reader
+	}
+	return &playheadReader{Reader: reader, offset: f.Offset(), report: f.playhead}
+}
+
+// playheadReader 读取时报告播放位置，供有限缓存淘汰已看过的分块
+type playheadReader struct {
+	torrent.Reader
+	offset int64 // 文件在种子中的偏移
+	pos    int64 // 文件内的读取位置
+	report func(offset int64)
+}
+
+func (r *playheadReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.pos += int64(n)
+	r.report(r.offset + r.pos)
+	return n, err
+}
+
+func (r *playheadReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.Reader.Seek(offset, whence)
+	if err == nil {
+		r.pos = pos
+		r.report(r.offset + r.pos)
+	}
+	return pos, err
+}
+
+// TorrentFile 把种子中的文件作为 MediaFile
+func TorrentFile(f *torrent.File) MediaFile {
+	return torrentFile{File: f}
+}
+
+// TorrentFiles 把种子中的文件作为 MediaFile 列表
+func TorrentFiles(files []*torrent.File) []MediaFile {
+	out := make([]MediaFile, len(files))
+	for i, f := range files {
+		out[i] = torrentFile{File: f}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package p2p
+
+import (
+	"time"
+
+	"movie-night/pkg/metrics"
+)
+
+// metricsSampleInterval 计算下载速度的采样间隔
+const metricsSampleInterval = 2 * time.Second
+
+// torrentGauges 输出时才计算的种子指标：名称、说明和计算函数
+var torrentGauges = []struct {
+	name, help string
+	value      func(c *Client) float64
+}{
+	{"movienight_torrent_peers", "已连接的 peer 数", func(c *Client) float64 {
+		return float64(len(c.torrent.PeerConns()))
+	}},
+	{"movienight_torrent_completed_bytes", "已下载完成的字节数", func(c *Client) float64 {
+		return float64(c.torrent.BytesCompleted())
+	}},
+	{"movienight_torrent_length_bytes", "种子总大小", func(c *Client) float64 {
+		return float64(c.torrent.Length())
+	}},
+}
+
+// RegisterMetrics 注册种子的下载指标（速度、进度、连接数），并在后台采样下载速度直到客户端关闭
+// 重复调用不会重复注册
+func (c *Client) RegisterMetrics() {
+	c.metrics.Do(func() {
+		for _, g := range torrentGauges {
+			value := g.value
+			metrics.NewGaugeFunc(g.name, g.help, func() float64 { return value(c) })
+		}
+		go c.sampleMetrics(metrics.NewGauge("movienight_torrent_download_bytes_per_second", "种子下载速度"))
+	})
+}
+
+// sampleMetrics 定时采样下载速度；客户端关闭时停止，并让指标不再引用已关闭的种子
+func (c *Client) sampleMetrics(rate *metrics.Gauge) {
+	ticker := time.NewTicker(metricsSampleInterval)
+	defer ticker.Stop()
+
+	stats := c.torrent.Stats()
+	last := stats.ConnStats.BytesReadUsefulData.Int64()
+	for {
+		select {
+		case <-c.stopCh:
+			rate.Set(0)
+			for _, g := range torrentGauges {
+				metrics.NewGaugeFunc(g.name, g.help, nil)
+			}
+			return
+		case <-ticker.C:
+		}
+		stats = c.torrent.Stats()
+		current := stats.ConnStats.BytesReadUsefulData.Int64()
+		rate.Set(float64(current-last) / metricsSampleInterval.Seconds())
+		last = current
+	}
+}
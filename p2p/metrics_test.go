@@ -0,0 +1,54 @@
+package p2p
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"movie-night/p2p/p2ptest"
+	"movie-night/pkg/metrics"
+)
+
+// samplers 正在运行的下载速度采样 goroutine 数（按创建位置统计，包括还没开始运行的）
+func samplers() int {
+	buf := make([]byte, 1<<20)
+	for runtime.Stack(buf, true) == len(buf) {
+		buf = make([]byte, 2*len(buf))
+	}
+	return strings.Count(string(buf[:runtime.Stack(buf, true)]), "created by movie-night/p2p.(*Client).RegisterMetrics")
+}
+
+func TestMetricsSamplerStopsOnClose(t *testing.T) {
+	mi := p2ptest.NewTorrent(t, t.TempDir(), "movie.mkv", 1<<20)
+	c, err := NewClient(Config{
+		DataDir:    t.TempDir(),
+		MaxConns:   10,
+		MagnetLink: "magnet:?xt=urn:btih:" + mi.HashInfoBytes().HexString(),
+		ListenPort: 42183,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 重复注册只有一个采样 goroutine
+	before := samplers()
+	c.RegisterMetrics()
+	c.RegisterMetrics()
+	if n := samplers() - before; n != 1 {
+		t.Fatalf("%d samplers running, want 1", n)
+	}
+
+	// 关闭后采样停止，指标不再读取已关闭的种子
+	c.Close()
+	deadline := time.Now().Add(time.Second)
+	for samplers() > before {
+		if time.Now().After(deadline) {
+			t.Fatal("sampler still running after Close")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if peers := metrics.NewGauge("movienight_torrent_peers", "已连接的 peer 数").Value(); peers != 0 {
+		t.Errorf("peers gauge reports %v after Close", peers)
+	}
+}
@@ -0,0 +1,74 @@
+// Package p2ptest 测试用的内存媒体文件和回环地址上的 BitTorrent 对端
+package p2ptest
+
+import (
+	"bytes"
+	"io"
+	"path"
+	"sync"
+)
+
+// File 内存中的媒体文件（实现 p2p.MediaFile）
+type File struct {
+	path string
+	data []byte
+
+	mu         sync.Mutex
+	opens      int
+	downloaded bool
+}
+
+// NewFile 创建内存文件，path 为种子内路径
+func NewFile(path string, data []byte) *File {
+	return &File{path: path, data: data}
+}
+
+// Path 种子内路径
+func (f *File) Path() string {
+	return f.path
+}
+
+// DisplayPath 显示的路径
+func (f *File) DisplayPath() string {
+	return path.Base(f.path)
+}
+
+// Length 文件大小
+func (f *File) Length() int64 {
+	return int64(len(f.data))
+}
+
+// Open 打开读取器
+func (f *File) Open() io.ReadSeekCloser {
+	f.mu.Lock()
+	f.opens++
+	f.mu.Unlock()
+	return nopCloser{bytes.NewReader(f.data)}
+}
+
+// Download 记录文件被要求完整下载
+func (f *File) Download() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.downloaded = true
+}
+
+// Opens 被打开的次数
+func (f *File) Opens() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.opens
+}
+
+// Downloaded 是否被要求完整下载过
+func (f *File) Downloaded() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.downloaded
+}
+
+type nopCloser struct {
+	*bytes.Reader
+}
+
+func (nopCloser) Close() error { return nil }
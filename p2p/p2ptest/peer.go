@@ -0,0 +1,96 @@
+package p2ptest
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+)
+
+// NewTorrent 在 dir 中生成 size 字节的文件 name 并制作种子（分块 256 KiB）
+func NewTorrent(t testing.TB, dir, name string, size int) *metainfo.MetaInfo {
+	t.Helper()
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	info := metainfo.Info{PieceLength: 256 << 10}
+	if err := info.BuildFromFilePath(path); err != nil {
+		t.Fatal(err)
+	}
+	infoBytes, err := bencode.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &metainfo.MetaInfo{InfoBytes: infoBytes}
+}
+
+// Peer 只在回环地址上通信的 BitTorrent 客户端，充当被测客户端的对端
+type Peer struct {
+	Client  *torrent.Client
+	Torrent *torrent.Torrent
+	host    string
+}
+
+// NewPeer 在 host 上启动客户端并添加种子（Linux 上整个 127.0.0.0/8 都是回环地址，可用不同 IP 区分对端）
+// dir 中已有完整数据时做种，否则下载全部数据；测试结束时关闭
+func NewPeer(t testing.TB, host string, mi *metainfo.MetaInfo, dir string) *Peer {
+	t.Helper()
+	cfg := torrent.NewDefaultClientConfig()
+	cfg.DataDir = dir
+	cfg.DefaultStorage = storage.NewFileOpts(storage.NewFileClientOpts{
+		ClientBaseDir:   dir,
+		PieceCompletion: storage.NewMapPieceCompletion(),
+	})
+	cfg.ListenHost = func(string) string { return host }
+	cfg.ListenPort = 0
+	cfg.Seed = true
+	cfg.NoDHT = true
+	cfg.DisableTrackers = true
+	cfg.DisablePEX = true
+	cfg.DisableUTP = true
+	cfg.DisableIPv6 = true
+	cfg.DisableWebtorrent = true
+	cfg.NoDefaultPortForwarding = true
+
+	client, err := torrent.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	tor, err := client.AddTorrent(mi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tor.VerifyData(); err != nil {
+		t.Fatal(err)
+	}
+	tor.DownloadAll()
+	return &Peer{Client: client, Torrent: tor, host: host}
+}
+
+// Addr 监听地址（host:port）
+func (p *Peer) Addr() string {
+	return net.JoinHostPort(p.host, strconv.Itoa(p.Client.LocalPort()))
+}
+
+// Connect 连接到 addr 上的对端
+func (p *Peer) Connect(addr string) {
+	tcp, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return
+	}
+	p.Torrent.AddPeers([]torrent.PeerInfo{{Addr: tcp, Trusted: true}})
+}
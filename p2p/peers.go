@@ -0,0 +1,70 @@
+package p2p
+
+import (
+	"net"
+	"net/netip"
+	"strconv"
+
+	"github.com/anacrolix/torrent"
+)
+
+// PeerAddrs 本机的 BitTorrent 监听地址（各网卡的 IP 加监听端口），发布到房间在线状态，
+// 让同一局域网或 VPN 里的参与者不经过 Tracker/DHT 直接连过来
+func (c *Client) PeerAddrs() []string {
+	port := c.client.LocalPort()
+	if port == 0 {
+		return nil
+	}
+
+	var ips []net.IP
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				ips = append(ips, ipNet.IP)
+			}
+		}
+	}
+	ips = append(ips, c.client.PublicIPs()...)
+
+	seen := make(map[string]bool)
+	var result []string
+	for _, ip := range ips {
+		if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			continue
+		}
+		addr := net.JoinHostPort(ip.String(), strconv.Itoa(port))
+		if !seen[addr] {
+			seen[addr] = true
+			result = append(result, addr)
+		}
+	}
+	return result
+}
+
+// AddRoomPeers 把房间参与者的地址加入为受信任的直连 Peer，返回新加入的数量
+// 已经加入过的地址和无效地址会被跳过
+func (c *Client) AddRoomPeers(addrs []string) int {
+	var peers []torrent.PeerInfo
+	c.mu.Lock()
+	for _, addr := range addrs {
+		addrPort, err := netip.ParseAddrPort(addr)
+		if err != nil || c.roomPeers[addrPort.String()] {
+			continue
+		}
+		c.roomPeers[addrPort.String()] = true
+		c.roomIPs[addrPort.Addr().Unmap()] = true
+		peers = append(peers, torrent.PeerInfo{
+			Addr:    net.TCPAddrFromAddrPort(addrPort),
+			Source:  torrent.PeerSourceDirect,
+			Trusted: true,
+		})
+	}
+	c.mu.Unlock()
+
+	if len(peers) == 0 {
+		return 0
+	}
+	c.torrent.AddPeers(peers)
+	c.logger.Info("添加房间 Peer", "count", len(peers))
+	return len(peers)
+}
@@ -0,0 +1,84 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/anacrolix/torrent"
+)
+
+// prebufferPollInterval 检查分片完成情况的间隔
+const prebufferPollInterval = 500 * time.Millisecond
+
+// OffsetForPosition 按平均码率估算播放位置对应的文件偏移
+func OffsetForPosition(file *torrent.File, position, duration float64) int64 {
+	if duration <= 0 || position <= 0 {
+		return 0
+	}
+	if position >= duration {
+		return file.Length()
+	}
+	return int64(position / duration * float64(file.Length()))
+}
+
+// Prebuffer 提升文件中 [offset, offset+length) 所在分片的优先级，并等待下载完成
+// progress 周期性报告完成比例 (0-1)，ctx 取消或超时时返回错误。返回时撤销提升，分片回到由文件和读取位置决定的优先级（普通或预读）
+func (c *Client) Prebuffer(ctx context.Context, file *torrent.File, offset, length int64, progress func(float64)) error {
+	t := file.Torrent()
+	info := t.Info()
+	if info == nil {
+		return fmt.Errorf("元数据尚未获取")
+	}
+
+	// 限制在文件范围内
+	if offset < 0 {
+		offset = 0
+	}
+	if offset+length > file.Length() {
+		length = file.Length() - offset
+	}
+	if length <= 0 {
+		return nil
+	}
+
+	pieceLength := info.PieceLength
+	begin := int((file.Offset() + offset) / pieceLength)
+	end := int((file.Offset()+offset+length-1)/pieceLength) + 1
+
+	var total int64
+	for i := begin; i < end; i++ {
+		t.Piece(i).SetPriority(torrent.PiecePriorityNow)
+		total += t.Piece(i).Info().Length()
+	}
+	defer func() {
+		for i := begin; i < end; i++ {
+			t.Piece(i).SetPriority(torrent.PiecePriorityNone)
+		}
+	}()
+
+	c.logger.Info("预缓冲", "pieces", fmt.Sprintf("%d-%d", begin, end-1), "mb", float64(total)/1024/1024)
+
+	ticker := time.NewTicker(prebufferPollInterval)
+	defer ticker.Stop()
+
+	for {
+		var missing int64
+		for i := begin; i < end; i++ {
+			missing += t.PieceBytesMissing(i)
+		}
+
+		if progress != nil {
+			progress(float64(total-missing) / float64(total))
+		}
+		if missing == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("预缓冲未完成: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
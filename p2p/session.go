@@ -0,0 +1,211 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+)
+
+// sessionDirName DataDir 下保存元数据缓存和分块完成状态的目录
+// 重启后不需要重新获取元数据，也不需要重新校验已下载的分块
+const sessionDirName = ".session"
+
+// ErrMetadataTimeout 在限定时间内没有获取到元数据
+var ErrMetadataTimeout = errors.New("获取元数据超时")
+
+// openFileStorage 完整下载模式的存储，分块完成状态保存在 DataDir 中
+func openFileStorage(dataDir string, logger *slog.Logger) storage.ClientImplCloser {
+	completion, err := storage.NewBoltPieceCompletion(filepath.Join(dataDir, sessionDirName))
+	if err != nil {
+		// 通常是另一个进程正在使用同一个 DataDir
+		logger.Warn("分块完成状态数据库不可用，重启后需要重新校验", "err", err)
+		completion = storage.NewMapPieceCompletion()
+	}
+	return storage.NewFileOpts(storage.NewFileClientOpts{
+		ClientBaseDir:   dataDir,
+		PieceCompletion: completion,
+	})
+}
+
+// metadataPath 元数据缓存文件
+func (c *Client) metadataPath() string {
+	return filepath.Join(c.dataDir, sessionDirName, c.torrent.InfoHash().HexString()+".torrent")
+}
+
+// loadMetadata 从缓存加载元数据，返回是否成功
+func (c *Client) loadMetadata() bool {
+	path := c.metadataPath()
+	if _, err := os.Stat(path); err != nil {
+		return false
+	}
+	mi, err := metainfo.LoadFromFile(path)
+	if err == nil {
+		err = c.torrent.SetInfoBytes(mi.InfoBytes)
+	}
+	if err != nil {
+		c.logger.Warn("元数据缓存无效", "path", path, "err", err)
+		os.Remove(path)
+		return false
+	}
+	c.logger.Info("使用缓存的元数据", "path", path)
+	return true
+}
+
+// saveMetadata 缓存元数据（.torrent 文件）
+func (c *Client) saveMetadata() {
+	path := c.metadataPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		c.logger.Warn("元数据缓存失败", "err", err)
+		return
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		c.logger.Warn("元数据缓存失败", "err", err)
+		return
+	}
+	mi := c.torrent.Metainfo()
+	if err := mi.Write(f); err != nil {
+		c.logger.Warn("元数据缓存失败", "err", err)
+	}
+	f.Close()
+}
+
+// WaitInfo 等待元数据（文件列表等），获取之前其他方法不可用
+// 房间参与者可能已经有元数据，因此应在交换 Peer 地址之后调用
+// 超过 MetadataTimeout 仍未获取到时返回 ErrMetadataTimeout
+func (c *Client) WaitInfo(ctx context.Context) error {
+	if c.metadataTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.metadataTimeout)
+		defer cancel()
+	}
+
+	c.logger.Info("获取元数据")
+	start := time.Now()
+	select {
+	case <-c.torrent.GotInfo():
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %v 内没有找到可用的 Peer（Tracker 可能已失效，可以让已有元数据的房间成员先加入）",
+				ErrMetadataTimeout, c.metadataTimeout)
+		}
+		return fmt.Errorf("获取元数据失败: %w", ctx.Err())
+	}
+	c.logger.Info("元数据已获取", "name", c.torrent.Name(), "files", len(c.torrent.Files()),
+		"elapsed", time.Since(start).Round(time.Millisecond))
+
+	if !c.cachedInfo {
+		c.saveMetadata()
+	}
+	return nil
+}
+
+// Download DataDir 中一个有元数据缓存的种子
+type Download struct {
+	Name     string // 种子名称（DataDir 下的文件或目录）
+	Path     string // 下载数据的路径
+	Complete bool   // 所有分块都已下载并校验
+
+	infoHash metainfo.Hash
+	pieces   int
+}
+
+// Session DataDir 中的会话状态（元数据缓存和分块完成状态），用于清理已下载完成的数据
+type Session struct {
+	dataDir    string
+	completion storage.PieceCompletion // 没有会话目录时为空
+}
+
+// OpenSession 打开 DataDir 的会话状态；DataDir 正被播放器使用时返回错误
+func OpenSession(dataDir string) (*Session, error) {
+	dir := filepath.Join(dataDir, sessionDirName)
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return &Session{dataDir: dataDir}, nil
+		}
+		return nil, err
+	}
+	completion, err := storage.NewBoltPieceCompletion(dir)
+	if err != nil {
+		return nil, fmt.Errorf("分块完成状态数据库不可用（可能正在播放）: %w", err)
+	}
+	return &Session{dataDir: dataDir, completion: completion}, nil
+}
+
+// Downloads 列出有元数据缓存的种子（按名称排序）
+func (s *Session) Downloads() ([]Download, error) {
+	if s.completion == nil {
+		return nil, nil
+	}
+	paths, err := filepath.Glob(filepath.Join(s.dataDir, sessionDirName, "*.torrent"))
+	if err != nil {
+		return nil, err
+	}
+
+	var downloads []Download
+	for _, path := range paths {
+		mi, err := metainfo.LoadFromFile(path)
+		if err != nil {
+			continue
+		}
+		info, err := mi.UnmarshalInfo()
+		if err != nil || info.BestName() == "" {
+			continue
+		}
+		d := Download{
+			Name:     info.BestName(),
+			Path:     filepath.Join(s.dataDir, info.BestName()),
+			infoHash: mi.HashInfoBytes(),
+			pieces:   info.NumPieces(),
+		}
+		d.Complete = s.complete(d)
+		downloads = append(downloads, d)
+	}
+	sort.Slice(downloads, func(i, j int) bool {
+		return downloads[i].Name < downloads[j].Name
+	})
+	return downloads, nil
+}
+
+// complete 所有分块是否都已完成
+func (s *Session) complete(d Download) bool {
+	if d.pieces == 0 {
+		return false
+	}
+	for i := 0; i < d.pieces; i++ {
+		c, err := s.completion.Get(metainfo.PieceKey{InfoHash: d.infoHash, Index: i})
+		if err != nil || !c.Ok || !c.Complete {
+			return false
+		}
+	}
+	return true
+}
+
+// Remove 删除种子的下载数据，并把分块标记为未完成（元数据缓存保留，再次观看时不需要重新获取）
+func (s *Session) Remove(d Download) error {
+	if err := os.RemoveAll(d.Path); err != nil {
+		return err
+	}
+	for i := 0; i < d.pieces; i++ {
+		if err := s.completion.Set(metainfo.PieceKey{InfoHash: d.infoHash, Index: i}, false); err != nil {
+			return fmt.Errorf("更新分块完成状态失败: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close 关闭会话状态
+func (s *Session) Close() error {
+	if s.completion == nil {
+		return nil
+	}
+	return s.completion.Close()
+}
@@ -0,0 +1,84 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+// testMetainfo 用临时文件生成种子
+func testMetainfo(t *testing.T) *metainfo.MetaInfo {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), "movie.mkv")
+	if err := os.WriteFile(src, make([]byte, 1<<20), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	info := metainfo.Info{PieceLength: 256 << 10}
+	if err := info.BuildFromFilePath(src); err != nil {
+		t.Fatal(err)
+	}
+	infoBytes, err := bencode.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &metainfo.MetaInfo{InfoBytes: infoBytes}
+}
+
+func TestMetadataCacheAndTimeout(t *testing.T) {
+	mi := testMetainfo(t)
+	magnet := "magnet:?xt=urn:btih:" + mi.HashInfoBytes().HexString()
+	dataDir := t.TempDir()
+
+	port := 42170
+	newClient := func() *Client {
+		port++ // 刚关闭的端口可能还不能立即重用
+		c, err := NewClient(Config{DataDir: dataDir, MagnetLink: magnet, ListenPort: port, MetadataTimeout: 200 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// 没有 Peer 也没有缓存：超时并给出明确的错误
+	c := newClient()
+	if err := c.WaitInfo(context.Background()); !errors.Is(err, ErrMetadataTimeout) {
+		t.Errorf("got %v, want ErrMetadataTimeout", err)
+	}
+	c.Close()
+
+	// 缓存了元数据：立即可用
+	path := filepath.Join(dataDir, sessionDirName, mi.HashInfoBytes().HexString()+".torrent")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mi.Write(f)
+	f.Close()
+
+	c = newClient()
+	defer c.Close()
+	if err := c.WaitInfo(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	file := c.GetLargestFile()
+	if file == nil || file.Length() != 1<<20 {
+		t.Fatalf("unexpected file %v", file)
+	}
+
+	// 没有任何 Peer：所有分块缺失，无法估计
+	health := c.CheckHealth(context.Background(), file, HealthOptions{Sample: 50 * time.Millisecond, Prebuffer: time.Second})
+	if health.Missing != health.Pieces || health.Pieces != 4 || health.Healthy() || health.StartupSeconds != -1 {
+		t.Errorf("unexpected health %+v", health)
+	}
+
+	stats := c.Stats(file, 10, 100)
+	if len(stats.Pieces) != 4 || len(stats.Peers) != 0 || stats.BufferedAhead != 0 || stats.Playhead != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
@@ -1,92 +1,155 @@
 package p2p
 
 import (
-	"encoding/json"
-	"fmt"
-	"net"
-	"runtime"
+	"sort"
 	"time"
 
 	"github.com/anacrolix/torrent"
 )
 
-// StatsPusher 统计推送器
-type StatsPusher struct {
-	torrent    *torrent.Torrent
-	socketPath string
+// Stats 种子传输状态快照（用于 OSD 统计面板等）
+type Stats struct {
+	DownloadRate float64 // 下载速度（有效数据，字节/秒）
+	UploadRate   float64 // 上传速度（字节/秒）
+	Completed    int64   // 所选文件已下载的字节数
+	Length       int64   // 所选文件大小
+
+	Peers []PeerStats // 已连接的 Peer，房间参与者在前，其余按下载速度排序
+
+	// BufferedAhead 播放位置之后连续已下载的时长（秒），视频时长未知时为 -1
+	BufferedAhead float64
+	// Pieces 所选文件每个分块的状态（可用性图）
+	Pieces []PieceStats
+	// Playhead 播放位置所在的分块（Pieces 中的下标）
+	Playhead int
 }
 
-// NewStatsPusher 创建统计推送器
-func NewStatsPusher(t *torrent.Torrent, socketPath string) *StatsPusher {
-	return &StatsPusher{
-		torrent:    t,
-		socketPath: socketPath,
-	}
+// PeerStats 单个 Peer 的状态
+type PeerStats struct {
+	Addr         string
+	Client       string  // 对方的客户端名称（可能为空）
+	Room         bool    // 是否为房间参与者
+	Pieces       int     // 对方拥有的分块数
+	Progress     float64 // 对方的完成度 (0-1)
+	DownloadRate float64 // 从对方下载的速度（字节/秒）
+	UploadRate   float64 // 上传给对方的速度（字节/秒）
 }
 
-// Start 启动推送（阻塞）
-func (s *StatsPusher) Start() error {
-	var conn net.Conn
-	var err error
+// PieceStats 单个分块的状态
+type PieceStats struct {
+	Complete bool // 本地已下载
+	Peers    int  // 拥有该分块的已连接 Peer 数
+}
 
-	// Windows 跳过
-	if runtime.GOOS == "windows" {
-		fmt.Println("⚠️  Windows 不支持 Unix Socket，跳过 OSD 统计")
-		return nil
+// Progress 所选文件的下载进度 (0-1)
+func (s Stats) Progress() float64 {
+	if s.Length <= 0 {
+		return 0
 	}
+	return float64(s.Completed) / float64(s.Length)
+}
 
-	// 连接 MPV IPC
-	for i := 0; i < 10; i++ {
-		conn, err = net.Dial("unix", s.socketPath)
-		if err == nil {
-			break
+// RoomPeers 房间参与者的数量
+func (s Stats) RoomPeers() int {
+	n := 0
+	for _, p := range s.Peers {
+		if p.Room {
+			n++
 		}
-		time.Sleep(500 * time.Millisecond)
 	}
+	return n
+}
 
-	if err != nil {
-		return fmt.Errorf("连接 MPV IPC 失败: %w", err)
-	}
-	defer conn.Close()
-
-	fmt.Println("🔌 [IPC] 已连接 MPV，推送 P2P 统计")
-
-	ticker := time.NewTicker(1 * time.Second)
-	defer ticker.Stop()
-
-	var lastBytes int64
-
-	for range ticker.C {
-		stats := s.torrent.Stats()
-		currentBytes := stats.ConnStats.BytesRead.Int64()
+// rateSample 上一次计算速度时的累计字节数
+type rateSample struct {
+	at      time.Time
+	read    int64
+	written int64
+}
 
-		var speedBytes int64
-		if lastBytes != 0 {
-			speedBytes = currentBytes - lastBytes
+// Stats 获取所选文件的传输状态（需在 WaitInfo 之后调用）
+// position/duration 为当前播放位置和视频时长（秒），用于估算缓冲时长；速度按两次调用之间的增量计算
+func (c *Client) Stats(file *torrent.File, position, duration float64) Stats {
+	stats := Stats{
+		Completed:     file.BytesCompleted(),
+		Length:        file.Length(),
+		BufferedAhead: -1,
+	}
+	stats.DownloadRate, stats.UploadRate = c.transferRates()
+
+	conns := c.torrent.PeerConns()
+	total := c.torrent.NumPieces()
+	for _, pc := range conns {
+		pcStats := pc.Stats()
+		addr := pc.RemoteAddr.String()
+		name, _ := pc.PeerClientName.Load().(string)
+		stats.Peers = append(stats.Peers, PeerStats{
+			Addr:         addr,
+			Client:       name,
+			Room:         c.isRoomPeer(addr),
+			Pieces:       pcStats.RemotePieceCount,
+			Progress:     float64(pcStats.RemotePieceCount) / float64(max(total, 1)),
+			DownloadRate: pcStats.DownloadRate,
+			UploadRate:   pcStats.LastWriteUploadRate,
+		})
+	}
+	sort.Slice(stats.Peers, func(i, j int) bool {
+		a, b := stats.Peers[i], stats.Peers[j]
+		if a.Room != b.Room {
+			return a.Room
 		}
-		lastBytes = currentBytes
-
-		speedMB := float64(speedBytes) / 1024 / 1024
-
-		var progress float64
-		if s.torrent.Length() > 0 {
-			progress = float64(s.torrent.BytesCompleted()) / float64(s.torrent.Length()) * 100
+		return a.DownloadRate > b.DownloadRate
+	})
+
+	begin, end := file.BeginPieceIndex(), file.EndPieceIndex()
+	stats.Pieces = make([]PieceStats, 0, end-begin)
+	for i := begin; i < end; i++ {
+		piece := PieceStats{Complete: c.torrent.Piece(i).State().Complete}
+		for _, pc := range conns {
+			if pc.PeerPieces().Contains(uint32(i)) {
+				piece.Peers++
+			}
 		}
+		stats.Pieces = append(stats.Pieces, piece)
+	}
 
-		msg := fmt.Sprintf("P2P: %.2f MB/s | %.1f%% | %d peers",
-			speedMB,
-			progress,
-			len(s.torrent.PeerConns()),
-		)
-
-		cmd := map[string]interface{}{
-			"command": []interface{}{"show-text", msg, 1000},
+	// 从播放位置所在的分块开始，统计连续已下载的数据
+	offset := OffsetForPosition(file, position, duration)
+	pieceLength := c.torrent.Info().PieceLength
+	playhead := int((file.Offset()+offset)/pieceLength) - begin
+	stats.Playhead = min(max(playhead, 0), max(len(stats.Pieces)-1, 0))
+	if duration > 0 && file.Length() > 0 {
+		buffered := int64(0)
+		for i := stats.Playhead; i < len(stats.Pieces) && stats.Pieces[i].Complete; i++ {
+			pieceEnd := int64(begin+i+1)*pieceLength - file.Offset()
+			buffered = min(pieceEnd, file.Length()) - offset
 		}
+		bitrate := float64(file.Length()) / duration
+		stats.BufferedAhead = max(float64(buffered), 0) / bitrate
+	}
+	return stats
+}
 
-		jsonBytes, _ := json.Marshal(cmd)
-		conn.Write(jsonBytes)
-		conn.Write([]byte("\n"))
+// transferRates 距上次调用的平均下载/上传速度（第一次调用返回 0）
+func (c *Client) transferRates() (download, upload float64) {
+	connStats := c.torrent.Stats()
+	now := rateSample{
+		at:      time.Now(),
+		read:    connStats.BytesReadUsefulData.Int64(),
+		written: connStats.BytesWrittenData.Int64(),
 	}
 
-	return nil
+	c.mu.Lock()
+	last := c.lastRates
+	c.lastRates = now
+	c.mu.Unlock()
+
+	if last.at.IsZero() {
+		return 0, 0
+	}
+	elapsed := now.at.Sub(last.at).Seconds()
+	if elapsed <= 0 {
+		return 0, 0
+	}
+	return float64(now.read-last.read) / elapsed, float64(now.written-last.written) / elapsed
 }
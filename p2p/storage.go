@@ -0,0 +1,314 @@
+package p2p
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	gosync "sync"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+)
+
+// 存储模式
+const (
+	StorageFull   = "full"   // 完整下载到 DataDir（默认）
+	StorageWindow = "window" // 只保留播放位置附近的分块，总量不超过 CacheSize
+)
+
+// windowStorage 有上限的分块缓存：超过上限时先淘汰播放位置之前（已看过）的分块，
+// 再淘汰离播放位置最远的分块。dir 为空时保存在内存中，否则每个分块一个文件
+// 被淘汰的分块会变成未完成，需要时由 BitTorrent 客户端重新下载
+type windowStorage struct {
+	capacity int64
+	dir      string
+	mu       gosync.Mutex
+	onEvict  func(infoHash metainfo.Hash, index int) // 淘汰后的回调（在单独的 goroutine 中调用）
+	used     int64
+	torrents map[metainfo.Hash]*windowTorrent
+}
+
+// windowTorrent 一个种子的分块
+type windowTorrent struct {
+	storage  *windowStorage
+	infoHash metainfo.Hash
+	dir      string
+	pieces   map[int]*windowPiece
+	playhead int // 当前播放位置所在的分块
+}
+
+// windowPiece 一个分块（加锁：windowStorage.mu）
+type windowPiece struct {
+	torrent  *windowTorrent
+	index    int
+	length   int64
+	data     []byte // 内存模式的数据
+	complete bool
+}
+
+// newWindowStorage 创建有上限的分块缓存
+func newWindowStorage(capacity int64, dir string) *windowStorage {
+	return &windowStorage{
+		capacity: capacity,
+		dir:      dir,
+		torrents: make(map[metainfo.Hash]*windowTorrent),
+	}
+}
+
+// OpenTorrent 实现 storage.ClientImpl
+func (s *windowStorage) OpenTorrent(ctx context.Context, info *metainfo.Info, infoHash metainfo.Hash) (storage.TorrentImpl, error) {
+	t := &windowTorrent{storage: s, infoHash: infoHash, pieces: make(map[int]*windowPiece)}
+	if s.dir != "" {
+		t.dir = filepath.Join(s.dir, infoHash.HexString())
+		// 上次运行留下的分块无法确认是否完整，直接丢弃
+		if err := os.RemoveAll(t.dir); err != nil {
+			return storage.TorrentImpl{}, fmt.Errorf("清理缓存失败: %w", err)
+		}
+		if err := os.MkdirAll(t.dir, 0o755); err != nil {
+			return storage.TorrentImpl{}, fmt.Errorf("创建缓存目录失败: %w", err)
+		}
+	}
+
+	s.mu.Lock()
+	s.torrents[infoHash] = t
+	s.mu.Unlock()
+
+	capacity := func() (int64, bool) { return s.capacity, true }
+	return storage.TorrentImpl{
+		Piece:    t.piece,
+		Close:    t.close,
+		Capacity: &capacity,
+	}, nil
+}
+
+// SetPlayhead 更新播放位置（种子内的字节偏移）
+func (s *windowStorage) SetPlayhead(infoHash metainfo.Hash, offset int64, pieceLength int64) {
+	if pieceLength <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t := s.torrents[infoHash]; t != nil {
+		t.playhead = int(offset / pieceLength)
+	}
+}
+
+// setOnEvict 设置淘汰后的回调
+func (s *windowStorage) setOnEvict(onEvict func(infoHash metainfo.Hash, index int)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onEvict = onEvict
+}
+
+// Used 已使用的字节数
+func (s *windowStorage) Used() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.used
+}
+
+// piece 实现 storage.TorrentImpl.Piece
+func (t *windowTorrent) piece(p metainfo.Piece) storage.PieceImpl {
+	return windowPieceRef{torrent: t, index: p.Index(), length: p.Length()}
+}
+
+// close 释放该种子的所有分块
+func (t *windowTorrent) close() error {
+	s := t.storage
+	s.mu.Lock()
+	for index := range t.pieces {
+		t.removeLocked(index)
+	}
+	delete(s.torrents, t.infoHash)
+	s.mu.Unlock()
+
+	if t.dir != "" {
+		return os.RemoveAll(t.dir)
+	}
+	return nil
+}
+
+// path 分块文件的路径
+func (t *windowTorrent) path(index int) string {
+	return filepath.Join(t.dir, strconv.Itoa(index))
+}
+
+// getLocked 取得分块，不存在时分配空间（必要时淘汰其他分块）
+func (t *windowTorrent) getLocked(index int, length int64) *windowPiece {
+	if p := t.pieces[index]; p != nil {
+		return p
+	}
+
+	t.storage.makeRoomLocked(length)
+	p := &windowPiece{torrent: t, index: index, length: length}
+	if t.dir == "" {
+		p.data = make([]byte, length)
+	}
+	t.pieces[index] = p
+	t.storage.used += length
+	return p
+}
+
+// removeLocked 删除分块
+func (t *windowTorrent) removeLocked(index int) {
+	p := t.pieces[index]
+	if p == nil {
+		return
+	}
+	delete(t.pieces, index)
+	t.storage.used -= p.length
+	if t.dir != "" {
+		os.Remove(t.path(index))
+	}
+}
+
+// makeRoomLocked 淘汰已完成的分块，直到能放下 length 字节
+// 只淘汰已完成的分块（正在下载的分块不动），因此占用可能短暂超过上限
+func (s *windowStorage) makeRoomLocked(length int64) {
+	for s.used+length > s.capacity {
+		victim := s.victimLocked()
+		if victim == nil {
+			return
+		}
+		t := victim.torrent
+		t.removeLocked(victim.index)
+		if s.onEvict != nil {
+			go s.onEvict(t.infoHash, victim.index)
+		}
+	}
+}
+
+// victimLocked 选择要淘汰的分块：播放位置之前离得最远的，其次是播放位置之后离得最远的
+func (s *windowStorage) victimLocked() *windowPiece {
+	var victim *windowPiece
+	var victimBehind bool
+	var victimDistance int
+	for _, t := range s.torrents {
+		for index, p := range t.pieces {
+			if !p.complete {
+				continue
+			}
+			behind := index < t.playhead
+			distance := index - t.playhead
+			if behind {
+				distance = -distance
+			}
+			if victim == nil || (behind && !victimBehind) || (behind == victimBehind && distance > victimDistance) {
+				victim, victimBehind, victimDistance = p, behind, distance
+			}
+		}
+	}
+	return victim
+}
+
+// windowPieceRef 实现 storage.PieceImpl（分块可能已被淘汰，每次操作时重新查找）
+type windowPieceRef struct {
+	torrent *windowTorrent
+	index   int
+	length  int64
+}
+
+// ReadAt 读取分块数据，分块已被淘汰时返回错误（客户端会重新检查完成状态）
+func (r windowPieceRef) ReadAt(b []byte, off int64) (int, error) {
+	s := r.torrent.storage
+	s.mu.Lock()
+	p := r.torrent.pieces[r.index]
+	if p == nil {
+		s.mu.Unlock()
+		return 0, io.EOF
+	}
+	if p.data != nil {
+		defer s.mu.Unlock()
+		if off >= int64(len(p.data)) {
+			return 0, io.EOF
+		}
+		n := copy(b, p.data[off:])
+		if n < len(b) {
+			return n, io.EOF
+		}
+		return n, nil
+	}
+	s.mu.Unlock()
+
+	f, err := os.Open(r.torrent.path(r.index))
+	if err != nil {
+		return 0, io.EOF
+	}
+	defer f.Close()
+	return f.ReadAt(b, off)
+}
+
+// WriteAt 写入分块数据
+func (r windowPieceRef) WriteAt(b []byte, off int64) (int, error) {
+	s := r.torrent.storage
+	s.mu.Lock()
+	p := r.torrent.getLocked(r.index, r.length)
+	if p.data != nil {
+		defer s.mu.Unlock()
+		if off+int64(len(b)) > int64(len(p.data)) {
+			return 0, fmt.Errorf("写入超出分块范围")
+		}
+		return copy(p.data[off:], b), nil
+	}
+	s.mu.Unlock()
+
+	f, err := os.OpenFile(r.torrent.path(r.index), os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.WriteAt(b, off)
+}
+
+// MarkComplete 分块校验通过
+func (r windowPieceRef) MarkComplete() error {
+	return r.setComplete(true)
+}
+
+// MarkNotComplete 分块校验失败或数据丢失
+func (r windowPieceRef) MarkNotComplete() error {
+	return r.setComplete(false)
+}
+
+func (r windowPieceRef) setComplete(complete bool) error {
+	s := r.torrent.storage
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p := r.torrent.pieces[r.index]; p != nil {
+		p.complete = complete
+	}
+	return nil
+}
+
+// Completion 分块是否完整（已淘汰的分块视为未下载）
+func (r windowPieceRef) Completion() storage.Completion {
+	s := r.torrent.storage
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := r.torrent.pieces[r.index]
+	return storage.Completion{Ok: true, Complete: p != nil && p.complete}
+}
+
+// MediaFile 把种子中的文件作为 MediaFile（StorageWindow 模式下读取位置会报告给缓存）
+func (c *Client) MediaFile(f *torrent.File) MediaFile {
+	if c.window == nil {
+		return TorrentFile(f)
+	}
+	t := f.Torrent()
+	return torrentFile{File: f, playhead: func(offset int64) {
+		c.window.SetPlayhead(t.InfoHash(), offset, t.Info().PieceLength)
+	}}
+}
+
+// MediaFiles 把种子中的文件作为 MediaFile 列表
+func (c *Client) MediaFiles(files []*torrent.File) []MediaFile {
+	out := make([]MediaFile, len(files))
+	for i, f := range files {
+		out[i] = c.MediaFile(f)
+	}
+	return out
+}
@@ -0,0 +1,61 @@
+package p2p
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+)
+
+func TestWindowStorageEvictsWatchedPiecesFirst(t *testing.T) {
+	for _, dir := range []string{"", t.TempDir()} {
+		s := newWindowStorage(30, dir)
+		var evicted []int
+
+		info := &metainfo.Info{PieceLength: 10, Length: 100, Pieces: make([]byte, 20*10)}
+		var infoHash metainfo.Hash
+		ts, err := s.OpenTorrent(context.Background(), info, infoHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ts.Close()
+
+		piece := func(i int) storage.PieceImpl { return ts.Piece(info.Piece(i)) }
+		fill := func(i int) {
+			p := piece(i)
+			if _, err := p.WriteAt([]byte("0123456789"), 0); err != nil {
+				t.Fatal(err)
+			}
+			p.MarkComplete()
+		}
+
+		// 正在看第 2 块：缓存放满 1、2、3 之后再下载 4 和 9
+		s.SetPlayhead(infoHash, 25, info.PieceLength)
+		fill(1)
+		fill(2)
+		fill(3)
+		fill(4) // 淘汰已看过的 1
+		fill(9) // 淘汰离播放位置最远的 4
+		for i := 0; i < 10; i++ {
+			if !piece(i).Completion().Complete {
+				evicted = append(evicted, i)
+			}
+		}
+		if want := []int{0, 1, 4, 5, 6, 7, 8}; !slices.Equal(evicted, want) {
+			t.Errorf("dir=%q: incomplete pieces %v, want %v", dir, evicted, want)
+		}
+		if used := s.Used(); used != 30 {
+			t.Errorf("dir=%q: used %d, want 30", dir, used)
+		}
+
+		buf := make([]byte, 4)
+		if _, err := piece(9).ReadAt(buf, 3); err != nil || string(buf) != "3456" {
+			t.Errorf("dir=%q: read %q, %v", dir, buf, err)
+		}
+		if _, err := piece(1).ReadAt(buf, 0); err == nil {
+			t.Errorf("dir=%q: reading an evicted piece should fail", dir)
+		}
+	}
+}
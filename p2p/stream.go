@@ -1,44 +1,195 @@
 package p2p
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
 	"time"
 
-	"github.com/anacrolix/torrent"
+	"movie-night/pkg/logging"
+	"movie-night/pkg/metrics"
 )
 
 // StreamServer HTTP 流服务器
 type StreamServer struct {
 	port       int
-	targetFile *torrent.File
+	targetFile MediaFile
+	server     *http.Server
+	logger     *slog.Logger
+
+	mu        sync.Mutex
+	subtitles map[string]MediaFile // 种子内路径 -> 字幕文件
+	playlist  []MediaFile          // 播放列表（/stream?item=N）
+	web       http.Handler         // 其他路径交给的处理器（浏览器端页面）
 }
 
 // NewStreamServer 创建流服务器
-func NewStreamServer(port int, file *torrent.File) *StreamServer {
+func NewStreamServer(port int, file MediaFile, logger *slog.Logger) *StreamServer {
 	return &StreamServer{
+		logger:     logging.Component(logger, "HTTP"),
 		port:       port,
 		targetFile: file,
+		server:     &http.Server{Addr: fmt.Sprintf(":%d", port)},
+		subtitles:  make(map[string]MediaFile),
 	}
 }
 
-// Start 启动服务器（阻塞）
+// Start 启动服务器（阻塞，Shutdown 后返回 nil）
 func (s *StreamServer) Start() error {
-	http.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
-		reader := s.targetFile.NewReader()
-		reader.SetResponsive()
+	s.logger.Info("流服务已启动",
+		"stream", fmt.Sprintf("http://localhost:%d/stream", s.port),
+		"metrics", fmt.Sprintf("http://localhost:%d/metrics", s.port))
+
+	s.server.Handler = s.Handler()
+	if err := s.server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler 流服务的 HTTP 处理器（/stream、/subtitle、/metrics，其他路径见 SetWebHandler）
+func (s *StreamServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		web := s.web
+		s.mu.Unlock()
+		if web == nil {
+			http.NotFound(w, r)
+			return
+		}
+		web.ServeHTTP(w, r)
+	})
+
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		file := s.targetFile
+		if item := r.URL.Query().Get("item"); item != "" {
+			index, err := strconv.Atoi(item)
+			s.mu.Lock()
+			if err != nil || index < 0 || index >= len(s.playlist) {
+				s.mu.Unlock()
+				http.NotFound(w, r)
+				return
+			}
+			file = s.playlist[index]
+			s.mu.Unlock()
+		}
+
+		reader := file.Open()
 		defer reader.Close()
 
-		http.ServeContent(w, r, s.targetFile.DisplayPath(), time.Now(), reader)
+		http.ServeContent(w, r, file.DisplayPath(), time.Now(), reader)
 	})
 
-	addr := fmt.Sprintf(":%d", s.port)
-	fmt.Printf("📡 [HTTP] 流服务: http://localhost:%d/stream\n", s.port)
+	mux.Handle("/metrics", metrics.Handler())
 
-	return http.ListenAndServe(addr, nil)
+	mux.HandleFunc("/subtitle", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		file, ok := s.subtitles[r.URL.Query().Get("path")]
+		s.mu.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		// 字幕很小，整个下载完再返回
+		file.Download()
+		reader := file.Open()
+		defer reader.Close()
+
+		http.ServeContent(w, r, file.DisplayPath(), time.Now(), reader)
+	})
+
+	return mux
+}
+
+// Shutdown 关闭服务器，等待进行中的请求结束（最长到 ctx 取消）
+func (s *StreamServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
 }
 
 // GetURL 获取流地址
 func (s *StreamServer) GetURL() string {
 	return fmt.Sprintf("http://localhost:%d/stream", s.port)
 }
+
+// GetLANURL 局域网内其他设备（如手机）访问流服务的地址，找不到局域网地址时用 localhost
+func (s *StreamServer) GetLANURL() string {
+	host := "localhost"
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.IsPrivate() && ipNet.IP.To4() != nil {
+				host = ipNet.IP.String()
+				break
+			}
+		}
+	}
+	return fmt.Sprintf("http://%s", net.JoinHostPort(host, strconv.Itoa(s.port)))
+}
+
+// AddSubtitles 通过 /subtitle 提供种子中的字幕文件
+func (s *StreamServer) AddSubtitles(files []MediaFile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, f := range files {
+		s.subtitles[f.Path()] = f
+	}
+}
+
+// SubtitleURL 获取种子内字幕文件的地址
+func (s *StreamServer) SubtitleURL(path string) (string, error) {
+	s.mu.Lock()
+	_, ok := s.subtitles[path]
+	s.mu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("种子中没有字幕文件: %s", path)
+	}
+	return fmt.Sprintf("http://localhost:%d/subtitle?path=%s", s.port, url.QueryEscape(path)), nil
+}
+
+// SetPlaylist 设置播放列表（依次通过 /stream?item=N 提供）
+func (s *StreamServer) SetPlaylist(files []MediaFile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.playlist = files
+}
+
+// SetWebHandler 设置 /stream 等之外的路径的处理器（可在启动后设置）
+func (s *StreamServer) SetWebHandler(handler http.Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.web = handler
+}
+
+// GetPaths 浏览器使用的相对地址：播放列表中的每一项，没有播放列表时只有 /stream
+func (s *StreamServer) GetPaths() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.playlist) <= 1 {
+		return []string{"/stream"}
+	}
+	paths := make([]string, len(s.playlist))
+	for i := range s.playlist {
+		paths[i] = fmt.Sprintf("/stream?item=%d", i)
+	}
+	return paths
+}
+
+// GetPlaylistURLs 获取播放列表中每一项的地址
+func (s *StreamServer) GetPlaylistURLs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	urls := make([]string, len(s.playlist))
+	for i := range s.playlist {
+		urls[i] = fmt.Sprintf("http://localhost:%d/stream?item=%d", s.port, i)
+	}
+	return urls
+}
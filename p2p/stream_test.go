@@ -0,0 +1,59 @@
+package p2p
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"movie-night/p2p/p2ptest"
+)
+
+func TestStreamServerServesMediaFiles(t *testing.T) {
+	video := p2ptest.NewFile("show/e01.mkv", []byte("0123456789"))
+	next := p2ptest.NewFile("show/e02.mkv", []byte("abcdef"))
+	sub := p2ptest.NewFile("show/e01.srt", []byte("1\n00:00:01,000 --> 00:00:02,000\nhi\n"))
+
+	s := NewStreamServer(0, video, nil)
+	s.SetPlaylist([]MediaFile{video, next})
+	s.AddSubtitles([]MediaFile{sub})
+
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	get := func(path, rangeHeader string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	// 播放器靠 Range 请求拖动
+	if code, body := get("/stream", "bytes=2-5"); code != http.StatusPartialContent || body != "2345" {
+		t.Errorf("range: got %d %q", code, body)
+	}
+	if code, body := get("/stream?item=1", ""); code != http.StatusOK || body != "abcdef" {
+		t.Errorf("playlist item: got %d %q", code, body)
+	}
+	if code, _ := get("/stream?item=2", ""); code != http.StatusNotFound {
+		t.Errorf("out of range item: got %d", code)
+	}
+
+	if code, body := get("/subtitle?path="+url.QueryEscape("show/e01.srt"), ""); code != http.StatusOK || body == "" {
+		t.Errorf("subtitle: got %d %q", code, body)
+	}
+	if !sub.Downloaded() {
+		t.Error("subtitle should be downloaded in full before serving")
+	}
+	if code, _ := get("/subtitle?path=missing.srt", ""); code != http.StatusNotFound {
+		t.Errorf("missing subtitle: got %d", code)
+	}
+}
@@ -0,0 +1,33 @@
+package p2p
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/anacrolix/torrent"
+)
+
+// subtitleExts 可以用 sub-add 加载的字幕格式
+var subtitleExts = map[string]bool{
+	".srt": true,
+	".ass": true,
+	".ssa": true,
+	".vtt": true,
+	".sub": true,
+}
+
+// IsSubtitleFile 文件名是否是字幕
+func IsSubtitleFile(name string) bool {
+	return subtitleExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// GetSubtitleFiles 获取种子中的字幕文件
+func (c *Client) GetSubtitleFiles() []*torrent.File {
+	var subs []*torrent.File
+	for _, f := range c.torrent.Files() {
+		if IsSubtitleFile(f.Path()) {
+			subs = append(subs, f)
+		}
+	}
+	return subs
+}
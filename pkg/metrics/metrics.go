@@ -0,0 +1,277 @@
+// Package metrics 轻量的指标收集，以 Prometheus 文本格式输出
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// collector 可以输出自身的指标
+type collector interface {
+	name() string
+	write(w io.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry 创建注册表
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default 默认注册表（/metrics 输出的内容）
+var Default = NewRegistry()
+
+// register 注册指标，同名指标重复注册时返回已有的
+func (r *Registry) register(c collector) collector {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if existing, ok := r.collectors[c.name()]; ok {
+		return existing
+	}
+	r.collectors[c.name()] = c
+	return c
+}
+
+// Write 按名称顺序输出所有指标
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := r.collectors
+	r.mu.Unlock()
+
+	sort.Strings(names)
+	for _, name := range names {
+		r.mu.Lock()
+		c := collectors[name]
+		r.mu.Unlock()
+		c.write(w)
+	}
+}
+
+// Handler 输出注册表的 HTTP 处理器
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(w)
+	})
+}
+
+// Handler 默认注册表的 HTTP 处理器
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// formatFloat Prometheus 格式的浮点数
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// writeHeader 输出 HELP 和 TYPE
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// Counter 只增不减的计数器
+type Counter struct {
+	n, help string
+
+	mu    sync.Mutex
+	value float64
+}
+
+// NewCounter 在默认注册表中创建计数器
+func NewCounter(name, help string) *Counter {
+	return Default.NewCounter(name, help)
+}
+
+// NewCounter 创建计数器
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.register(&Counter{n: name, help: help}).(*Counter)
+}
+
+// Inc 加一
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add 增加（负数会被忽略）
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.value += v
+}
+
+// Value 当前值
+func (c *Counter) Value() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.value
+}
+
+func (c *Counter) name() string { return c.n }
+
+func (c *Counter) write(w io.Writer) {
+	writeHeader(w, c.n, c.help, "counter")
+	fmt.Fprintf(w, "%s %s\n", c.n, formatFloat(c.Value()))
+}
+
+// Gauge 可增可减的数值
+type Gauge struct {
+	n, help string
+
+	mu    sync.Mutex
+	value float64
+	fn    func() float64 // 不为空时在输出时计算
+}
+
+// NewGauge 在默认注册表中创建数值
+func NewGauge(name, help string) *Gauge {
+	return Default.NewGauge(name, help)
+}
+
+// NewGauge 创建数值
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.register(&Gauge{n: name, help: help}).(*Gauge)
+}
+
+// NewGaugeFunc 在默认注册表中创建输出时才计算的数值
+func NewGaugeFunc(name, help string, fn func() float64) *Gauge {
+	return Default.NewGaugeFunc(name, help, fn)
+}
+
+// NewGaugeFunc 创建输出时才计算的数值（同名时替换计算函数）
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) *Gauge {
+	g := r.register(&Gauge{n: name, help: help}).(*Gauge)
+	g.mu.Lock()
+	g.fn = fn
+	g.mu.Unlock()
+	return g
+}
+
+// Set 设置
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.value = v
+}
+
+// Value 当前值
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	fn, value := g.fn, g.value
+	g.mu.Unlock()
+
+	if fn != nil {
+		return fn()
+	}
+	return value
+}
+
+func (g *Gauge) name() string { return g.n }
+
+func (g *Gauge) write(w io.Writer) {
+	writeHeader(w, g.n, g.help, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.n, formatFloat(g.Value()))
+}
+
+// Histogram 分布统计
+type Histogram struct {
+	n, help string
+	buckets []float64 // 升序的桶上界（不含 +Inf）
+
+	mu     sync.Mutex
+	counts []uint64 // 每个桶的计数（非累计）
+	sum    float64
+	count  uint64
+	max    float64
+}
+
+// NewHistogram 在默认注册表中创建分布统计
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return Default.NewHistogram(name, help, buckets)
+}
+
+// NewHistogram 创建分布统计
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	return r.register(&Histogram{
+		n:       name,
+		help:    help,
+		buckets: sorted,
+		counts:  make([]uint64, len(sorted)+1),
+	}).(*Histogram)
+}
+
+// Observe 记录一个值
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	i := sort.SearchFloat64s(h.buckets, v)
+	h.counts[i]++
+	h.sum += v
+	h.count++
+	if h.count == 1 || v > h.max {
+		h.max = v
+	}
+}
+
+// HistogramSummary 分布的简要统计
+type HistogramSummary struct {
+	Count uint64
+	Mean  float64
+	Max   float64
+}
+
+// Summary 简要统计（用于界面显示）
+func (h *Histogram) Summary() HistogramSummary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := HistogramSummary{Count: h.count, Max: h.max}
+	if h.count > 0 {
+		s.Mean = h.sum / float64(h.count)
+	}
+	return s
+}
+
+func (h *Histogram) name() string { return h.n }
+
+func (h *Histogram) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	writeHeader(w, h.n, h.help, "histogram")
+	var cumulative uint64
+	for i, le := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.n, formatFloat(le), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.n, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.n, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.n, h.count)
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegistryOutput(t *testing.T) {
+	r := NewRegistry()
+	seeks := r.NewCounter("test_seeks_total", "Seeks")
+	seeks.Inc()
+	seeks.Add(2)
+	seeks.Add(-5) // 计数器不能减少
+
+	r.NewGaugeFunc("test_peers", "Peers", func() float64 { return 7 })
+
+	drift := r.NewHistogram("test_drift_seconds", "Drift", []float64{1, 0.5})
+	drift.Observe(0.2)
+	drift.Observe(0.5)
+	drift.Observe(3)
+
+	if again := r.NewCounter("test_seeks_total", "Seeks"); again != seeks {
+		t.Error("Expected re-registering a counter to return the existing one")
+	}
+
+	var sb strings.Builder
+	r.Write(&sb)
+	out := sb.String()
+
+	for _, line := range []string{
+		"# TYPE test_seeks_total counter",
+		"test_seeks_total 3",
+		"test_peers 7",
+		`test_drift_seconds_bucket{le="0.5"} 2`,
+		`test_drift_seconds_bucket{le="1"} 2`,
+		`test_drift_seconds_bucket{le="+Inf"} 3`,
+		"test_drift_seconds_sum 3.7",
+		"test_drift_seconds_count 3",
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Missing %q in output:\n%s", line, out)
+		}
+	}
+
+	summary := drift.Summary()
+	if summary.Count != 3 || summary.Max != 3 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+}
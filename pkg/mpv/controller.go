@@ -102,11 +102,52 @@ func (c *Controller) Play() error {
 	return c.sendCommand("set_property", "pause", false)
 }
 
+// SetProperty 设置 MPV 属性
+func (c *Controller) SetProperty(name string, value interface{}) error {
+	return c.sendCommand("set_property", name, value)
+}
+
+// SetSpeed 设置播放速度
+func (c *Controller) SetSpeed(speed float64) error {
+	return c.SetProperty("speed", speed)
+}
+
+// SetAudioTrack 选择音轨（ID 或 "no"）
+func (c *Controller) SetAudioTrack(id string) error {
+	return c.SetProperty("aid", id)
+}
+
+// Quit 让 MPV 退出
+func (c *Controller) Quit() error {
+	return c.sendCommand("quit")
+}
+
 func (c *Controller) GetDuration() (float64, error) {
+	data, err := c.GetProperty("duration")
+	if err != nil {
+		return 0, err
+	}
+
+	// 检查数据
+	if data == nil {
+		return 0, fmt.Errorf("视频未加载完成，时长未知")
+	}
+
+	// 转换为 float64
+	duration, ok := data.(float64)
+	if !ok {
+		return 0, fmt.Errorf("时长格式错误: %T", data)
+	}
+
+	return duration, nil
+}
+
+// GetProperty 查询 MPV 属性（JSON 解码后的原始值，可能为 nil）
+func (c *Controller) GetProperty(name string) (interface{}, error) {
 	// 使用临时连接，避免干扰主连接
 	conn, err := net.Dial("unix", c.SocketPath)
 	if err != nil {
-		return 0, fmt.Errorf("连接 MPV 失败: %w", err)
+		return nil, fmt.Errorf("连接 MPV 失败: %w", err)
 	}
 	defer conn.Close()
 
@@ -114,37 +155,36 @@ func (c *Controller) GetDuration() (float64, error) {
 	conn.SetDeadline(time.Now().Add(2 * time.Second))
 
 	// 发送查询命令
-	cmd := `{"command": ["get_property", "duration"]}` + "\n"
-	if _, err := conn.Write([]byte(cmd)); err != nil {
-		return 0, fmt.Errorf("发送命令失败: %w", err)
+	cmd, _ := json.Marshal(map[string]interface{}{
+		"command":    []interface{}{"get_property", name},
+		"request_id": 1,
+	})
+	if _, err := conn.Write(append(cmd, '\n')); err != nil {
+		return nil, fmt.Errorf("发送命令失败: %w", err)
 	}
 
-	// 读取响应
+	// 读取响应（跳过期间收到的事件）
 	decoder := json.NewDecoder(conn)
-	var response struct {
-		Data  interface{} `json:"data"` // 可能是 float64 或 null
-		Error string      `json:"error"`
-	}
-
-	if err := decoder.Decode(&response); err != nil {
-		return 0, fmt.Errorf("解析响应失败: %w", err)
-	}
+	for {
+		var response struct {
+			Data      interface{} `json:"data"`
+			Error     string      `json:"error"`
+			Event     string      `json:"event"`
+			RequestID int         `json:"request_id"`
+		}
 
-	// 检查错误
-	if response.Error != "" && response.Error != "success" {
-		return 0, fmt.Errorf("MPV 错误: %s", response.Error)
-	}
+		if err := decoder.Decode(&response); err != nil {
+			return nil, fmt.Errorf("解析响应失败: %w", err)
+		}
+		if response.Event != "" {
+			continue
+		}
 
-	// 检查数据
-	if response.Data == nil {
-		return 0, fmt.Errorf("视频未加载完成，时长未知")
-	}
+		// 检查错误
+		if response.Error != "" && response.Error != "success" {
+			return nil, fmt.Errorf("MPV 错误: %s", response.Error)
+		}
 
-	// 转换为 float64
-	duration, ok := response.Data.(float64)
-	if !ok {
-		return 0, fmt.Errorf("时长格式错误: %T", response.Data)
+		return response.Data, nil
 	}
-
-	return duration, nil
 }
@@ -0,0 +1,31 @@
+package mpv
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MessageTarget script-message 的第一个参数，用来区分其他脚本的 client-message
+const MessageTarget = "movie-night"
+
+// KeyBinding 按键绑定：按下 Key 时向本程序发送 Action
+type KeyBinding struct {
+	Key    string // mpv 按键名，如 "Ctrl+p"
+	Action string // 动作名，如 "request-pause"
+}
+
+// BindKeys 在 MPV 中注册按键，按下后 MPV 会发出 client-message 事件
+// 由 Monitor.SubscribeMessages 接收
+func (c *Controller) BindKeys(bindings []KeyBinding) error {
+	for _, b := range bindings {
+		if strings.ContainsAny(b.Action, " \"'") {
+			return fmt.Errorf("动作名不能包含空格或引号: %q", b.Action)
+		}
+
+		cmd := fmt.Sprintf("script-message %s %s", MessageTarget, b.Action)
+		if err := c.sendCommand("keybind", b.Key, cmd); err != nil {
+			return fmt.Errorf("绑定 %s 失败: %w", b.Key, err)
+		}
+	}
+	return nil
+}
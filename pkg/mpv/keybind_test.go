@@ -0,0 +1,95 @@
+package mpv
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBindKeys(t *testing.T) {
+	tmpDir := t.TempDir()
+	socketPath := filepath.Join(tmpDir, "mpv-test-keybind.sock")
+	cmdChan := make(chan string, 10)
+	startMockMpvServer(t, socketPath, cmdChan)
+	time.Sleep(100 * time.Millisecond)
+
+	controller, err := NewController(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer controller.Close()
+
+	if err := controller.BindKeys([]KeyBinding{{Key: "Ctrl+r", Action: "ready"}}); err != nil {
+		t.Fatalf("BindKeys failed: %v", err)
+	}
+
+	select {
+	case cmdJSON := <-cmdChan:
+		var payload struct {
+			Command []interface{} `json:"command"`
+		}
+		if err := json.Unmarshal([]byte(cmdJSON), &payload); err != nil {
+			t.Fatalf("Failed to parse command JSON: %v", err)
+		}
+
+		if len(payload.Command) != 3 || payload.Command[0] != "keybind" || payload.Command[1] != "Ctrl+r" {
+			t.Fatalf("Unexpected command: %v", payload.Command)
+		}
+		if payload.Command[2] != "script-message movie-night ready" {
+			t.Errorf("Unexpected bound command: %v", payload.Command[2])
+		}
+
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for command")
+	}
+
+	if err := controller.BindKeys([]KeyBinding{{Key: "x", Action: "bad action"}}); err == nil {
+		t.Error("Expected action with spaces to be rejected")
+	}
+}
+
+func TestMonitorClientMessage(t *testing.T) {
+	tmpDir := t.TempDir()
+	socketPath := filepath.Join(tmpDir, "mpv-test-message.sock")
+	os.Remove(socketPath)
+
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("Failed to listen on socket: %v", err)
+	}
+	defer l.Close()
+
+	// 连接后模拟 MPV 发出按键消息（包括其他脚本的消息）
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		time.Sleep(100 * time.Millisecond)
+		conn.Write([]byte(`{"event": "client-message", "args": ["other-script", "ignored"]}` + "\n"))
+		conn.Write([]byte(`{"event": "client-message", "args": ["movie-night", "ready"]}` + "\n"))
+		time.Sleep(time.Second)
+	}()
+
+	monitor, err := NewMonitor(socketPath, nil)
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	defer monitor.Stop()
+
+	messages := monitor.SubscribeMessages()
+	monitor.Start()
+
+	select {
+	case args := <-messages:
+		if len(args) != 1 || args[0] != "ready" {
+			t.Errorf("Expected [ready], got %v", args)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for client-message")
+	}
+}
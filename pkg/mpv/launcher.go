@@ -1,21 +1,34 @@
 package mpv
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"time"
+
+	"movie-night/pkg/logging"
 )
 
+// quitTimeout ctx 取消后等待 MPV 退出的时间，超时后强制结束
+const quitTimeout = 3 * time.Second
+
 // LaunchConfig MPV 启动配置
 type LaunchConfig struct {
 	VideoURL   string
+	Playlist   []string // 播放列表中 VideoURL 之后的项
 	SocketPath string
 	Title      string
 	Fullscreen bool
+	NoScript   bool         // 不加载内置脚本
+	Logger     *slog.Logger // 日志（为空时使用 slog.Default()）
 }
 
-// Launch 启动 MPV 播放器（阻塞）
-func Launch(cfg LaunchConfig) error {
+// Launch 启动 MPV 播放器（阻塞，直到 MPV 退出或 ctx 取消）
+func Launch(ctx context.Context, cfg LaunchConfig) error {
+	logger := logging.Component(cfg.Logger, "MPV")
+
 	// 删除旧 Socket
 	if _, err := os.Stat(cfg.SocketPath); err == nil {
 		os.Remove(cfg.SocketPath)
@@ -26,19 +39,36 @@ func Launch(cfg LaunchConfig) error {
 		"--input-ipc-server=" + cfg.SocketPath,
 		"--force-window",
 		"--title=" + cfg.Title,
+		// 播完停在最后一帧，由房间统一切到下一项
+		"--keep-open=always",
 	}
+	args = append(args, cfg.Playlist...)
 
 	if cfg.Fullscreen {
 		args = append(args, "--fs")
 	}
 
-	fmt.Printf("📺 [MPV] 启动播放器\n")
-	fmt.Printf("   视频: %s\n", cfg.VideoURL)
-	fmt.Printf("   Socket: %s\n", cfg.SocketPath)
+	// 内置脚本写到私有临时目录，退出后删除
+	if !cfg.NoScript {
+		scriptPath, err := WriteBridgeScript("")
+		if err != nil {
+			logger.Warn("内置脚本不可用", "err", err)
+		} else {
+			defer os.RemoveAll(filepath.Dir(scriptPath))
+			args = append(args, "--script="+scriptPath)
+		}
+	}
+
+	logger.Info("启动播放器", "video", cfg.VideoURL, "playlist", len(cfg.Playlist), "socket", cfg.SocketPath)
 
-	cmd := exec.Command("mpv", args...)
+	cmd := exec.CommandContext(ctx, "mpv", args...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
+	// ctx 取消时先让 MPV 正常退出
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = quitTimeout
 
 	return cmd.Run()
 }
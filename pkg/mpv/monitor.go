@@ -3,11 +3,14 @@ package mpv
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
+	"strconv"
+	"sync"
 	"time"
 
 	"movie-night/model"
+	"movie-night/pkg/logging"
 )
 
 // MPVEvent MPV 事件
@@ -16,6 +19,7 @@ type MPVEvent struct {
 	Name  string      `json:"name"`
 	Data  interface{} `json:"data"`
 	Error string      `json:"error"`
+	Args  []string    `json:"args"` // client-message 的参数
 }
 
 // Monitor MPV 状态监听器
@@ -24,10 +28,15 @@ type Monitor struct {
 	conn       net.Conn
 	statusCh   chan model.PlayStatus // 状态 channel
 	stopCh     chan struct{}
+	logger     *slog.Logger
+
+	subMu       sync.Mutex
+	subscribers []chan model.PlayStatus // 额外的订阅者（每个只保留最新状态）
+	messageSubs []chan []string         // client-message 订阅者
 }
 
 // NewMonitor 创建监听器
-func NewMonitor(socketPath string) (*Monitor, error) {
+func NewMonitor(socketPath string, logger *slog.Logger) (*Monitor, error) {
 	// 等待 Socket 就绪
 	var conn net.Conn
 	var err error
@@ -49,19 +58,26 @@ func NewMonitor(socketPath string) (*Monitor, error) {
 		conn:       conn,
 		statusCh:   make(chan model.PlayStatus, 1), // 只保留最新状态
 		stopCh:     make(chan struct{}),
+		logger:     logging.Component(logger, "Monitor"),
 	}
 
 	// 发送监听命令
 	commands := []string{
 		`{"command": ["observe_property", 1, "time-pos"]}`,
 		`{"command": ["observe_property", 2, "pause"]}`,
+		`{"command": ["observe_property", 3, "speed"]}`,
+		`{"command": ["observe_property", 4, "aid"]}`,
+		`{"command": ["observe_property", 5, "sid"]}`,
+		`{"command": ["observe_property", 6, "chapter"]}`,
+		`{"command": ["observe_property", 7, "playlist-pos"]}`,
+		`{"command": ["observe_property", 8, "eof-reached"]}`,
 	}
 
 	for _, cmd := range commands {
 		conn.Write([]byte(cmd + "\n"))
 	}
 
-	fmt.Println("👂 [Monitor] 开始监听 MPV 播放状态")
+	m.logger.Info("开始监听 MPV 播放状态")
 
 	return m, nil
 }
@@ -76,6 +92,43 @@ func (m *Monitor) GetStatusChannel() <-chan model.PlayStatus {
 	return m.statusCh
 }
 
+// Subscribe 注册一个新的状态订阅者
+// 与 GetStatusChannel 互不干扰，每个订阅者都只保留最新状态
+func (m *Monitor) Subscribe() <-chan model.PlayStatus {
+	ch := make(chan model.PlayStatus, 1)
+
+	m.subMu.Lock()
+	m.subscribers = append(m.subscribers, ch)
+	m.subMu.Unlock()
+
+	return ch
+}
+
+// SubscribeMessages 订阅发给本程序的 client-message（按键绑定触发）
+// 收到的是去掉 MessageTarget 后的参数，如 ["ready"]
+func (m *Monitor) SubscribeMessages() <-chan []string {
+	ch := make(chan []string, 16)
+
+	m.subMu.Lock()
+	m.messageSubs = append(m.messageSubs, ch)
+	m.subMu.Unlock()
+
+	return ch
+}
+
+// Unsubscribe 移除订阅者
+func (m *Monitor) Unsubscribe(ch <-chan model.PlayStatus) {
+	m.subMu.Lock()
+	defer m.subMu.Unlock()
+
+	for i, sub := range m.subscribers {
+		if sub == ch {
+			m.subscribers = append(m.subscribers[:i], m.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
 // listen 监听循环
 func (m *Monitor) listen() {
 	decoder := json.NewDecoder(m.conn)
@@ -90,10 +143,26 @@ func (m *Monitor) listen() {
 
 		var event MPVEvent
 		if err := decoder.Decode(&event); err != nil {
-			log.Printf("❌ [Monitor] MPV 连接断开: %v", err)
+			m.logger.Error("MPV 连接断开", "err", err)
 			return
 		}
 
+		// 按键绑定发来的消息
+		if event.Event == "client-message" {
+			if len(event.Args) > 1 && event.Args[0] == MessageTarget {
+				m.subMu.Lock()
+				for _, ch := range m.messageSubs {
+					select {
+					case ch <- event.Args[1:]:
+					default:
+						// 处理不过来时丢弃，按键可以再按
+					}
+				}
+				m.subMu.Unlock()
+			}
+			continue
+		}
+
 		// 处理事件
 		if event.Event == "property-change" {
 			updated := false
@@ -109,26 +178,78 @@ func (m *Monitor) listen() {
 					currentStatus.Paused = isPaused
 					updated = true
 				}
+			case "speed":
+				if speed, ok := event.Data.(float64); ok {
+					currentStatus.Speed = speed
+					updated = true
+				}
+			case "aid":
+				currentStatus.Aid = trackID(event.Data)
+				updated = true
+			case "sid":
+				currentStatus.Sid = trackID(event.Data)
+				updated = true
+			case "chapter":
+				currentStatus.Chapter = -1
+				if chapter, ok := event.Data.(float64); ok {
+					currentStatus.Chapter = int(chapter)
+				}
+				updated = true
+			case "playlist-pos":
+				if pos, ok := event.Data.(float64); ok && pos >= 0 {
+					currentStatus.Item = int(pos)
+					updated = true
+				}
+			case "eof-reached":
+				eof, _ := event.Data.(bool)
+				currentStatus.EOF = eof
+				updated = true
 			}
 
 			// 有更新时发送到 channel（非阻塞）
 			if updated {
-				select {
-				case m.statusCh <- currentStatus:
-					// 成功发送
-				default:
-					// channel 满，丢弃旧的，发送新的
-					select {
-					case <-m.statusCh:
-						m.statusCh <- currentStatus
-					default:
-					}
+				pushLatest(m.statusCh, currentStatus)
+
+				m.subMu.Lock()
+				for _, ch := range m.subscribers {
+					pushLatest(ch, currentStatus)
 				}
+				m.subMu.Unlock()
 			}
 		}
 	}
 }
 
+// trackID 把 aid/sid 属性值转换为字符串（数字 ID 或 "no"）
+func trackID(data interface{}) string {
+	switch v := data.(type) {
+	case float64:
+		return strconv.Itoa(int(v))
+	case string:
+		return v
+	default:
+		// false / null 表示没有选择轨道
+		return "no"
+	}
+}
+
+// pushLatest 非阻塞发送，channel 满时丢弃旧的，保留新的
+func pushLatest(ch chan model.PlayStatus, status model.PlayStatus) {
+	select {
+	case ch <- status:
+		// 成功发送
+	default:
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- status:
+		default:
+		}
+	}
+}
+
 // GetCurrentStatus 获取当前状态（同步）
 func (m *Monitor) GetCurrentStatus() model.PlayStatus {
 	select {
@@ -0,0 +1,268 @@
+// Package mpvtest 进程内的模拟 MPV（JSON IPC），用于测试 mpv.Controller / mpv.Monitor 及其上层
+package mpvtest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Server 模拟的 MPV：维护播放属性，响应属性查询，记录收到的命令，
+// 并像真实 MPV 一样向 observe_property 的连接推送属性变化
+type Server struct {
+	SocketPath string
+
+	listener net.Listener
+	commands chan []interface{}
+
+	mu        sync.Mutex
+	props     map[string]interface{}
+	conns     map[*conn]bool
+	observers map[*conn]map[string]float64 // 连接 -> 属性 -> observe id
+}
+
+// conn 一个 IPC 连接（写入需加锁）
+type conn struct {
+	net.Conn
+	mu sync.Mutex
+}
+
+// send 写一行 JSON
+func (c *conn) send(v interface{}) {
+	line, _ := json.Marshal(v)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Write(append(line, '\n'))
+}
+
+// commandBuffer 记录命令的缓冲，满了之后的命令会被丢弃
+const commandBuffer = 1024
+
+// NewServer 在 socketPath 上启动模拟 MPV
+func NewServer(socketPath string) (*Server, error) {
+	os.Remove(socketPath)
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("监听失败: %w", err)
+	}
+
+	s := &Server{
+		SocketPath: socketPath,
+		listener:   listener,
+		commands:   make(chan []interface{}, commandBuffer),
+		props: map[string]interface{}{
+			"time-pos":       0.0,
+			"pause":          false,
+			"speed":          1.0,
+			"duration":       0.0,
+			"aid":            1.0,
+			"sid":            false,
+			"chapter":        -1.0,
+			"chapter-list":   []interface{}{},
+			"playlist-pos":   0.0,
+			"playlist-count": 1.0,
+			"eof-reached":    false,
+			"track-list":     []interface{}{},
+		},
+		conns:     make(map[*conn]bool),
+		observers: make(map[*conn]map[string]float64),
+	}
+	go s.accept()
+	return s, nil
+}
+
+// Commands 收到的命令（属性查询和 observe_property 除外）
+func (s *Server) Commands() <-chan []interface{} {
+	return s.commands
+}
+
+// WaitCommand 等待指定名称的命令，跳过其他命令
+func (s *Server) WaitCommand(name string, timeout time.Duration) ([]interface{}, error) {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case cmd := <-s.commands:
+			if fmt.Sprint(cmd[0]) == name {
+				return cmd, nil
+			}
+		case <-deadline:
+			return nil, fmt.Errorf("等待命令 %s 超时", name)
+		}
+	}
+}
+
+// Get 获取属性
+func (s *Server) Get(name string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.props[name]
+}
+
+// Set 设置属性并通知观察者（模拟用户在 MPV 里的操作）
+func (s *Server) Set(name string, value interface{}) {
+	s.mu.Lock()
+	s.props[name] = value
+	var targets []*conn
+	var ids []float64
+	for c, observed := range s.observers {
+		if id, ok := observed[name]; ok {
+			targets = append(targets, c)
+			ids = append(ids, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for i, c := range targets {
+		c.send(map[string]interface{}{"event": "property-change", "id": ids[i], "name": name, "data": value})
+	}
+}
+
+// SendMessage 向所有连接发送 client-message（模拟按键绑定或脚本）
+func (s *Server) SendMessage(args ...string) {
+	s.mu.Lock()
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	s.mu.Unlock()
+
+	for _, c := range conns {
+		c.send(map[string]interface{}{"event": "client-message", "args": args})
+	}
+}
+
+// Close 停止模拟 MPV 并断开所有连接
+func (s *Server) Close() error {
+	err := s.listener.Close()
+
+	s.mu.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	os.Remove(s.SocketPath)
+	return err
+}
+
+// accept 接受连接
+func (s *Server) accept() {
+	for {
+		nc, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &conn{Conn: nc}
+		s.mu.Lock()
+		s.conns[c] = true
+		s.mu.Unlock()
+		go s.serve(c)
+	}
+}
+
+// serve 处理一个连接上的命令
+func (s *Server) serve(c *conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		delete(s.observers, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	scanner := bufio.NewScanner(c)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var req struct {
+			Command   []interface{} `json:"command"`
+			RequestID int           `json:"request_id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || len(req.Command) == 0 {
+			continue
+		}
+
+		resp := map[string]interface{}{"error": "success", "request_id": req.RequestID}
+		if data, err := s.handle(c, req.Command); err != nil {
+			resp["error"] = err.Error()
+		} else if data != nil {
+			resp["data"] = data
+		}
+		c.send(resp)
+	}
+}
+
+// handle 执行命令，返回响应数据
+func (s *Server) handle(c *conn, cmd []interface{}) (interface{}, error) {
+	name := fmt.Sprint(cmd[0])
+	arg := func(i int) interface{} {
+		if i < len(cmd) {
+			return cmd[i]
+		}
+		return nil
+	}
+
+	switch name {
+	case "get_property":
+		s.mu.Lock()
+		value, ok := s.props[fmt.Sprint(arg(1))]
+		s.mu.Unlock()
+		if !ok {
+			return nil, fmt.Errorf("property unavailable")
+		}
+		return value, nil
+
+	case "observe_property":
+		id, _ := arg(1).(float64)
+		property := fmt.Sprint(arg(2))
+		s.mu.Lock()
+		if s.observers[c] == nil {
+			s.observers[c] = make(map[string]float64)
+		}
+		s.observers[c][property] = id
+		value, ok := s.props[property]
+		s.mu.Unlock()
+		// 真实 MPV 会立即推送一次当前值
+		if ok {
+			c.send(map[string]interface{}{"event": "property-change", "id": id, "name": property, "data": value})
+		}
+		return nil, nil
+	}
+
+	s.record(cmd)
+
+	switch name {
+	case "set_property":
+		s.Set(fmt.Sprint(arg(1)), normalize(arg(2)))
+	case "seek":
+		target, _ := arg(1).(float64)
+		if mode := fmt.Sprint(arg(2)); mode != "absolute" {
+			current, _ := s.Get("time-pos").(float64)
+			target += current
+		}
+		s.Set("time-pos", target)
+	}
+	return nil, nil
+}
+
+// record 记录命令（缓冲满时丢弃）
+func (s *Server) record(cmd []interface{}) {
+	select {
+	case s.commands <- cmd:
+	default:
+	}
+}
+
+// normalize 把 set_property 的字符串数字转换为 MPV 会回报的数字（如 aid "2" -> 2）
+func normalize(value interface{}) interface{} {
+	if str, ok := value.(string); ok {
+		var n float64
+		if _, err := fmt.Sscanf(str, "%g", &n); err == nil && fmt.Sprint(n) == str {
+			return n
+		}
+	}
+	return value
+}
@@ -6,22 +6,52 @@ import (
 	"strings"
 )
 
+const roomOverlayID = 45 // 房间成员面板使用的 overlay
+
 // PeerSyncState 定义参与者的同步状态
 type PeerSyncState struct {
 	Name       string // 节点名称或ID
 	IsReady    bool   // 是否已就绪
-	Buffering  int    // 缓冲进度百分比 (0-100)
+	Buffering  int    // 缓冲进度百分比 (0-100)，小于 0 时不显示
 	StatusText string // 额外的状态文本 (如 "Buffering", "Seeked")
+	Detail     string // 附加信息（如偏差），灰色显示在状态之后
 }
 
 // DrawSyncOverlay 在屏幕上绘制同步状态面板
 func (c *Controller) DrawSyncOverlay(states map[string]PeerSyncState) error {
+	// 标题样式: 顶部居中(\an8), 字号48(\fs48), 加粗(\b1)
+	assContent := buildPeerListASS(`\an8\fs48`, "Sync Status", states)
+
+	// 4. 发送 IPC 命令
+	// 命令格式: ["osd-overlay", <overlay_id>, "ass-events", <ass_content_string>]
+	// overlay_id = 1
+	return c.sendCommand("osd-overlay", 42, "ass-events", assContent)
+}
+
+// DrawRoomOverlay 在左上角绘制房间成员面板（可随时开关，不影响同步面板）
+// summary 不为空时显示在标题下方（如同步质量汇总）
+func (c *Controller) DrawRoomOverlay(states map[string]PeerSyncState, summary string) error {
+	title := "Room"
+	if summary != "" {
+		title += `{\N}{\fs24\b0\c&HAAAAAA&}` + escapeASS(summary)
+	}
+	assContent := buildPeerListASS(`\an7\fs32`, title, states)
+	return c.sendCommand("osd-overlay", roomOverlayID, "ass-events", assContent)
+}
+
+// ClearRoomOverlay 清除房间成员面板
+func (c *Controller) ClearRoomOverlay() error {
+	return c.sendCommand("osd-overlay", roomOverlayID, "ass-events", "")
+}
+
+// buildPeerListASS 生成参与者列表，style 为标题的位置和字号标签
+func buildPeerListASS(style, title string, states map[string]PeerSyncState) string {
 	// 1. 构建 ASS 内容
 	var sb strings.Builder
 
-	// 标题样式: 居中(\an5), 字号48(\fs48), 加粗(\b1), 亮青色(\c&HFFFF00&) - 注意ASS颜色是BGR
+	// 标题: 加粗(\b1), 亮青色(\c&HFFFF00&) - 注意ASS颜色是BGR
 	// 这里使用简单的白色或亮色作为标题
-	sb.WriteString(`{\an8\fs48\b1\bord2\3c&H000000&}{\c&HFFFFFF&}Sync Status{\N}{\fs30\b0}`)
+	sb.WriteString(`{` + style + `\b1\bord2\3c&H000000&}{\c&HFFFFFF&}` + title + `{\N}{\fs30\b0}`)
 	// 空行
 	sb.WriteString(`{\N}`)
 
@@ -43,27 +73,25 @@ func (c *Controller) DrawSyncOverlay(states map[string]PeerSyncState) error {
 	for _, it := range items {
 		s := it.State
 		// 名字
-		sb.WriteString(fmt.Sprintf(`{\c&HFFFFFF&}%s: `, s.Name))
+		sb.WriteString(fmt.Sprintf(`{\c&HFFFFFF&}%s: `, escapeASS(s.Name)))
 
 		if s.IsReady {
 			// Ready: 绿色 (\c&H00FF00&)
 			sb.WriteString(`{\c&H00FF00&}Ready`)
-		} else {
+		} else if s.Buffering < 0 {
 			// Not Ready: 黄色 (\c&H00FFFF&)
+			sb.WriteString(fmt.Sprintf(`{\c&H00FFFF&}%s`, s.StatusText))
+		} else {
 			sb.WriteString(fmt.Sprintf(`{\c&H00FFFF&}%s (%d%%)`, s.StatusText, s.Buffering))
 		}
+		if s.Detail != "" {
+			sb.WriteString(fmt.Sprintf(`{\c&HAAAAAA&} · %s`, escapeASS(s.Detail)))
+		}
 		// 换行
 		sb.WriteString(`{\N}`)
 	}
 
-	assContent := sb.String()
-
-	fmt.Println("ASS Content:", assContent)
-
-	// 4. 发送 IPC 命令
-	// 命令格式: ["osd-overlay", <overlay_id>, "ass-events", <ass_content_string>]
-	// overlay_id = 1
-	return c.sendCommand("osd-overlay", 42, "ass-events", assContent)
+	return sb.String()
 }
 
 // ClearSyncOverlay 清除同步状态面板
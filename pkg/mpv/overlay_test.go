@@ -165,3 +165,61 @@ func TestClearSyncOverlay(t *testing.T) {
 		t.Fatal("Timeout waiting for command")
 	}
 }
+
+func TestDrawVoteOverlay(t *testing.T) {
+	tmpDir := t.TempDir()
+	socketPath := filepath.Join(tmpDir, "mpv-test-vote.sock")
+	cmdChan := make(chan string, 10)
+	startMockMpvServer(t, socketPath, cmdChan)
+	time.Sleep(100 * time.Millisecond)
+
+	controller, err := NewController(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer controller.Close()
+
+	err = controller.DrawVoteOverlay(VoteInfo{
+		Title:     "Pause",
+		Proposer:  "Alice",
+		Voters:    []string{"Alice", "Bob"},
+		Needed:    3,
+		Remaining: 12,
+	})
+	if err != nil {
+		t.Fatalf("DrawVoteOverlay failed: %v", err)
+	}
+
+	select {
+	case cmdJSON := <-cmdChan:
+		var payload struct {
+			Command []interface{} `json:"command"`
+		}
+		if err := json.Unmarshal([]byte(cmdJSON), &payload); err != nil {
+			t.Fatalf("Failed to parse command JSON: %v", err)
+		}
+
+		if fmt.Sprintf("%v", payload.Command[1]) != "44" {
+			t.Errorf("Expected overlay id 44, got %v", payload.Command[1])
+		}
+
+		assContent := payload.Command[3].(string)
+		t.Logf("Generated ASS: %s", assContent)
+
+		if !strings.Contains(assContent, "Vote: Pause") {
+			t.Error("ASS content missing vote title")
+		}
+		if !strings.Contains(assContent, "2/3") {
+			t.Error("ASS content missing vote count")
+		}
+		if !strings.Contains(assContent, "12s") {
+			t.Error("ASS content missing countdown")
+		}
+		if !strings.Contains(assContent, "Alice, Bob") {
+			t.Error("ASS content missing voters")
+		}
+
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for command")
+	}
+}
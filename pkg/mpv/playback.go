@@ -0,0 +1,81 @@
+package mpv
+
+import (
+	"fmt"
+	"strings"
+
+	"movie-night/model"
+)
+
+// intermissionOverlayID 幕间倒计时使用的 overlay ID
+const intermissionOverlayID = 46
+
+// GetChapters 获取当前视频的章节列表
+func (c *Controller) GetChapters() ([]model.Chapter, error) {
+	data, err := c.GetProperty("chapter-list")
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("章节列表格式错误: %T", data)
+	}
+
+	chapters := make([]model.Chapter, 0, len(list))
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		chapter := model.Chapter{}
+		chapter.Title, _ = m["title"].(string)
+		chapter.Time, _ = m["time"].(float64)
+		chapters = append(chapters, chapter)
+	}
+	return chapters, nil
+}
+
+// GetPlaylistCount 获取播放列表长度
+func (c *Controller) GetPlaylistCount() (int, error) {
+	data, err := c.GetProperty("playlist-count")
+	if err != nil {
+		return 0, err
+	}
+	count, ok := data.(float64)
+	if !ok {
+		return 0, fmt.Errorf("播放列表长度格式错误: %T", data)
+	}
+	return int(count), nil
+}
+
+// SetChapter 跳到指定章节
+func (c *Controller) SetChapter(index int) error {
+	return c.SetProperty("chapter", index)
+}
+
+// SetPlaylistPos 切换到播放列表中的指定项
+func (c *Controller) SetPlaylistPos(index int) error {
+	return c.SetProperty("playlist-pos", index)
+}
+
+// PlaylistNext 播放下一项
+func (c *Controller) PlaylistNext() error {
+	return c.sendCommand("playlist-next", "force")
+}
+
+// DrawIntermissionOverlay 绘制幕间倒计时（居中的大字）
+func (c *Controller) DrawIntermissionOverlay(title string, remaining int) error {
+	var sb strings.Builder
+	sb.WriteString(`{\an5\fs64\bord3\3c&H000000&\1c&HFFFFFF&}`)
+	sb.WriteString(escapeASS(title))
+	if remaining > 0 {
+		sb.WriteString(fmt.Sprintf(`\N{\fs40\1c&H00FFFF&}Next in %ds`, remaining))
+	}
+	return c.sendCommand("osd-overlay", intermissionOverlayID, "ass-events", sb.String(), overlayResX, overlayResY)
+}
+
+// ClearIntermissionOverlay 清除幕间倒计时
+func (c *Controller) ClearIntermissionOverlay() error {
+	return c.sendCommand("osd-overlay", intermissionOverlayID, "ass-events", "")
+}
@@ -0,0 +1,173 @@
+package mpv
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	reactionOverlayID = 43 // 反应动画使用的 overlay（与同步面板 42 区分）
+
+	// ASS 坐标系，与 osd-overlay 的 res_x/res_y 参数一致
+	overlayResX = 1280
+	overlayResY = 720
+
+	reactionLanes     = 5   // 屏幕底部的横向轨道数
+	reactionParticles = 5   // 每次爆发的表情数量
+	reactionRiseSpeed = 220 // 上升速度（像素/秒）
+)
+
+// ReactionBurst 一次表情爆发
+type ReactionBurst struct {
+	Emoji string    // 显示的表情
+	Label string    // 发送者名称
+	Start time.Time // 开始时间
+	Lane  int       // 所在轨道
+}
+
+// BuildReactionASS 生成指定时刻的一帧动画（每个粒子一行 ASS 事件）
+func BuildReactionASS(bursts []ReactionBurst, now time.Time, lifetime time.Duration) string {
+	var lines []string
+
+	for _, b := range bursts {
+		elapsed := now.Sub(b.Start)
+		if elapsed < 0 || elapsed >= lifetime {
+			continue
+		}
+
+		progress := float64(elapsed) / float64(lifetime)
+		alpha := fadeAlpha(progress)
+		baseX := overlayResX/reactionLanes*(b.Lane%reactionLanes) + overlayResX/reactionLanes/2
+		baseY := overlayResY - 40
+
+		// 发送者名称，停留在底部
+		if b.Label != "" {
+			lines = append(lines, fmt.Sprintf(`{\an2\pos(%d,%d)\fs22\bord2\3c&H000000&\c&HFFFFFF&\alpha&H%02X&}%s`,
+				baseX, baseY+30, alpha, escapeASS(b.Label)))
+		}
+
+		// 表情粒子：错峰出发，向上漂浮并左右摆动
+		for i := 0; i < reactionParticles; i++ {
+			t := elapsed.Seconds() - float64(i)*0.12
+			if t < 0 {
+				continue
+			}
+
+			dx := float64(i-reactionParticles/2) * 36
+			sway := 14 * math.Sin(t*5+float64(i))
+			x := float64(baseX) + dx + sway
+			y := float64(baseY) - reactionRiseSpeed*t
+
+			// 出现时的弹出效果
+			scale := 100
+			if t < 0.2 {
+				scale = 50 + int(t/0.2*50)
+			}
+
+			lines = append(lines, fmt.Sprintf(`{\an2\pos(%.0f,%.0f)\fs44\fscx%d\fscy%d\bord0\alpha&H%02X&}%s`,
+				x, y, scale, scale, alpha, b.Emoji))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// fadeAlpha 根据进度计算透明度（前 60% 不透明，之后线性淡出）
+func fadeAlpha(progress float64) int {
+	if progress < 0.6 {
+		return 0
+	}
+	a := int((progress - 0.6) / 0.4 * 255)
+	if a > 255 {
+		a = 255
+	}
+	return a
+}
+
+// escapeASS 转义用户输入，避免被当作 ASS 标签
+func escapeASS(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "{", `\{`)
+	s = strings.ReplaceAll(s, "}", `\}`)
+	s = strings.ReplaceAll(s, "\n", " ")
+	return s
+}
+
+// ReactionRenderer 表情反应动画渲染器
+// 有活跃的爆发时按帧刷新 overlay，全部结束后清除并停止
+type ReactionRenderer struct {
+	ctrl          *Controller
+	Lifetime      time.Duration // 单次爆发持续时间
+	FrameInterval time.Duration // 帧间隔
+
+	mu       sync.Mutex
+	bursts   []ReactionBurst
+	nextLane int
+	running  bool
+}
+
+// NewReactionRenderer 创建反应渲染器
+func NewReactionRenderer(ctrl *Controller) *ReactionRenderer {
+	return &ReactionRenderer{
+		ctrl:          ctrl,
+		Lifetime:      2500 * time.Millisecond,
+		FrameInterval: 50 * time.Millisecond,
+	}
+}
+
+// Burst 触发一次表情爆发
+func (r *ReactionRenderer) Burst(emoji, label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bursts = append(r.bursts, ReactionBurst{
+		Emoji: emoji,
+		Label: label,
+		Start: time.Now(),
+		Lane:  r.nextLane,
+	})
+	r.nextLane = (r.nextLane + 1) % reactionLanes
+
+	if !r.running {
+		r.running = true
+		go r.animate()
+	}
+}
+
+// animate 动画循环
+func (r *ReactionRenderer) animate() {
+	ticker := time.NewTicker(r.FrameInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		r.mu.Lock()
+		active := r.bursts[:0]
+		for _, b := range r.bursts {
+			if now.Sub(b.Start) < r.Lifetime {
+				active = append(active, b)
+			}
+		}
+		r.bursts = active
+
+		if len(active) == 0 {
+			r.running = false
+			r.mu.Unlock()
+			r.ctrl.sendCommand("osd-overlay", reactionOverlayID, "ass-events", "")
+			return
+		}
+
+		frame := BuildReactionASS(active, now, r.Lifetime)
+		r.mu.Unlock()
+
+		if err := r.ctrl.sendCommand("osd-overlay", reactionOverlayID, "ass-events", frame, overlayResX, overlayResY); err != nil {
+			r.mu.Lock()
+			r.bursts = nil
+			r.running = false
+			r.mu.Unlock()
+			return
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package mpv
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildReactionASS(t *testing.T) {
+	start := time.Now()
+	bursts := []ReactionBurst{
+		{Emoji: "😂", Label: "Alice", Start: start, Lane: 0},
+		{Emoji: "👏", Label: "{Bob}", Start: start.Add(-10 * time.Second), Lane: 1}, // 已过期
+	}
+
+	ass := BuildReactionASS(bursts, start.Add(time.Second), 2500*time.Millisecond)
+	t.Logf("Generated ASS: %s", ass)
+
+	if !strings.Contains(ass, "😂") {
+		t.Error("ASS content missing emoji")
+	}
+	if !strings.Contains(ass, "Alice") {
+		t.Error("ASS content missing sender label")
+	}
+	if strings.Contains(ass, "👏") || strings.Contains(ass, "Bob") {
+		t.Error("Expired burst should not be rendered")
+	}
+
+	// 每个粒子一行，加上一行名称
+	if lines := strings.Split(ass, "\n"); len(lines) != reactionParticles+1 {
+		t.Errorf("Expected %d ASS events, got %d", reactionParticles+1, len(lines))
+	}
+
+	// 淡出阶段透明度不为 0
+	faded := BuildReactionASS(bursts[:1], start.Add(2400*time.Millisecond), 2500*time.Millisecond)
+	if strings.Contains(faded, `\alpha&H00&`) {
+		t.Error("Expected burst to be fading out near the end of its lifetime")
+	}
+
+	// 用户输入中的花括号需要转义
+	escaped := BuildReactionASS([]ReactionBurst{{Emoji: "😱", Label: "{\\b1}", Start: start}}, start, time.Second)
+	if strings.Contains(escaped, "}{\\b1}") {
+		t.Error("Label was not escaped")
+	}
+}
+
+func TestReactionRendererBurst(t *testing.T) {
+	tmpDir := t.TempDir()
+	socketPath := filepath.Join(tmpDir, "mpv-test-reaction.sock")
+	cmdChan := make(chan string, 10)
+	startMockMpvServer(t, socketPath, cmdChan)
+	time.Sleep(100 * time.Millisecond)
+
+	controller, err := NewController(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer controller.Close()
+
+	renderer := NewReactionRenderer(controller)
+	renderer.Burst("😂", "Alice")
+
+	select {
+	case cmdJSON := <-cmdChan:
+		var payload struct {
+			Command []interface{} `json:"command"`
+		}
+		if err := json.Unmarshal([]byte(cmdJSON), &payload); err != nil {
+			t.Fatalf("Failed to parse command JSON: %v", err)
+		}
+
+		if payload.Command[0] != "osd-overlay" {
+			t.Errorf("Expected command 'osd-overlay', got %v", payload.Command[0])
+		}
+		if fmt.Sprintf("%v", payload.Command[1]) != "43" {
+			t.Errorf("Expected overlay id 43, got %v", payload.Command[1])
+		}
+		if !strings.Contains(payload.Command[3].(string), "😂") {
+			t.Error("Frame missing emoji")
+		}
+
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for command")
+	}
+}
@@ -0,0 +1,94 @@
+package mpv
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// 内置脚本的消息协议
+//
+// Go -> 脚本：script-message-to movie_night <命令> <JSON>
+//
+//	set-menu   [{"title": "...", "action": "..."}]       房间菜单（Ctrl+m 打开）
+//	set-state  {"host": "...", "mode": "...", "participants": 3}  菜单标题栏显示的房间状态
+//	toast      {"text": "...", "color": "&H00FF00&", "duration_ms": 3000}  右下角提示气泡
+//
+// 脚本 -> Go：script-message movie-night ...（MPV 发出 client-message，由 Monitor.SubscribeMessages 接收）
+//
+//	<动作>                       用户在菜单中选择的动作，与按键绑定的动作相同（如 ready、react-laugh）
+//	event script-ready <版本>    脚本加载完成，Go 端此时下发菜单和状态
+//	event on-load <地址>         on_load 钩子，MPV 开始加载文件
+const (
+	ScriptName    = "movie_night" // 脚本名（script-message-to 的目标）
+	ScriptVersion = "1"
+
+	ScriptEvent       = "event"        // 脚本事件的第一个参数
+	ScriptEventReady  = "script-ready" // 脚本加载完成
+	ScriptEventOnLoad = "on-load"      // 开始加载文件
+)
+
+//go:embed scripts/movie_night.lua
+var bridgeScript []byte
+
+// MenuItem 房间菜单项
+type MenuItem struct {
+	Title  string `json:"title"`
+	Action string `json:"action"`
+}
+
+// ScriptState 菜单标题栏显示的房间状态
+type ScriptState struct {
+	Host         string `json:"host"`
+	Mode         string `json:"mode"`
+	Participants int    `json:"participants"`
+}
+
+// Toast 提示气泡
+type Toast struct {
+	Text       string `json:"text"`
+	Color      string `json:"color,omitempty"` // ASS BGR 颜色，如 &H00FF00&
+	DurationMs int    `json:"duration_ms,omitempty"`
+}
+
+// WriteBridgeScript 把内置脚本写到 dir 下新建的私有临时目录中（dir 为空时使用系统临时目录），返回文件路径（供 --script 使用）
+// 文件名必须是脚本名，所以不能用随机文件名；每个实例使用自己的目录，避免被其他用户替换或被其他实例覆盖、删除。
+// 用完后由调用方删除整个目录
+func WriteBridgeScript(dir string) (string, error) {
+	scriptDir, err := os.MkdirTemp(dir, "movie-night-script-")
+	if err != nil {
+		return "", fmt.Errorf("创建内置脚本目录失败: %w", err)
+	}
+	path := filepath.Join(scriptDir, ScriptName+".lua")
+	if err := os.WriteFile(path, bridgeScript, 0600); err != nil {
+		os.RemoveAll(scriptDir)
+		return "", fmt.Errorf("写入内置脚本失败: %w", err)
+	}
+	return path, nil
+}
+
+// scriptMessage 以 JSON 参数给内置脚本发消息
+func (c *Controller) scriptMessage(command string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal script message: %w", err)
+	}
+	return c.sendCommand("script-message-to", ScriptName, command, string(data))
+}
+
+// SetMenu 设置房间菜单
+func (c *Controller) SetMenu(items []MenuItem) error {
+	return c.scriptMessage("set-menu", items)
+}
+
+// SetScriptState 更新菜单中显示的房间状态
+func (c *Controller) SetScriptState(state ScriptState) error {
+	return c.scriptMessage("set-state", state)
+}
+
+// ShowToast 显示提示气泡（不会覆盖 show-text 的消息）
+func (c *Controller) ShowToast(toast Toast) error {
+	return c.scriptMessage("toast", toast)
+}
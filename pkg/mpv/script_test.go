@@ -0,0 +1,89 @@
+package mpv
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteBridgeScript(t *testing.T) {
+	path, err := WriteBridgeScript(t.TempDir())
+	if err != nil {
+		t.Fatalf("WriteBridgeScript failed: %v", err)
+	}
+
+	if filepath.Base(path) != ScriptName+".lua" {
+		t.Errorf("Script file name must match script name, got %s", filepath.Base(path))
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read script: %v", err)
+	}
+
+	// 每次写到新的私有目录，多个实例互不影响
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("Failed to stat script directory: %v", err)
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("Script directory should be private, got %v", info.Mode())
+	}
+	other, err := WriteBridgeScript(filepath.Dir(filepath.Dir(path)))
+	if err != nil || other == path {
+		t.Errorf("Second script should get its own directory, got %s (%v)", other, err)
+	}
+
+	// 脚本需要实现协议中的命令和事件
+	for _, want := range []string{`"set-menu"`, `"set-state"`, `"toast"`, `"` + ScriptEventReady + `"`, `"` + ScriptEventOnLoad + `"`, `VERSION = "` + ScriptVersion + `"`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Script missing %s", want)
+		}
+	}
+}
+
+func TestSetMenu(t *testing.T) {
+	tmpDir := t.TempDir()
+	socketPath := filepath.Join(tmpDir, "mpv-test-script.sock")
+	cmdChan := make(chan string, 10)
+	startMockMpvServer(t, socketPath, cmdChan)
+	time.Sleep(100 * time.Millisecond)
+
+	controller, err := NewController(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to create controller: %v", err)
+	}
+	defer controller.Close()
+
+	if err := controller.SetMenu([]MenuItem{{Title: "Ready", Action: "ready"}}); err != nil {
+		t.Fatalf("SetMenu failed: %v", err)
+	}
+
+	select {
+	case cmdJSON := <-cmdChan:
+		var payload struct {
+			Command []interface{} `json:"command"`
+		}
+		if err := json.Unmarshal([]byte(cmdJSON), &payload); err != nil {
+			t.Fatalf("Failed to parse command JSON: %v", err)
+		}
+
+		if len(payload.Command) != 4 || payload.Command[0] != "script-message-to" || payload.Command[1] != ScriptName || payload.Command[2] != "set-menu" {
+			t.Fatalf("Unexpected command: %v", payload.Command)
+		}
+
+		var items []MenuItem
+		if err := json.Unmarshal([]byte(payload.Command[3].(string)), &items); err != nil {
+			t.Fatalf("Menu payload is not JSON: %v", err)
+		}
+		if len(items) != 1 || items[0].Action != "ready" {
+			t.Errorf("Unexpected menu items: %+v", items)
+		}
+
+	case <-time.After(1 * time.Second):
+		t.Fatal("Timeout waiting for command")
+	}
+}
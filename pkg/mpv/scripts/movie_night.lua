@@ -0,0 +1,162 @@
+-- movie_night.lua: Movie Night 内置 MPV 脚本
+--
+-- 由 Go 端在启动 MPV 时通过 --script 加载，负责 show-text / osd-overlay
+-- 做不到的界面（房间菜单、提示气泡），并把用户操作发回 Go 端。
+-- 消息协议见 pkg/mpv/script.go。
+
+local mp = require 'mp'
+local utils = require 'mp.utils'
+
+local VERSION = "1"
+local TARGET = "movie-night" -- 发回 Go 端时的第一个参数
+
+local menu = { items = {}, open = false, selected = 1 }
+local state = {}
+local menu_overlay = mp.create_osd_overlay("ass-events")
+local toast_overlay = mp.create_osd_overlay("ass-events")
+local toasts = {}
+
+-- 发给 Go 端
+local function send_action(action)
+    mp.commandv("script-message", TARGET, action)
+end
+
+local function send_event(name, ...)
+    mp.commandv("script-message", TARGET, "event", name, ...)
+end
+
+local function escape(s)
+    s = tostring(s or "")
+    s = s:gsub("\\", "\\\\"):gsub("{", "\\{"):gsub("}", "\\}"):gsub("\n", " ")
+    return s
+end
+
+local function parse(json)
+    local value, err = utils.parse_json(json or "")
+    if err then
+        mp.msg.warn("invalid json from movie-night: " .. err)
+    end
+    return value
+end
+
+-- ===== 房间菜单 =====
+
+local function render_menu()
+    if not menu.open then
+        menu_overlay.data = ""
+        menu_overlay:update()
+        return
+    end
+
+    local lines = {}
+    table.insert(lines, "{\\an7\\fs30\\b1\\bord2\\3c&H000000&\\c&HFFFFFF&}Movie Night")
+    if state.host then
+        table.insert(lines, string.format("{\\fs22\\b0\\c&HC0C0C0&}Host: %s · %s · %d online",
+            escape(state.host), escape(state.mode or "?"), state.participants or 0))
+    end
+
+    for i, item in ipairs(menu.items) do
+        local color = (i == menu.selected) and "&H00FFFF&" or "&HFFFFFF&"
+        local marker = (i == menu.selected) and "▶ " or "   "
+        table.insert(lines, string.format("{\\fs26\\b0\\c%s}%s%s", color, marker, escape(item.title)))
+    end
+
+    menu_overlay.data = table.concat(lines, "\\N")
+    menu_overlay:update()
+end
+
+local function close_menu()
+    menu.open = false
+    for _, name in ipairs({ "mn-up", "mn-down", "mn-enter", "mn-esc" }) do
+        mp.remove_key_binding(name)
+    end
+    render_menu()
+end
+
+local function open_menu()
+    if #menu.items == 0 then
+        mp.osd_message("Movie Night: menu not ready")
+        return
+    end
+
+    menu.open = true
+    menu.selected = math.min(menu.selected, #menu.items)
+
+    mp.add_forced_key_binding("UP", "mn-up", function()
+        menu.selected = (menu.selected - 2) % #menu.items + 1
+        render_menu()
+    end, { repeatable = true })
+    mp.add_forced_key_binding("DOWN", "mn-down", function()
+        menu.selected = menu.selected % #menu.items + 1
+        render_menu()
+    end, { repeatable = true })
+    mp.add_forced_key_binding("ENTER", "mn-enter", function()
+        local item = menu.items[menu.selected]
+        close_menu()
+        if item then
+            send_action(item.action)
+        end
+    end)
+    mp.add_forced_key_binding("ESC", "mn-esc", close_menu)
+
+    render_menu()
+end
+
+mp.add_key_binding("Ctrl+m", "room-menu", function()
+    if menu.open then close_menu() else open_menu() end
+end)
+
+-- ===== 提示气泡（右下角堆叠，不覆盖 show-text）=====
+
+local function render_toasts()
+    local lines = {}
+    for _, t in ipairs(toasts) do
+        table.insert(lines, string.format("{\\an3\\fs26\\bord2\\3c&H000000&\\c%s}%s", t.color, escape(t.text)))
+    end
+    toast_overlay.data = table.concat(lines, "\\N")
+    toast_overlay:update()
+end
+
+local function push_toast(t)
+    table.insert(toasts, t)
+    render_toasts()
+    mp.add_timeout(t.duration, function()
+        for i, other in ipairs(toasts) do
+            if other == t then
+                table.remove(toasts, i)
+                break
+            end
+        end
+        render_toasts()
+    end)
+end
+
+-- ===== Go -> 脚本 =====
+
+mp.register_script_message("set-menu", function(json)
+    menu.items = parse(json) or {}
+    render_menu()
+end)
+
+mp.register_script_message("set-state", function(json)
+    state = parse(json) or {}
+    render_menu()
+end)
+
+mp.register_script_message("toast", function(json)
+    local t = parse(json)
+    if not t then return end
+    push_toast({
+        text = t.text or "",
+        color = t.color or "&HFFFFFF&",
+        duration = (t.duration_ms or 3000) / 1000,
+    })
+end)
+
+-- ===== 脚本 -> Go（事件）=====
+
+mp.add_hook("on_load", 50, function()
+    send_event("on-load", mp.get_property("stream-open-filename", ""))
+end)
+
+send_event("script-ready", VERSION)
@@ -0,0 +1,136 @@
+package mpv
+
+import (
+	"fmt"
+	"strings"
+)
+
+const statsOverlayID = 47 // 种子统计面板使用的 overlay
+
+const (
+	statsBarWidth = 60 // 分块图的格数
+	statsMaxPeers = 6  // 最多列出的 Peer 数
+)
+
+// PieceState 分块状态（用于分块图）
+type PieceState int
+
+const (
+	PieceMissing   PieceState = iota // 本地没有，也没有任何 Peer 拥有
+	PieceAvailable                   // 有 Peer 拥有，尚未下载
+	PieceComplete                    // 本地已下载
+)
+
+// PeerInfo 统计面板中的一个 Peer
+type PeerInfo struct {
+	Name         string  // 地址或客户端名称
+	Room         bool    // 是否为房间参与者
+	Progress     float64 // 对方的完成度 (0-1)
+	DownloadRate float64 // 从对方下载的速度（字节/秒）
+	UploadRate   float64 // 上传给对方的速度（字节/秒）
+}
+
+// TorrentStats 种子统计面板的内容
+type TorrentStats struct {
+	DownloadRate  float64 // 字节/秒
+	UploadRate    float64 // 字节/秒
+	Progress      float64 // 下载进度 (0-1)
+	BufferedAhead float64 // 播放位置之后已缓冲的秒数，小于 0 时不显示
+	Peers         []PeerInfo
+	Pieces        []PieceState // 每个分块的状态
+	Playhead      int          // 播放位置所在的分块
+}
+
+// DrawStatsOverlay 在右下角绘制种子统计面板（独立的 overlay，不会覆盖 show-text 消息）
+func (c *Controller) DrawStatsOverlay(stats TorrentStats) error {
+	return c.sendCommand("osd-overlay", statsOverlayID, "ass-events", buildStatsASS(stats))
+}
+
+// ClearStatsOverlay 清除种子统计面板
+func (c *Controller) ClearStatsOverlay() error {
+	return c.sendCommand("osd-overlay", statsOverlayID, "ass-events", "")
+}
+
+// buildStatsASS 生成统计面板：速度和进度、分块图、Peer 列表
+func buildStatsASS(stats TorrentStats) string {
+	var sb strings.Builder
+
+	sb.WriteString(`{\an3\fs22\bord2\3c&H000000&\c&HFFFFFF&}`)
+	sb.WriteString(fmt.Sprintf(`↓ %s  ↑ %s  %.1f%%`, formatRate(stats.DownloadRate), formatRate(stats.UploadRate), stats.Progress*100))
+	if stats.BufferedAhead >= 0 {
+		sb.WriteString(fmt.Sprintf(`  buffered %.0fs`, stats.BufferedAhead))
+	}
+	sb.WriteString(`{\N}`)
+
+	if len(stats.Pieces) > 0 {
+		sb.WriteString(buildPieceBar(stats.Pieces, stats.Playhead))
+		sb.WriteString(`{\N}`)
+	}
+
+	room := 0
+	for _, p := range stats.Peers {
+		if p.Room {
+			room++
+		}
+	}
+	sb.WriteString(fmt.Sprintf(`{\c&HFFFFFF&}%d peers (%d in room){\N}`, len(stats.Peers), room))
+	for i, p := range stats.Peers {
+		if i == statsMaxPeers {
+			sb.WriteString(fmt.Sprintf(`{\c&HAAAAAA&}+%d more{\N}`, len(stats.Peers)-statsMaxPeers))
+			break
+		}
+		// 房间参与者为青色
+		color := `\c&HC0C0C0&`
+		if p.Room {
+			color = `\c&HFFFF00&`
+		}
+		sb.WriteString(fmt.Sprintf(`{%s}%s  %3.0f%%  ↓ %s  ↑ %s{\N}`,
+			color, escapeASS(p.Name), p.Progress*100, formatRate(p.DownloadRate), formatRate(p.UploadRate)))
+	}
+	return sb.String()
+}
+
+// buildPieceBar 把分块状态压缩成固定宽度的条：每格取所含分块中最差的状态
+// 已下载为绿色，可下载为灰色，无来源为红色，播放位置为黄色
+func buildPieceBar(pieces []PieceState, playhead int) string {
+	width := min(statsBarWidth, len(pieces))
+	var sb strings.Builder
+	last := ""
+	for cell := 0; cell < width; cell++ {
+		begin := cell * len(pieces) / width
+		end := (cell + 1) * len(pieces) / width
+
+		state := PieceComplete
+		for _, s := range pieces[begin:end] {
+			state = min(state, s)
+		}
+
+		color := `\c&H808080&`
+		switch {
+		case playhead >= begin && playhead < end:
+			color = `\c&H00FFFF&`
+		case state == PieceComplete:
+			color = `\c&H00FF00&`
+		case state == PieceMissing:
+			color = `\c&H0000FF&`
+		}
+		if color != last {
+			sb.WriteString(`{` + color + `}`)
+			last = color
+		}
+		sb.WriteString("▮")
+	}
+	return sb.String()
+}
+
+// formatRate 格式化传输速度
+func formatRate(bytesPerSecond float64) string {
+	switch {
+	case bytesPerSecond >= 1<<20:
+		return fmt.Sprintf("%.1f MB/s", bytesPerSecond/(1<<20))
+	case bytesPerSecond >= 1<<10:
+		return fmt.Sprintf("%.0f KB/s", bytesPerSecond/(1<<10))
+	default:
+		return fmt.Sprintf("%.0f B/s", bytesPerSecond)
+	}
+}
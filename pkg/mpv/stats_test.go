@@ -0,0 +1,37 @@
+package mpv
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPieceBar(t *testing.T) {
+	// 120 个分块压缩成 60 格：前半已下载，第 61 块无来源，播放位置在第 10 块
+	pieces := make([]PieceState, 120)
+	for i := range pieces {
+		switch {
+		case i < 60:
+			pieces[i] = PieceComplete
+		case i == 61:
+			pieces[i] = PieceMissing
+		default:
+			pieces[i] = PieceAvailable
+		}
+	}
+
+	bar := buildPieceBar(pieces, 10)
+	if n := strings.Count(bar, "▮"); n != statsBarWidth {
+		t.Fatalf("got %d cells, want %d", n, statsBarWidth)
+	}
+
+	want := `{\c&H00FF00&}▮▮▮▮▮{\c&H00FFFF&}▮{\c&H00FF00&}` + strings.Repeat("▮", 24) +
+		`{\c&H0000FF&}▮{\c&H808080&}` + strings.Repeat("▮", 29)
+	if bar != want {
+		t.Errorf("got  %s\nwant %s", bar, want)
+	}
+
+	// 分块比格数少时每块一格
+	if n := strings.Count(buildPieceBar(pieces[:4], 0), "▮"); n != 4 {
+		t.Errorf("got %d cells, want 4", n)
+	}
+}
@@ -0,0 +1,79 @@
+package mpv
+
+import "fmt"
+
+// Track MPV 轨道信息（track-list 中的一项）
+type Track struct {
+	ID       int    `json:"id"`
+	Type     string `json:"type"` // video / audio / sub
+	Title    string `json:"title,omitempty"`
+	Lang     string `json:"lang,omitempty"`
+	External bool   `json:"external"`
+	Selected bool   `json:"selected"`
+}
+
+// Label 轨道的显示名称
+func (t Track) Label() string {
+	label := fmt.Sprintf("#%d", t.ID)
+	if t.Title != "" {
+		label += " " + t.Title
+	}
+	if t.Lang != "" {
+		label += fmt.Sprintf(" [%s]", t.Lang)
+	}
+	if t.External {
+		label += " (外挂)"
+	}
+	return label
+}
+
+// GetTracks 获取指定类型的轨道（kind 为空时返回全部）
+func (c *Controller) GetTracks(kind string) ([]Track, error) {
+	data, err := c.GetProperty("track-list")
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := data.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("轨道列表格式错误: %T", data)
+	}
+
+	var tracks []Track
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		track := Track{}
+		if id, ok := m["id"].(float64); ok {
+			track.ID = int(id)
+		}
+		track.Type, _ = m["type"].(string)
+		track.Title, _ = m["title"].(string)
+		track.Lang, _ = m["lang"].(string)
+		track.External, _ = m["external"].(bool)
+		track.Selected, _ = m["selected"].(bool)
+
+		if kind == "" || track.Type == kind {
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks, nil
+}
+
+// SetSubtitleTrack 选择字幕轨道
+func (c *Controller) SetSubtitleTrack(id int) error {
+	return c.sendCommand("set_property", "sid", id)
+}
+
+// DisableSubtitles 关闭字幕
+func (c *Controller) DisableSubtitles() error {
+	return c.sendCommand("set_property", "sid", "no")
+}
+
+// AddSubtitle 加载外挂字幕（本地路径或 URL）并立即选中
+func (c *Controller) AddSubtitle(path, title string) error {
+	return c.sendCommand("sub-add", path, "select", title)
+}
@@ -0,0 +1,52 @@
+package mpv
+
+import (
+	"fmt"
+	"strings"
+)
+
+const voteOverlayID = 44 // 投票面板使用的 overlay
+
+// VoteInfo 进行中的投票
+type VoteInfo struct {
+	Title     string   // 投票内容，如 "Pause"
+	Proposer  string   // 发起人
+	Voters    []string // 已同意的人
+	Needed    int      // 需要的票数
+	Remaining int      // 剩余秒数
+}
+
+// DrawVoteOverlay 在右上角绘制投票面板
+func (c *Controller) DrawVoteOverlay(vote VoteInfo) error {
+	var sb strings.Builder
+
+	// 右上角(\an9)，标题橙色 (BGR: \c&H00A5FF&)
+	sb.WriteString(fmt.Sprintf(`{\an9\fs34\b1\bord2\3c&H000000&\c&H00A5FF&}Vote: %s{\N}{\fs26\b0\c&HFFFFFF&}`, escapeASS(vote.Title)))
+	sb.WriteString(fmt.Sprintf(`by %s{\N}`, escapeASS(vote.Proposer)))
+
+	// 进度条：已投票为绿色方块，其余为灰色
+	votes := len(vote.Voters)
+	for i := 0; i < vote.Needed; i++ {
+		if i < votes {
+			sb.WriteString(`{\c&H00FF00&}■`)
+		} else {
+			sb.WriteString(`{\c&H808080&}□`)
+		}
+	}
+	sb.WriteString(fmt.Sprintf(`{\c&HFFFFFF&} %d/%d  %ds{\N}`, votes, vote.Needed, vote.Remaining))
+
+	if len(vote.Voters) > 0 {
+		names := make([]string, len(vote.Voters))
+		for i, name := range vote.Voters {
+			names[i] = escapeASS(name)
+		}
+		sb.WriteString(`{\fs22\c&HC0C0C0&}` + strings.Join(names, ", "))
+	}
+
+	return c.sendCommand("osd-overlay", voteOverlayID, "ass-events", sb.String())
+}
+
+// ClearVoteOverlay 清除投票面板
+func (c *Controller) ClearVoteOverlay() error {
+	return c.sendCommand("osd-overlay", voteOverlayID, "ass-events", "")
+}
@@ -0,0 +1,84 @@
+// Package rpc 本地控制 API：JSON-RPC 2.0（每行一个消息），通过 Unix socket 或 HTTP 提供，
+// 让 Stream Deck、脚本和家庭自动化控制房间。所有操作都按房间策略执行，和房主自己的操作走同一流程
+//
+// 方法：
+//
+//	room.state                    房间概况（model.RoomState）
+//	room.participants             在线的参与者
+//	room.pause / room.resume      暂停 / 继续
+//	room.seek     {"position": 秒} 跳转到指定位置
+//	room.skip     {"seconds": 秒}  向前（负数为向后）跳过
+//	room.chat     {"text": 内容}   发送聊天消息
+//	room.subtitle {"command": 指令} 选择字幕（同终端 sub 指令的参数，如 "2"、"file 1"、"off"）
+//	room.item     {"index": 序号}  切换播放列表中的视频（从 0 开始，只有房主可以切换）
+//	room.subscribe / room.unsubscribe  开始 / 停止接收事件通知（只在 Unix socket 上可用）
+//
+// 订阅后，服务端以 room.event 通知推送 model.RoomEvent
+package rpc
+
+import (
+	"encoding/json"
+
+	"movie-night/model"
+)
+
+// Version JSON-RPC 版本
+const Version = "2.0"
+
+// EventMethod 事件通知的方法名
+const EventMethod = "room.event"
+
+// JSON-RPC 错误码
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeRoomError      = -32000 // 房间拒绝了操作（如没有权限）
+)
+
+// Room 控制 API 可用的房间操作（一般是 *sync.Remote）
+type Room interface {
+	State() model.RoomState
+	Participants() []model.Participant
+	Control(action model.ControlAction, position float64) error
+	Say(text string) error
+	SelectSubtitle(command string) error
+	SelectItem(index int) error
+	Subscribe() <-chan model.RoomEvent
+	Unsubscribe(ch <-chan model.RoomEvent)
+}
+
+// Request 请求（没有 ID 时是通知，不回复）
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response 回复，Result 和 Error 只有一个
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Notification 服务端推送的通知
+type Notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// Error 错误
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error 实现 error
+func (e *Error) Error() string {
+	return e.Message
+}
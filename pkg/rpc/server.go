@@ -0,0 +1,307 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+)
+
+// maxHTTPBody HTTP 请求体的最大长度
+const maxHTTPBody = 64 << 10
+
+// errNoEvents HTTP 上不能订阅事件
+var errNoEvents = &Error{Code: CodeMethodNotFound, Message: "事件流只在 Unix socket 上可用"}
+
+// Server 控制 API 服务
+type Server struct {
+	room Room
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	logger   *slog.Logger
+}
+
+// NewServer 创建控制 API 服务
+func NewServer(room Room, logger *slog.Logger) *Server {
+	return &Server{
+		logger: logging.Component(logger, "RPC"),
+		room:   room,
+		conns:  make(map[net.Conn]struct{}),
+	}
+}
+
+// Listen 在 Unix socket 上提供服务（后台），会先删除上次残留的 socket 文件
+func (s *Server) Listen(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除旧的 socket 失败: %w", err)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", path, err)
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serveConn(conn)
+		}
+	}()
+	return nil
+}
+
+// Close 停止监听并断开所有连接
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Close()
+}
+
+// Handler 通过 HTTP 提供服务：POST 一个请求，回复一个结果（不支持事件订阅）
+func (s *Server) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHTTPBody)).Decode(&req); err != nil {
+			writeJSON(w, Response{JSONRPC: Version, ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			return
+		}
+
+		var resp *Response
+		if req.Method == "room.subscribe" || req.Method == "room.unsubscribe" {
+			resp = reply(req, nil, errNoEvents)
+		} else {
+			result, rpcErr := s.call(req)
+			resp = reply(req, result, rpcErr)
+		}
+		if resp == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, resp)
+	})
+}
+
+// serveConn 处理一个 socket 连接：每行一个请求，回复和事件通知也是每行一个
+func (s *Server) serveConn(conn net.Conn) {
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+
+	var writeMu sync.Mutex
+	encoder := json.NewEncoder(conn)
+	write := func(v any) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		encoder.Encode(v)
+	}
+
+	var events <-chan model.RoomEvent
+	stopEvents := make(chan struct{})
+	unsubscribe := func() {
+		if events != nil {
+			s.room.Unsubscribe(events)
+			close(stopEvents)
+			events, stopEvents = nil, make(chan struct{})
+		}
+	}
+
+	defer func() {
+		unsubscribe()
+		conn.Close()
+
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+	}()
+
+	decoder := json.NewDecoder(conn)
+	for {
+		var req Request
+		if err := decoder.Decode(&req); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				// 格式错误之后无法再找到下一个请求的开头，回复后断开
+				write(Response{JSONRPC: Version, ID: json.RawMessage("null"), Error: &Error{Code: CodeParseError, Message: err.Error()}})
+			}
+			return
+		}
+
+		var result any
+		var rpcErr *Error
+		switch req.Method {
+		case "room.subscribe":
+			if events == nil {
+				events = s.room.Subscribe()
+				go forwardEvents(events, stopEvents, write)
+			}
+		case "room.unsubscribe":
+			unsubscribe()
+		default:
+			result, rpcErr = s.call(req)
+		}
+		if resp := reply(req, result, rpcErr); resp != nil {
+			write(resp)
+		}
+	}
+}
+
+// forwardEvents 把房间事件作为通知推送给连接，直到 stop 关闭
+func forwardEvents(events <-chan model.RoomEvent, stop <-chan struct{}, write func(v any)) {
+	for {
+		select {
+		case <-stop:
+			return
+		case event := <-events:
+			write(Notification{JSONRPC: Version, Method: EventMethod, Params: event})
+		}
+	}
+}
+
+// call 执行一个方法
+func (s *Server) call(req Request) (any, *Error) {
+	if req.JSONRPC != Version || req.Method == "" {
+		return nil, &Error{Code: CodeInvalidRequest, Message: "不是有效的 JSON-RPC 2.0 请求"}
+	}
+
+	var err error
+	switch req.Method {
+	case "room.state":
+		return s.room.State(), nil
+
+	case "room.participants":
+		return s.room.Participants(), nil
+
+	case "room.pause":
+		err = s.room.Control(model.ActionPause, 0)
+
+	case "room.resume":
+		err = s.room.Control(model.ActionResume, 0)
+
+	case "room.seek":
+		var params struct {
+			Position *float64 `json:"position"`
+		}
+		if rpcErr := decodeParams(req.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		if params.Position == nil {
+			return nil, missingParam("position")
+		}
+		err = s.room.Control(model.ActionSeek, *params.Position)
+
+	case "room.skip":
+		var params struct {
+			Seconds *float64 `json:"seconds"`
+		}
+		if rpcErr := decodeParams(req.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		if params.Seconds == nil {
+			return nil, missingParam("seconds")
+		}
+		err = s.room.Control(model.ActionSkip, *params.Seconds)
+
+	case "room.chat":
+		var params struct {
+			Text string `json:"text"`
+		}
+		if rpcErr := decodeParams(req.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		err = s.room.Say(params.Text)
+
+	case "room.subtitle":
+		var params struct {
+			Command string `json:"command"`
+		}
+		if rpcErr := decodeParams(req.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		err = s.room.SelectSubtitle(params.Command)
+
+	case "room.item":
+		var params struct {
+			Index *int `json:"index"`
+		}
+		if rpcErr := decodeParams(req.Params, &params); rpcErr != nil {
+			return nil, rpcErr
+		}
+		if params.Index == nil {
+			return nil, missingParam("index")
+		}
+		err = s.room.SelectItem(*params.Index)
+
+	default:
+		return nil, &Error{Code: CodeMethodNotFound, Message: fmt.Sprintf("未知的方法: %s", req.Method)}
+	}
+
+	if err != nil {
+		s.logger.Debug("操作被拒绝", "method", req.Method, "err", err)
+		return nil, &Error{Code: CodeRoomError, Message: err.Error()}
+	}
+	return nil, nil
+}
+
+// decodeParams 解析按名称传递的参数
+func decodeParams(raw json.RawMessage, v any) *Error {
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("参数格式错误: %v", err)}
+	}
+	return nil
+}
+
+// missingParam 缺少必需参数的错误
+func missingParam(name string) *Error {
+	return &Error{Code: CodeInvalidParams, Message: fmt.Sprintf("缺少参数: %s", name)}
+}
+
+// reply 生成回复，通知（没有 ID）不回复
+func reply(req Request, result any, rpcErr *Error) *Response {
+	if len(req.ID) == 0 {
+		return nil
+	}
+	resp := &Response{JSONRPC: Version, ID: req.ID}
+	if rpcErr != nil {
+		resp.Error = rpcErr
+		return resp
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &Error{Code: CodeRoomError, Message: err.Error()}
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+// writeJSON 回复 JSON
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
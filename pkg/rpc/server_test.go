@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"movie-night/model"
+)
+
+// fakeRoom 记录收到的操作，事件由测试手动推送
+type fakeRoom struct {
+	mu       sync.Mutex
+	action   model.ControlAction
+	position float64
+	events   chan model.RoomEvent
+}
+
+func (f *fakeRoom) State() model.RoomState { return model.RoomState{Self: "me"} }
+
+func (f *fakeRoom) Participants() []model.Participant {
+	return []model.Participant{{ID: "me", Name: "Alice"}}
+}
+
+func (f *fakeRoom) Control(action model.ControlAction, position float64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.action, f.position = action, position
+	return nil
+}
+
+func (f *fakeRoom) Say(text string) error { return errors.New("没有权限") }
+
+func (f *fakeRoom) SelectSubtitle(command string) error { return nil }
+
+func (f *fakeRoom) SelectItem(index int) error { return nil }
+
+func (f *fakeRoom) Subscribe() <-chan model.RoomEvent { return f.events }
+
+func (f *fakeRoom) Unsubscribe(ch <-chan model.RoomEvent) {}
+
+func TestServerSocket(t *testing.T) {
+	room := &fakeRoom{events: make(chan model.RoomEvent, 1)}
+	server := NewServer(room, nil)
+	path := filepath.Join(t.TempDir(), "rpc.sock")
+	if err := server.Listen(path); err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	lines := bufio.NewScanner(conn)
+
+	call := func(request string) map[string]json.RawMessage {
+		t.Helper()
+		if _, err := conn.Write([]byte(request + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if !lines.Scan() {
+			t.Fatalf("没有收到回复: %v", lines.Err())
+		}
+		var resp map[string]json.RawMessage
+		if err := json.Unmarshal(lines.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := call(`{"jsonrpc":"2.0","id":1,"method":"room.seek","params":{"position":90}}`)
+	if string(resp["id"]) != "1" || string(resp["result"]) != "null" || resp["error"] != nil {
+		t.Errorf("room.seek 回复: %v", resp)
+	}
+	room.mu.Lock()
+	if room.action != model.ActionSeek || room.position != 90 {
+		t.Errorf("收到 %s %.0f，期望 seek 90", room.action, room.position)
+	}
+	room.mu.Unlock()
+
+	resp = call(`{"jsonrpc":"2.0","id":"p","method":"room.participants"}`)
+	var participants []model.Participant
+	if err := json.Unmarshal(resp["result"], &participants); err != nil || len(participants) != 1 || participants[0].Name != "Alice" {
+		t.Errorf("room.participants 回复: %s", resp["result"])
+	}
+
+	for request, code := range map[string]int{
+		`{"jsonrpc":"2.0","id":2,"method":"room.dance"}`:                       CodeMethodNotFound,
+		`{"jsonrpc":"2.0","id":3,"method":"room.seek","params":{}}`:            CodeInvalidParams,
+		`{"jsonrpc":"2.0","id":4,"method":"room.chat","params":{"text":"hi"}}`: CodeRoomError,
+		`{"id":5,"method":"room.pause"}`:                                       CodeInvalidRequest,
+	} {
+		var rpcErr Error
+		resp := call(request)
+		if err := json.Unmarshal(resp["error"], &rpcErr); err != nil || rpcErr.Code != code {
+			t.Errorf("%s: 错误 %s，期望错误码 %d", request, resp["error"], code)
+		}
+	}
+
+	// 订阅后收到事件通知
+	call(`{"jsonrpc":"2.0","id":6,"method":"room.subscribe"}`)
+	room.events <- model.RoomEvent{Kind: model.RoomEventChat, Chat: &model.ChatMessage{Name: "Bob", Text: "hello"}}
+	if !lines.Scan() {
+		t.Fatalf("没有收到事件: %v", lines.Err())
+	}
+	var note struct {
+		Method string          `json:"method"`
+		Params model.RoomEvent `json:"params"`
+	}
+	if err := json.Unmarshal(lines.Bytes(), &note); err != nil {
+		t.Fatal(err)
+	}
+	if note.Method != EventMethod || note.Params.Kind != model.RoomEventChat || note.Params.Chat.Text != "hello" {
+		t.Errorf("事件通知: %s", lines.Bytes())
+	}
+}
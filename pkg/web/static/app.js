@@ -0,0 +1,117 @@
+// 浏览器参与者：按服务端的指令播放 /stream，并定期上报播放状态
+// 是否跳转由服务端决定（与 MPV 跟随端相同的 sync.Syncer），这里只执行 seek / rate 等指令
+"use strict";
+
+const video = document.getElementById("video");
+const overlay = document.getElementById("overlay");
+const toast = document.getElementById("toast");
+const unmute = document.getElementById("unmute");
+
+const statusInterval = 1000; // 上报播放状态的间隔（毫秒）
+const reconnectDelay = 3000; // 断线后重连的间隔（毫秒）
+
+let socket = null;
+let baseRate = 1; // 房间的播放速度（换片后重新设置）
+let toastTimer = null;
+let wantPlaying = false; // 房间在播放（浏览器阻止自动播放时提示点击）
+
+function send(msg) {
+  if (socket && socket.readyState === WebSocket.OPEN) {
+    socket.send(JSON.stringify(msg));
+  }
+}
+
+function sendStatus() {
+  send({
+    kind: "status",
+    position: video.currentTime,
+    paused: video.paused,
+    duration: Number.isFinite(video.duration) ? video.duration : 0,
+  });
+}
+
+function play() {
+  wantPlaying = true;
+  video.play().then(() => {
+    unmute.hidden = true;
+  }).catch(() => {
+    // 浏览器要求用户先和页面交互
+    unmute.hidden = false;
+  });
+}
+
+function showToast(text, duration) {
+  toast.textContent = text;
+  toast.hidden = false;
+  clearTimeout(toastTimer);
+  toastTimer = setTimeout(() => { toast.hidden = true; }, duration || 2000);
+}
+
+function handle(msg) {
+  switch (msg.kind) {
+    case "load":
+      video.src = msg.src;
+      video.playbackRate = baseRate;
+      break;
+    case "seek":
+      video.currentTime = msg.position || 0;
+      break;
+    case "pause":
+      wantPlaying = false;
+      video.pause();
+      unmute.hidden = true;
+      break;
+    case "play":
+      if (video.paused) {
+        play();
+      }
+      break;
+    case "rate":
+      baseRate = msg.rate || 1;
+      video.playbackRate = baseRate;
+      break;
+    case "text":
+      showToast(msg.text, msg.duration);
+      break;
+    case "overlay":
+      overlay.textContent = msg.text || "";
+      overlay.hidden = !msg.text;
+      break;
+  }
+}
+
+function connect(name) {
+  const scheme = location.protocol === "https:" ? "wss:" : "ws:";
+  const token = new URLSearchParams(location.search).get("token") || "";
+  socket = new WebSocket(`${scheme}//${location.host}/ws?name=${encodeURIComponent(name)}&token=${encodeURIComponent(token)}`);
+  socket.onmessage = (event) => handle(JSON.parse(event.data));
+  socket.onclose = () => {
+    showToast("⚠️ 与房间的连接已断开，正在重连...", reconnectDelay);
+    setTimeout(() => connect(name), reconnectDelay);
+  };
+}
+
+["play", "pause", "seeked", "loadedmetadata", "ratechange"].forEach((type) => {
+  video.addEventListener(type, sendStatus);
+});
+setInterval(sendStatus, statusInterval);
+
+unmute.addEventListener("click", () => {
+  if (wantPlaying) {
+    play();
+  }
+});
+
+const nameInput = document.getElementById("name");
+nameInput.value = localStorage.getItem("movie-night-name") || "";
+document.getElementById("join").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const name = nameInput.value.trim();
+  if (!name) {
+    return;
+  }
+  localStorage.setItem("movie-night-name", name);
+  document.getElementById("join").hidden = true;
+  document.getElementById("player").hidden = false;
+  connect(name);
+});
@@ -0,0 +1,152 @@
+// 手机遥控页面：定期拉取房间概况（/api/state），把操作发给遥控接口
+// 会话令牌来自页面地址的 ?token=，之后的请求都带在 X-Remote-Token 头里
+"use strict";
+
+const token = new URLSearchParams(location.search).get("token") || "";
+const refreshInterval = 2000; // 拉取房间概况的间隔（毫秒）
+
+const $ = (id) => document.getElementById(id);
+const scrubber = $("scrubber");
+const items = $("items");
+const subtitles = $("subtitles");
+
+let state = null;
+let dragging = false; // 拖动进度条期间不刷新它的位置
+
+async function api(path, body) {
+  const options = { headers: { "X-Remote-Token": token } };
+  if (body !== undefined) {
+    options.method = "POST";
+    options.headers["Content-Type"] = "application/json";
+    options.body = JSON.stringify(body);
+  }
+  const resp = await fetch(path, options);
+  if (!resp.ok) {
+    throw new Error((await resp.text()).trim() || resp.statusText);
+  }
+  return resp.status === 204 ? null : resp.json();
+}
+
+function showError(err) {
+  $("error").textContent = err ? `⚠️ ${err.message}` : "";
+  $("error").hidden = !err;
+}
+
+function act(path, body) {
+  api(path, body).then(() => {
+    showError(null);
+    refresh();
+  }).catch(showError);
+}
+
+function formatClock(seconds) {
+  const total = Math.max(0, Math.floor(seconds));
+  const h = Math.floor(total / 3600);
+  const m = Math.floor((total % 3600) / 60);
+  const s = String(total % 60).padStart(2, "0");
+  return h > 0 ? `${h}:${String(m).padStart(2, "0")}:${s}` : `${m}:${s}`;
+}
+
+function formatRate(bytes) {
+  if (bytes >= 1 << 20) return `${(bytes / (1 << 20)).toFixed(1)} MB/s`;
+  if (bytes >= 1 << 10) return `${(bytes / (1 << 10)).toFixed(0)} KB/s`;
+  return `${bytes.toFixed(0)} B/s`;
+}
+
+function fillList(list, entries) {
+  list.replaceChildren(...entries.map((text) => {
+    const li = document.createElement("li");
+    li.textContent = text;
+    return li;
+  }));
+}
+
+function fillSelect(select, options) {
+  // 用户正在选择时不重建
+  if (document.activeElement === select) return;
+  select.replaceChildren(...options.map(({ value, label, selected }) => {
+    const option = document.createElement("option");
+    option.value = value;
+    option.textContent = label;
+    option.selected = selected;
+    return option;
+  }));
+}
+
+function render() {
+  const { status, duration } = state;
+  const host = state.host.name ? `房主: ${state.host.name}` : "暂无房主";
+  $("host").textContent = state.is_host ? `${host}（你）` : host;
+
+  $("item").textContent = state.items[status.item || 0] || "";
+  scrubber.max = Math.floor(duration);
+  if (!dragging) {
+    scrubber.value = Math.floor(status.timestamp);
+    $("position").textContent = formatClock(status.timestamp);
+  }
+  $("duration").textContent = formatClock(duration);
+  $("toggle").textContent = status.paused ? "▶" : "⏸";
+
+  const b = state.buffer;
+  $("buffer").textContent = b
+    ? `↓ ${formatRate(b.download_rate)} · ${(b.progress * 100).toFixed(1)}%` +
+      (b.buffered_ahead >= 0 ? ` · 已缓冲 ${b.buffered_ahead.toFixed(0)}s` : "") +
+      ` · ${b.peers} peers`
+    : "";
+
+  items.disabled = !state.is_host;
+  fillSelect(items, state.items.map((name, i) => ({ value: i, label: name, selected: i === (status.item || 0) })));
+
+  $("subtitle").textContent = `房间字幕: ${state.subtitle}`;
+  fillSelect(subtitles, state.subtitles.map((s) => ({ value: s.command, label: s.label, selected: s.selected })));
+
+  fillList($("participants"), state.participants.map((p) => {
+    let line = `${p.ready ? "✅" : "⏳"} ${p.name}`;
+    if (p.id === state.host.id) line += "（房主）";
+    if (p.browser) line += " 🌐";
+    if (p.drift !== undefined) line += `  ${p.drift >= 0 ? "+" : ""}${p.drift.toFixed(1)}s`;
+    return line;
+  }));
+
+  const chat = $("chat");
+  const atBottom = chat.scrollTop + chat.clientHeight >= chat.scrollHeight - 4;
+  fillList(chat, state.chat.map((m) => `${m.name}: ${m.text}`));
+  if (atBottom) chat.scrollTop = chat.scrollHeight;
+}
+
+function refresh() {
+  api("/api/state").then((s) => {
+    state = s;
+    showError(null);
+    render();
+  }).catch(showError);
+}
+
+$("toggle").addEventListener("click", () => {
+  if (state) act("/api/control", { action: state.status.paused ? "resume" : "pause" });
+});
+$("back").addEventListener("click", () => act("/api/control", { action: "skip", position: -10 }));
+$("forward").addEventListener("click", () => act("/api/control", { action: "skip", position: 30 }));
+
+scrubber.addEventListener("input", () => {
+  dragging = true;
+  $("position").textContent = formatClock(Number(scrubber.value));
+});
+scrubber.addEventListener("change", () => {
+  dragging = false;
+  act("/api/control", { action: "seek", position: Number(scrubber.value) });
+});
+
+items.addEventListener("change", () => act("/api/item", { index: Number(items.value) }));
+subtitles.addEventListener("change", () => act("/api/subtitle", { command: subtitles.value }));
+
+$("say").addEventListener("submit", (e) => {
+  e.preventDefault();
+  const text = $("text").value.trim();
+  if (!text) return;
+  act("/api/chat", { text });
+  $("text").value = "";
+});
+
+refresh();
+setInterval(refresh, refreshInterval);
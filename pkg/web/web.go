@@ -0,0 +1,174 @@
+// Package web 浏览器端页面：没有 MPV 的朋友用浏览器观看并跟随房间，以及手机遥控页面
+package web
+
+import (
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"strings"
+
+	"movie-night/model"
+
+	"github.com/gorilla/websocket"
+)
+
+//go:embed static
+var static embed.FS
+
+// maxNameLength 浏览器参与者名称的最大长度
+const maxNameLength = 32
+
+// maxRequestBody 遥控接口请求体的最大长度
+const maxRequestBody = 4 << 10
+
+// TokenHeader 遥控接口携带会话令牌的请求头（也可以用 ?token= 参数）
+const TokenHeader = "X-Remote-Token"
+
+// JoinFunc 处理一个浏览器参与者的 WebSocket 连接，阻塞到连接断开
+type JoinFunc func(conn *websocket.Conn, name string)
+
+// Remote 手机遥控页面可用的操作（一般是 *sync.Remote）
+type Remote interface {
+	State() model.RoomState
+	Control(action model.ControlAction, position float64) error
+	Say(text string) error
+	SelectSubtitle(command string) error
+	SelectItem(index int) error
+}
+
+// Config 页面配置
+type Config struct {
+	Join      JoinFunc     // 浏览器参与者加入（为空时不提供 /ws）
+	JoinToken string       // 浏览器参与者加入的令牌（只能观看，不能遥控；为空时不提供 /ws）
+	Remote    Remote       // 遥控后端（为空时不提供遥控页面）
+	RPC       http.Handler // 控制 API（挂在 /rpc，和遥控页面使用同一令牌）
+	Token     string       // 遥控页面的会话令牌
+}
+
+// Handler 浏览器端页面（/）、静态文件（/static/）、需要加入令牌的参与者 WebSocket（/ws?name=...&token=...），
+// 以及需要会话令牌的遥控页面（/remote?token=...）、接口（/api/...）和控制 API（/rpc）
+func Handler(cfg Config) http.Handler {
+	files, _ := fs.Sub(static, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServerFS(files)))
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, files, "index.html")
+	})
+	if cfg.Join != nil && cfg.JoinToken != "" {
+		mux.HandleFunc("/ws", requireToken(cfg.JoinToken, joinHandler(cfg.Join)))
+	}
+	if cfg.Remote != nil && cfg.Token != "" {
+		registerRemote(mux, files, cfg.Remote, cfg.Token)
+	}
+	if cfg.RPC != nil && cfg.Token != "" {
+		mux.Handle("POST /rpc", requireToken(cfg.Token, cfg.RPC.ServeHTTP))
+	}
+	return mux
+}
+
+// joinHandler 把浏览器的连接升级为 WebSocket 后交给 join
+func joinHandler(join JoinFunc) http.HandlerFunc {
+	upgrader := websocket.Upgrader{}
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimSpace(r.URL.Query().Get("name"))
+		if name == "" {
+			http.Error(w, "缺少名称", http.StatusBadRequest)
+			return
+		}
+		if runes := []rune(name); len(runes) > maxNameLength {
+			name = string(runes[:maxNameLength])
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// Upgrade 已经回复了错误
+			return
+		}
+		defer conn.Close()
+		join(conn, name)
+	}
+}
+
+// registerRemote 注册遥控页面和接口
+func registerRemote(mux *http.ServeMux, files fs.FS, remote Remote, token string) {
+	auth := func(handler http.HandlerFunc) http.HandlerFunc {
+		return requireToken(token, handler)
+	}
+
+	mux.HandleFunc("GET /remote", auth(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFileFS(w, r, files, "remote.html")
+	}))
+	mux.HandleFunc("GET /api/state", auth(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(remote.State())
+	}))
+	mux.HandleFunc("POST /api/control", auth(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Action   model.ControlAction `json:"action"`
+			Position float64             `json:"position"`
+		}
+		if decodeRequest(w, r, &req) {
+			reply(w, remote.Control(req.Action, req.Position))
+		}
+	}))
+	mux.HandleFunc("POST /api/chat", auth(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Text string `json:"text"`
+		}
+		if decodeRequest(w, r, &req) {
+			reply(w, remote.Say(req.Text))
+		}
+	}))
+	mux.HandleFunc("POST /api/subtitle", auth(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Command string `json:"command"`
+		}
+		if decodeRequest(w, r, &req) {
+			reply(w, remote.SelectSubtitle(req.Command))
+		}
+	}))
+	mux.HandleFunc("POST /api/item", auth(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Index int `json:"index"`
+		}
+		if decodeRequest(w, r, &req) {
+			reply(w, remote.SelectItem(req.Index))
+		}
+	}))
+}
+
+// requireToken 检查请求携带的会话令牌（请求头或 ?token= 参数），不对时回复 401
+func requireToken(token string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		given := r.Header.Get(TokenHeader)
+		if given == "" {
+			given = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "令牌无效", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// decodeRequest 解析 JSON 请求体，失败时回复 400 并返回 false
+func decodeRequest(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBody)).Decode(v); err != nil {
+		http.Error(w, "请求格式错误", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// reply 回复操作结果：成功为 204，失败为 409 和错误信息
+func reply(w http.ResponseWriter, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,120 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"movie-night/model"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeRemote 记录收到的操作
+type fakeRemote struct {
+	action   model.ControlAction
+	position float64
+	said     string
+}
+
+func (f *fakeRemote) State() model.RoomState {
+	return model.RoomState{Self: "me", IsHost: true}
+}
+
+func (f *fakeRemote) Control(action model.ControlAction, position float64) error {
+	f.action, f.position = action, position
+	return nil
+}
+
+func (f *fakeRemote) Say(text string) error {
+	if text == "" {
+		return errors.New("消息不能为空")
+	}
+	f.said = text
+	return nil
+}
+
+func (f *fakeRemote) SelectSubtitle(command string) error { return nil }
+
+func (f *fakeRemote) SelectItem(index int) error { return nil }
+
+func TestRemoteAPI(t *testing.T) {
+	remote := &fakeRemote{}
+	handler := Handler(Config{Remote: remote, Token: "secret"})
+
+	do := func(method, target, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set(TokenHeader, token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, token := range []string{"", "wrong"} {
+		if rec := do("GET", "/api/state", token, ""); rec.Code != http.StatusUnauthorized {
+			t.Errorf("令牌 %q: 状态码 %d，期望 401", token, rec.Code)
+		}
+	}
+
+	rec := do("GET", "/api/state?token=secret", "", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"self":"me"`) {
+		t.Fatalf("获取状态失败: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("GET", "/remote?token=secret", "", ""); rec.Code != http.StatusOK {
+		t.Errorf("遥控页面: 状态码 %d", rec.Code)
+	}
+
+	rec = do("POST", "/api/control", "secret", `{"action":"seek","position":42}`)
+	if rec.Code != http.StatusNoContent || remote.action != model.ActionSeek || remote.position != 42 {
+		t.Errorf("控制请求: 状态码 %d，收到 %s %.0f", rec.Code, remote.action, remote.position)
+	}
+
+	if rec := do("POST", "/api/chat", "secret", `{"text":""}`); rec.Code != http.StatusConflict {
+		t.Errorf("空消息: 状态码 %d，期望 409", rec.Code)
+	}
+	if rec := do("POST", "/api/chat", "secret", `not json`); rec.Code != http.StatusBadRequest {
+		t.Errorf("格式错误: 状态码 %d，期望 400", rec.Code)
+	}
+	if rec := do("GET", "/api/chat", "secret", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /api/chat: 状态码 %d，期望 405", rec.Code)
+	}
+}
+
+func TestJoinRequiresToken(t *testing.T) {
+	joined := make(chan string, 1)
+	server := httptest.NewServer(Handler(Config{
+		Join:      func(conn *websocket.Conn, name string) { joined <- name },
+		JoinToken: "watch",
+		Remote:    &fakeRemote{},
+		Token:     "secret",
+	}))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?name=Carol"
+
+	// 没有令牌或使用遥控令牌都不能加入
+	for _, token := range []string{"", "secret"} {
+		_, resp, err := websocket.DefaultDialer.Dial(url+"&token="+token, nil)
+		if err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("令牌 %q: 期望 401，得到 %v", token, err)
+		}
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"&token=watch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	select {
+	case name := <-joined:
+		if name != "Carol" {
+			t.Errorf("加入的名称 %q", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("持有加入令牌的浏览器没有加入")
+	}
+}
@@ -0,0 +1,252 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+)
+
+// errBrowserUnsupported 浏览器播放器不支持的操作
+var errBrowserUnsupported = errors.New("浏览器不支持该操作")
+
+// BrowserConn 与浏览器的连接（一般是 *websocket.Conn），消息为 JSON 格式的 BrowserMessage
+type BrowserConn interface {
+	ReadJSON(v interface{}) error
+	WriteJSON(v interface{}) error
+	Close() error
+}
+
+// BrowserParticipant 通过浏览器加入的参与者：在本程序里作为跟随端运行，
+// 用 HTML5 <video> 代替 MPV 播放，并像其他跟随端一样出现在在线状态中
+// 何时同步、是否跳转由 Syncer 决定，规则与 MPV 跟随端相同（见 needsSeek）
+type BrowserParticipant struct {
+	conn     BrowserConn
+	sources  []string // 播放列表各项的地址（浏览器中的相对地址）
+	presence *Presence
+	follower *Follower
+	drift    *DriftMeter
+
+	writeMu gosync.Mutex // 串行化写入（WebSocket 不支持并发写）
+
+	mu       gosync.Mutex
+	local    model.PlayStatus // 浏览器最近上报的状态
+	localAt  time.Time
+	duration float64 // 当前项的时长（浏览器加载元数据之后才知道）
+	item     int
+	speed    float64 // 房间的播放速度
+	logger   *slog.Logger
+}
+
+// NewBrowserParticipant 创建浏览器参与者
+// mqttClient 应是该参与者独占的连接（遗嘱用 LeaveMessage(id, name)），sources 为播放列表各项的地址
+func NewBrowserParticipant(conn BrowserConn, mqttClient *MQTTClient, id, name string, sources []string, maxDuration float64, logger *slog.Logger) *BrowserParticipant {
+	b := &BrowserParticipant{
+		logger:   logging.Component(logger, "Browser").With("name", name),
+		conn:     conn,
+		sources:  sources,
+		presence: NewPresence(mqttClient, id, name, model.RoleFollower, logger),
+		drift:    &DriftMeter{},
+		speed:    1,
+	}
+	b.presence.self.Browser = true
+	b.follower = NewFollower(b, mqttClient, maxDuration, logger)
+	b.follower.SetDriftMeter(b.drift, b.presence.SetDrift)
+	return b
+}
+
+// Serve 加入房间并处理浏览器上报的状态，直到连接断开（阻塞），返回后已离开房间
+func (b *BrowserParticipant) Serve() error {
+	if len(b.sources) == 0 {
+		return fmt.Errorf("没有可播放的视频")
+	}
+	if err := b.send(model.BrowserMessage{Kind: model.BrowserLoad, Src: b.sources[0]}); err != nil {
+		return err
+	}
+
+	if err := b.presence.Start(); err != nil {
+		return fmt.Errorf("在线状态不可用: %w", err)
+	}
+	defer b.presence.Leave()
+
+	if err := b.follower.Start(); err != nil {
+		return err
+	}
+	defer b.follower.Stop()
+
+	b.logger.Info("浏览器已加入")
+	for {
+		var msg model.BrowserMessage
+		if err := b.conn.ReadJSON(&msg); err != nil {
+			b.logger.Info("浏览器已断开", "err", err)
+			return nil
+		}
+		if msg.Kind == model.BrowserStatus {
+			b.handleStatus(msg)
+		}
+	}
+}
+
+// handleStatus 记录浏览器的播放状态
+func (b *BrowserParticipant) handleStatus(msg model.BrowserMessage) {
+	b.mu.Lock()
+	b.local = model.PlayStatus{Timestamp: msg.Position, Paused: msg.Paused, Speed: b.speed, Item: b.item}
+	b.localAt = time.Now()
+	if msg.Duration > 0 {
+		b.duration = msg.Duration
+	}
+	local := b.local
+	b.mu.Unlock()
+
+	b.drift.Observe(local)
+}
+
+// send 发送消息给浏览器
+func (b *BrowserParticipant) send(msg model.BrowserMessage) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	return b.conn.WriteJSON(msg)
+}
+
+// position 按经过的时间推算浏览器当前的播放位置，同时返回是否暂停、是否上报过状态
+func (b *BrowserParticipant) position() (float64, bool, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.localAt.IsZero() {
+		return 0, false, false
+	}
+	position := b.local.Timestamp
+	if !b.local.Paused {
+		position += time.Since(b.localAt).Seconds() * b.local.Rate()
+	}
+	return position, b.local.Paused, true
+}
+
+// === Player ===
+
+// Seek 跳转，相对跳转从推算的当前位置开始
+func (b *BrowserParticipant) Seek(seconds float64, mode string) error {
+	if mode != "absolute" {
+		position, _, _ := b.position()
+		seconds += position
+	}
+	return b.send(model.BrowserMessage{Kind: model.BrowserSeek, Position: seconds})
+}
+
+// Pause 暂停
+func (b *BrowserParticipant) Pause() error {
+	return b.send(model.BrowserMessage{Kind: model.BrowserPause})
+}
+
+// Play 播放
+func (b *BrowserParticipant) Play() error {
+	return b.send(model.BrowserMessage{Kind: model.BrowserPlay})
+}
+
+// ShowText 在页面上显示提示
+func (b *BrowserParticipant) ShowText(text string, duration int) error {
+	return b.send(model.BrowserMessage{Kind: model.BrowserText, Text: text, Duration: float64(duration)})
+}
+
+// GetProperty 只支持 playlist-pos，以及按上报状态推算的 time-pos 和 pause
+func (b *BrowserParticipant) GetProperty(name string) (interface{}, error) {
+	switch name {
+	case "playlist-pos":
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return float64(b.item), nil
+	case "time-pos", "pause":
+		position, paused, ok := b.position()
+		if !ok {
+			return nil, fmt.Errorf("浏览器还没有上报播放状态")
+		}
+		if name == "pause" {
+			return paused, nil
+		}
+		return position, nil
+	}
+	return nil, fmt.Errorf("%w: 读取 %s", errBrowserUnsupported, name)
+}
+
+// SetProperty 浏览器没有 MPV 属性（字幕轨道等），忽略
+func (b *BrowserParticipant) SetProperty(name string, value interface{}) error {
+	return nil
+}
+
+// SetSpeed 设置房间的播放速度
+func (b *BrowserParticipant) SetSpeed(speed float64) error {
+	b.mu.Lock()
+	b.speed = speed
+	b.mu.Unlock()
+	return b.send(model.BrowserMessage{Kind: model.BrowserRate, Rate: speed})
+}
+
+// SetAudioTrack 浏览器无法切换音轨，忽略
+func (b *BrowserParticipant) SetAudioTrack(id string) error {
+	return nil
+}
+
+// GetDuration 浏览器上报的时长
+func (b *BrowserParticipant) GetDuration() (float64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.duration <= 0 {
+		return 0, fmt.Errorf("浏览器尚未加载视频")
+	}
+	return b.duration, nil
+}
+
+// GetChapters 浏览器拿不到章节信息
+func (b *BrowserParticipant) GetChapters() ([]model.Chapter, error) {
+	return nil, nil
+}
+
+// SetChapter 不支持（位置仍会由下一次状态同步）
+func (b *BrowserParticipant) SetChapter(index int) error {
+	return fmt.Errorf("%w: 章节跳转", errBrowserUnsupported)
+}
+
+// GetPlaylistCount 播放列表长度
+func (b *BrowserParticipant) GetPlaylistCount() (int, error) {
+	return len(b.sources), nil
+}
+
+// SetPlaylistPos 播放播放列表中的指定项
+func (b *BrowserParticipant) SetPlaylistPos(index int) error {
+	if index < 0 || index >= len(b.sources) {
+		return fmt.Errorf("播放列表没有第 %d 项", index+1)
+	}
+
+	b.mu.Lock()
+	b.item = index
+	b.duration = 0
+	b.mu.Unlock()
+	return b.send(model.BrowserMessage{Kind: model.BrowserLoad, Src: b.sources[index]})
+}
+
+// PlaylistNext 播放下一项
+func (b *BrowserParticipant) PlaylistNext() error {
+	b.mu.Lock()
+	item := b.item
+	b.mu.Unlock()
+	return b.SetPlaylistPos(item + 1)
+}
+
+// DrawIntermissionOverlay 显示幕间倒计时
+func (b *BrowserParticipant) DrawIntermissionOverlay(title string, remaining int) error {
+	text := title
+	if remaining > 0 {
+		text += fmt.Sprintf("\nNext in %ds", remaining)
+	}
+	return b.send(model.BrowserMessage{Kind: model.BrowserOverlay, Text: text})
+}
+
+// ClearIntermissionOverlay 隐藏幕间倒计时
+func (b *BrowserParticipant) ClearIntermissionOverlay() error {
+	return b.send(model.BrowserMessage{Kind: model.BrowserOverlay})
+}
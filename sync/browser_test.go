@@ -0,0 +1,189 @@
+package sync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"movie-night/model"
+)
+
+// fakeBrowser 模拟浏览器端的 WebSocket 连接
+type fakeBrowser struct {
+	in     chan model.BrowserMessage // 浏览器 -> 本程序
+	out    chan model.BrowserMessage // 本程序 -> 浏览器
+	closed chan struct{}
+}
+
+func newFakeBrowser() *fakeBrowser {
+	return &fakeBrowser{
+		in:     make(chan model.BrowserMessage, 8),
+		out:    make(chan model.BrowserMessage, 32),
+		closed: make(chan struct{}),
+	}
+}
+
+func (f *fakeBrowser) ReadJSON(v interface{}) error {
+	select {
+	case msg := <-f.in:
+		*v.(*model.BrowserMessage) = msg
+		return nil
+	case <-f.closed:
+		return errors.New("closed")
+	}
+}
+
+func (f *fakeBrowser) WriteJSON(v interface{}) error {
+	f.out <- v.(model.BrowserMessage)
+	return nil
+}
+
+func (f *fakeBrowser) Close() error {
+	close(f.closed)
+	return nil
+}
+
+// expect 等待下一条发给浏览器的消息
+func (f *fakeBrowser) expect(t *testing.T, kind model.BrowserMessageKind) model.BrowserMessage {
+	t.Helper()
+	select {
+	case msg := <-f.out:
+		if msg.Kind != kind {
+			t.Fatalf("got %+v, want %s", msg, kind)
+		}
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatalf("browser never got %s", kind)
+	}
+	return model.BrowserMessage{}
+}
+
+func TestBrowserParticipant(t *testing.T) {
+	broker := NewMemoryBroker()
+	host := NewMQTTClientWithTransport(broker.Connect(), "video/control", nil)
+	watcher := NewPresence(NewMQTTClientWithTransport(broker.Connect(), "video/control", nil), "host", "Host", model.RoleHost, nil)
+	if err := watcher.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Leave()
+
+	browser := newFakeBrowser()
+	client := NewMQTTClientWithTransport(broker.Connect(), "video/control", nil)
+	participant := NewBrowserParticipant(browser, client, "web-1", "Carol", []string{"/stream"}, 600, nil)
+	done := make(chan error, 1)
+	go func() { done <- participant.Serve() }()
+
+	if msg := browser.expect(t, model.BrowserLoad); msg.Src != "/stream" {
+		t.Errorf("loaded %q", msg.Src)
+	}
+	browser.in <- model.BrowserMessage{Kind: model.BrowserStatus, Position: 100, Duration: 600}
+	time.Sleep(50 * time.Millisecond)
+
+	// 偏差很小：不跳转
+	host.publishStatus(model.PlayStatus{Timestamp: 100.1})
+	browser.expect(t, model.BrowserPlay)
+
+	// 偏差超出容差：直接跳转
+	host.publishStatus(model.PlayStatus{Timestamp: 300})
+	if msg := browser.expect(t, model.BrowserSeek); msg.Position != 300 {
+		t.Errorf("sought to %v", msg.Position)
+	}
+
+	// 像其他跟随端一样出现在在线状态中，但不参与房主选举
+	var carol model.Participant
+	for _, p := range watcher.Participants() {
+		if p.ID == "web-1" {
+			carol = p
+		}
+	}
+	if carol.Name != "Carol" || carol.Role != model.RoleFollower || !carol.Browser {
+		t.Errorf("unexpected participant %+v", carol)
+	}
+	if elected, ok := ElectHost([]model.Participant{carol}, "host"); ok {
+		t.Errorf("browser participant elected: %+v", elected)
+	}
+
+	browser.Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Serve did not return after the browser disconnected")
+	}
+	deadline := time.Now().Add(time.Second)
+	for watcher.Contains("web-1") {
+		if time.Now().After(deadline) {
+			t.Fatal("browser participant did not leave")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBrowserDriftMatchesMPV(t *testing.T) {
+	broker := NewMemoryBroker()
+	mpvPeer := newPeer(t, broker, "mpv", 600)
+	mpvSyncer := NewSyncer(mpvPeer.ctrl, 600, nil)
+
+	browser := newFakeBrowser()
+	participant := NewBrowserParticipant(browser, nil, "web-1", "Carol", []string{"/stream"}, 600, nil)
+	browserSyncer := NewSyncer(participant, 600, nil)
+
+	// 两种跟随端都位于 100 秒，按 needsSeek 的同一规则决定是否跳转
+	cases := []struct {
+		target float64
+		paused bool
+		seek   bool
+	}{
+		{100 + driftTolerance - 0.05, false, false},
+		{100 - driftTolerance + 0.05, false, false},
+		{100 + driftTolerance + 0.05, false, true},
+		{100 - driftTolerance - 0.05, false, true},
+		{100.1, true, true}, // 暂停时小偏差也跳转
+	}
+	for _, c := range cases {
+		status := model.PlayStatus{Timestamp: c.target, Paused: c.paused}
+		mpvPeer.server.Set("time-pos", 100.0)
+		if err := mpvSyncer.Apply(status); err != nil {
+			t.Fatal(err)
+		}
+		participant.mu.Lock()
+		participant.local, participant.localAt = model.PlayStatus{Timestamp: 100}, time.Now()
+		participant.mu.Unlock()
+		if err := browserSyncer.Apply(status); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := mpvPeer.server.WaitCommand("seek", 200*time.Millisecond)
+		if mpvSeek := err == nil; mpvSeek != c.seek {
+			t.Errorf("target %v paused %v: mpv seek %v, want %v", c.target, c.paused, mpvSeek, c.seek)
+		}
+		browserSeek := false
+		for len(browser.out) > 0 {
+			if msg := <-browser.out; msg.Kind == model.BrowserSeek {
+				browserSeek = msg.Position == c.target
+			}
+		}
+		if browserSeek != c.seek {
+			t.Errorf("target %v paused %v: browser seek %v, want %v", c.target, c.paused, browserSeek, c.seek)
+		}
+	}
+
+	// 还没上报过状态时总是跳转
+	participant.mu.Lock()
+	participant.localAt = time.Time{}
+	participant.mu.Unlock()
+	browserSyncer.Apply(model.PlayStatus{Timestamp: 100})
+	browser.expect(t, model.BrowserSeek)
+	browser.expect(t, model.BrowserPlay)
+
+	// 相对跳转从推算的位置开始
+	participant.mu.Lock()
+	participant.local, participant.localAt = model.PlayStatus{Timestamp: 100, Paused: true}, time.Now()
+	participant.mu.Unlock()
+	participant.Seek(10, "relative")
+	if msg := browser.expect(t, model.BrowserSeek); msg.Position != 110 {
+		t.Errorf("relative: sought to %v", msg.Position)
+	}
+}
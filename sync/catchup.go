@@ -0,0 +1,194 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+	"movie-night/pkg/mpv"
+)
+
+const (
+	maxExtrapolation   = 60 * time.Second // 推算位置时消息最大年龄（防止时钟偏差过大）
+	catchUpTimeout     = 2 * time.Minute  // 预缓冲最长等待时间
+	catchUpBoardLinger = 3 * time.Second  // 所有人追上后面板保留的时间
+)
+
+// Extrapolate 推算状态在 now 时刻对应的播放位置
+// 保留消息可能是几秒前发的，播放中需要加上经过的时间
+func Extrapolate(status model.PlayStatus, now time.Time) float64 {
+	if status.Paused || status.SentAt == 0 {
+		return status.Timestamp
+	}
+
+	age := now.Sub(time.UnixMilli(status.SentAt))
+	if age < 0 {
+		age = 0
+	}
+	if age > maxExtrapolation {
+		age = maxExtrapolation
+	}
+	return status.Timestamp + age.Seconds()*status.Rate()
+}
+
+// PrebufferFunc 预缓冲 position 附近的数据，progress 报告完成比例 (0-1)
+type PrebufferFunc func(ctx context.Context, position float64, progress func(float64)) error
+
+// CatchUp 中途加入：暂停播放，预缓冲目标位置附近的数据，并向房主报告进度
+type CatchUp struct {
+	mqttClient *MQTTClient
+	mpvCtrl    *mpv.Controller
+	prebuffer  PrebufferFunc
+	id         string
+	name       string
+	Timeout    time.Duration
+	logger     *slog.Logger
+}
+
+// NewCatchUp 创建追赶流程
+func NewCatchUp(mqttClient *MQTTClient, mpvCtrl *mpv.Controller, prebuffer PrebufferFunc, id, name string, logger *slog.Logger) *CatchUp {
+	return &CatchUp{
+		logger:     logging.Component(logger, "CatchUp"),
+		mqttClient: mqttClient,
+		mpvCtrl:    mpvCtrl,
+		prebuffer:  prebuffer,
+		id:         id,
+		name:       name,
+		Timeout:    catchUpTimeout,
+	}
+}
+
+// Run 暂停并预缓冲 target 附近的数据（阻塞到完成、超时或 ctx 取消），完成后由调用方跳转并继续播放
+func (c *CatchUp) Run(ctx context.Context, target float64) error {
+	c.logger.Info("中途加入，预缓冲附近的数据", "target", formatClock(target))
+
+	c.mpvCtrl.Pause()
+	c.report(model.CatchUpProgress{Target: target})
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	lastPercent := -1
+	err := c.prebuffer(ctx, target, func(ratio float64) {
+		percent := int(ratio * 100)
+		if percent == lastPercent {
+			return
+		}
+		lastPercent = percent
+
+		c.mpvCtrl.ShowText(fmt.Sprintf("⏳ 正在追赶进度 %d%%", percent), 1000)
+		c.report(model.CatchUpProgress{Target: target, Percent: percent})
+	})
+
+	progress := model.CatchUpProgress{Target: target, Percent: 100, Done: true}
+	if err != nil {
+		// 超时也继续播放，只是可能还会卡顿
+		progress.Error = err.Error()
+		c.logger.Warn("预缓冲失败", "err", err)
+	}
+	c.report(progress)
+
+	return err
+}
+
+// report 发送进度
+func (c *CatchUp) report(progress model.CatchUpProgress) {
+	progress.ID = c.id
+	progress.Name = c.name
+	if err := c.mqttClient.PublishTo(TopicCatchUp, progress, false); err != nil {
+		c.logger.Warn("进度发送失败", "err", err)
+	}
+}
+
+// CatchUpBoard 房主端：在同步面板上显示中途加入者的追赶进度
+type CatchUpBoard struct {
+	mqttClient *MQTTClient
+	mpvCtrl    *mpv.Controller
+
+	mu     gosync.Mutex
+	states map[string]mpv.PeerSyncState
+	clear  *time.Timer
+	logger *slog.Logger
+}
+
+// NewCatchUpBoard 创建追赶进度面板
+func NewCatchUpBoard(mqttClient *MQTTClient, mpvCtrl *mpv.Controller, logger *slog.Logger) *CatchUpBoard {
+	return &CatchUpBoard{
+		logger:     logging.Component(logger, "CatchUp"),
+		mqttClient: mqttClient,
+		mpvCtrl:    mpvCtrl,
+		states:     make(map[string]mpv.PeerSyncState),
+	}
+}
+
+// Start 订阅追赶进度
+func (b *CatchUpBoard) Start() error {
+	return b.mqttClient.SubscribeTo(TopicCatchUp, b.handlePayload)
+}
+
+// Stop 取消订阅并清除面板
+func (b *CatchUpBoard) Stop() {
+	if err := b.mqttClient.UnsubscribeFrom(TopicCatchUp); err != nil {
+		b.logger.Warn("取消订阅失败", "err", err)
+	}
+
+	b.mu.Lock()
+	if b.clear != nil {
+		b.clear.Stop()
+	}
+	b.states = make(map[string]mpv.PeerSyncState)
+	b.mu.Unlock()
+
+	b.mpvCtrl.ClearSyncOverlay()
+}
+
+// handlePayload 更新面板
+func (b *CatchUpBoard) handlePayload(payload []byte) {
+	var progress model.CatchUpProgress
+	if err := json.Unmarshal(payload, &progress); err != nil || progress.ID == "" {
+		return
+	}
+
+	state := mpv.PeerSyncState{
+		Name:       progress.Name,
+		IsReady:    progress.Done,
+		Buffering:  progress.Percent,
+		StatusText: "Catching up",
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.states[progress.ID] = state
+
+	allDone := true
+	for _, s := range b.states {
+		allDone = allDone && s.IsReady
+	}
+
+	if progress.Done {
+		b.logger.Info("已追上进度", "name", progress.Name)
+	}
+
+	b.logger.Debug("绘制同步面板", "peers", len(b.states))
+	b.mpvCtrl.DrawSyncOverlay(b.states)
+
+	if b.clear != nil {
+		b.clear.Stop()
+		b.clear = nil
+	}
+	if allDone {
+		b.clear = time.AfterFunc(catchUpBoardLinger, func() {
+			b.mu.Lock()
+			b.states = make(map[string]mpv.PeerSyncState)
+			b.clear = nil
+			b.mu.Unlock()
+			b.mpvCtrl.ClearSyncOverlay()
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"movie-night/model"
+)
+
+func TestExtrapolate(t *testing.T) {
+	now := time.Now()
+	sentAt := now.Add(-4 * time.Second).UnixMilli()
+
+	playing := model.PlayStatus{Timestamp: 100, SentAt: sentAt}
+	if got := Extrapolate(playing, now); got < 103.9 || got > 104.1 {
+		t.Errorf("Expected ~104s for a playing status sent 4s ago, got %.2f", got)
+	}
+
+	// 房间同步了播放速度时按速度推算
+	fast := model.PlayStatus{Timestamp: 100, SentAt: sentAt, Speed: 1.5}
+	if got := Extrapolate(fast, now); got < 105.9 || got > 106.1 {
+		t.Errorf("Expected ~106s at 1.5x, got %.2f", got)
+	}
+
+	paused := model.PlayStatus{Timestamp: 100, Paused: true, SentAt: sentAt}
+	if got := Extrapolate(paused, now); got != 100 {
+		t.Errorf("Paused status should not advance, got %.2f", got)
+	}
+
+	// 旧版本的消息没有发送时间
+	legacy := model.PlayStatus{Timestamp: 100}
+	if got := Extrapolate(legacy, now); got != 100 {
+		t.Errorf("Status without SentAt should not advance, got %.2f", got)
+	}
+
+	// 时钟偏差过大时限制推算范围
+	ancient := model.PlayStatus{Timestamp: 100, SentAt: now.Add(-time.Hour).UnixMilli()}
+	if got := Extrapolate(ancient, now); got != 100+maxExtrapolation.Seconds() {
+		t.Errorf("Expected extrapolation to be clamped, got %.2f", got)
+	}
+
+	future := model.PlayStatus{Timestamp: 100, SentAt: now.Add(time.Minute).UnixMilli()}
+	if got := Extrapolate(future, now); got != 100 {
+		t.Errorf("Status from the future should not go backwards, got %.2f", got)
+	}
+}
+
+func TestFollowerStopCancelsCatchUp(t *testing.T) {
+	viewer := newPeer(t, NewMemoryBroker(), "viewer", 600)
+	follower := NewFollower(viewer.ctrl, viewer.client, 600, nil)
+
+	// 预缓冲一直等不到数据，直到被取消
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	prebuffer := func(ctx context.Context, position float64, progress func(float64)) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	}
+	follower.SetCatchUp(NewCatchUp(viewer.client, viewer.ctrl, prebuffer, "viewer", "Viewer", nil))
+	if err := follower.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	follower.handleStatus(model.PlayStatus{Timestamp: 300})
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("catch-up never started")
+	}
+
+	// 停止（退出或成为房主）时中止预缓冲，之后不再跳转
+	follower.Stop()
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("prebuffer not cancelled by Stop")
+	}
+	if cmd, err := viewer.server.WaitCommand("seek", 300*time.Millisecond); err == nil {
+		t.Errorf("follower sought after Stop: %v", cmd)
+	}
+}
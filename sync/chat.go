@@ -0,0 +1,132 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+)
+
+const (
+	chatHistory  = 50   // 保留的最近消息数
+	chatShowTime = 4000 // 在 MPV 中显示的时长（毫秒）
+)
+
+// Chat 房间聊天：收到的消息显示在 MPV 中，并保留最近的记录供遥控页面显示
+type Chat struct {
+	mqttClient *MQTTClient
+	player     Player // 显示收到的消息（可为空）
+	self       model.Participant
+
+	mu        gosync.Mutex
+	messages  []model.ChatMessage
+	onMessage func(msg model.ChatMessage) // 收到消息时的回调
+	logger    *slog.Logger
+}
+
+// NewChat 创建聊天，self 为发送者身份，player 可为空
+func NewChat(mqttClient *MQTTClient, player Player, self model.Participant, logger *slog.Logger) *Chat {
+	return &Chat{
+		logger:     logging.Component(logger, "Chat"),
+		mqttClient: mqttClient,
+		player:     player,
+		self:       self,
+	}
+}
+
+// Start 订阅聊天消息
+func (c *Chat) Start() error {
+	return c.mqttClient.SubscribeTo(TopicChat, c.handlePayload)
+}
+
+// Stop 取消订阅聊天消息
+func (c *Chat) Stop() {
+	if err := c.mqttClient.UnsubscribeFrom(TopicChat); err != nil {
+		c.logger.Warn("取消订阅失败", "err", err)
+	}
+}
+
+// SetOnMessage 设置收到消息（包括自己发的）时的回调
+func (c *Chat) SetOnMessage(onMessage func(msg model.ChatMessage)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onMessage = onMessage
+}
+
+// Send 发送一条消息
+func (c *Chat) Send(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return fmt.Errorf("消息不能为空")
+	}
+	if len([]rune(text)) > model.MaxChatLength {
+		return fmt.Errorf("消息太长（最多 %d 字）", model.MaxChatLength)
+	}
+
+	msg := model.ChatMessage{
+		From:   c.self.ID,
+		Name:   c.self.Name,
+		Text:   text,
+		SentAt: time.Now().UnixMilli(),
+	}
+	return c.mqttClient.PublishTo(TopicChat, msg, false)
+}
+
+// Relay 以其他人的身份转发一条消息（如 Syncplay 用户的聊天），from 应带来源前缀以免被转发回去
+func (c *Chat) Relay(from, name, text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if runes := []rune(text); len(runes) > model.MaxChatLength {
+		text = string(runes[:model.MaxChatLength])
+	}
+
+	msg := model.ChatMessage{
+		From:   from,
+		Name:   name,
+		Text:   text,
+		SentAt: time.Now().UnixMilli(),
+	}
+	return c.mqttClient.PublishTo(TopicChat, msg, false)
+}
+
+// Messages 最近的消息（按收到的顺序）
+func (c *Chat) Messages() []model.ChatMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]model.ChatMessage(nil), c.messages...)
+}
+
+// handlePayload 记录并显示收到的消息（包括自己发的）
+func (c *Chat) handlePayload(payload []byte) {
+	var msg model.ChatMessage
+	if err := json.Unmarshal(payload, &msg); err != nil || strings.TrimSpace(msg.Text) == "" {
+		c.logger.Warn("聊天消息无效", "err", err)
+		return
+	}
+	if runes := []rune(msg.Text); len(runes) > model.MaxChatLength {
+		msg.Text = string(runes[:model.MaxChatLength])
+	}
+
+	c.mu.Lock()
+	c.messages = append(c.messages, msg)
+	if len(c.messages) > chatHistory {
+		c.messages = c.messages[len(c.messages)-chatHistory:]
+	}
+	onMessage := c.onMessage
+	c.mu.Unlock()
+
+	c.logger.Info("聊天", "from", msg.Name, "text", msg.Text)
+	if c.player != nil {
+		c.player.ShowText(fmt.Sprintf("💬 %s: %s", msg.Name, msg.Text), chatShowTime)
+	}
+	if onMessage != nil {
+		onMessage(msg)
+	}
+}
@@ -0,0 +1,43 @@
+package sync
+
+import "time"
+
+// Clock 时间来源（网络模拟和回放中替换为虚拟时钟）
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+// realClock 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time        { return time.Now() }
+func (realClock) Sleep(d time.Duration) { time.Sleep(d) }
+
+// VirtualClock 虚拟时钟，只在模拟器处理事件或回放工具读到下一条记录时走动
+// 使用方是单线程的：Sleep 直接把时间往前拨，期间到期的事件在返回后按时间顺序处理
+type VirtualClock struct {
+	now time.Time
+}
+
+// NewVirtualClock 创建虚拟时钟
+func NewVirtualClock(start time.Time) *VirtualClock {
+	return &VirtualClock{now: start}
+}
+
+// Now 当前虚拟时间
+func (c *VirtualClock) Now() time.Time {
+	return c.now
+}
+
+// Sleep 把时间往前拨
+func (c *VirtualClock) Sleep(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// AdvanceTo 前进到 t（不会倒退）
+func (c *VirtualClock) AdvanceTo(t time.Time) {
+	if t.After(c.now) {
+		c.now = t
+	}
+}
@@ -0,0 +1,418 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+	"movie-night/pkg/mpv"
+)
+
+const (
+	voteTimeout     = 30 * time.Second // 投票有效期
+	settleWindow    = 2 * time.Second  // 同步后这段时间内的状态变化不算用户操作
+	localSeekJump   = 3.0              // 本地位置跳变超过该值视为用户跳转（秒）
+	voteRefreshRate = 1 * time.Second  // 投票倒计时刷新间隔
+	resultShowTime  = 2000             // 投票结果提示时长（毫秒）
+)
+
+var (
+	errHostOnly   = errors.New("当前房间仅房主可控制")
+	errVoteOpened = errors.New("已发起投票，通过后执行")
+)
+
+// actionTitle 控制动作的显示名称
+func actionTitle(action model.ControlAction, position float64) string {
+	switch action {
+	case model.ActionPause:
+		return "Pause"
+	case model.ActionResume:
+		return "Resume"
+	case model.ActionSeek:
+		return fmt.Sprintf("Seek to %s", formatClock(position))
+	case model.ActionSkip:
+		return fmt.Sprintf("Skip %+.0fs", position)
+	}
+	return string(action)
+}
+
+// formatClock 秒数格式化为 h:mm:ss
+func formatClock(seconds float64) string {
+	total := int(seconds)
+	return fmt.Sprintf("%d:%02d:%02d", total/3600, total/60%60, total%60)
+}
+
+// ControlArbiter 房主端：按房间策略处理参与者的控制请求
+type ControlArbiter struct {
+	mqttClient *MQTTClient
+	mpvCtrl    *mpv.Controller
+	presence   *Presence
+
+	mu     gosync.Mutex
+	policy model.RoomPolicy
+	tally  *VoteTally
+
+	stopCh chan struct{}
+	logger *slog.Logger
+}
+
+// NewControlArbiter 创建控制仲裁器
+func NewControlArbiter(mqttClient *MQTTClient, mpvCtrl *mpv.Controller, presence *Presence, policy model.RoomPolicy, logger *slog.Logger) *ControlArbiter {
+	return &ControlArbiter{
+		logger:     logging.Component(logger, "Arbiter"),
+		mqttClient: mqttClient,
+		mpvCtrl:    mpvCtrl,
+		presence:   presence,
+		policy:     policy,
+		tally:      NewVoteTally(voteTimeout),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Start 发布房间策略并开始处理请求
+func (a *ControlArbiter) Start() error {
+	if err := a.mqttClient.PublishTo(TopicPolicy, a.policy, true); err != nil {
+		return err
+	}
+	if err := a.mqttClient.SubscribeTo(TopicRequest, a.handlePayload); err != nil {
+		return err
+	}
+
+	a.logger.Info("控制模式", "mode", a.policy.Mode, "sync", a.policy.Sync.String())
+
+	if a.policy.Mode == model.ControlVote {
+		go a.refreshLoop()
+	}
+	return nil
+}
+
+// handlePayload 处理控制请求
+func (a *ControlArbiter) handlePayload(payload []byte) {
+	var req model.ControlRequest
+	if err := json.Unmarshal(payload, &req); err != nil {
+		a.logger.Error("JSON 解析失败", "err", err)
+		return
+	}
+	a.handle(req)
+}
+
+// handle 按房间策略处理参与者的控制请求，请求未立即执行时返回原因
+func (a *ControlArbiter) handle(req model.ControlRequest) error {
+	policy := a.Policy()
+	switch policy.Mode {
+	case model.ControlAnyone:
+		a.logger.Info("收到控制请求", "from", req.Name, "action", actionTitle(req.Action, req.Position))
+		a.apply(req.Action, req.Position)
+		a.mpvCtrl.ShowText(fmt.Sprintf("%s: %s", req.Name, actionTitle(req.Action, req.Position)), resultShowTime)
+		return nil
+
+	case model.ControlVote:
+		needed := RequiredVotes(a.presence.Count(), policy.Quorum)
+
+		a.mu.Lock()
+		state, err := a.tally.Cast(req, needed, time.Now())
+		a.mu.Unlock()
+
+		if err != nil {
+			a.logger.Warn("忽略请求", "from", req.Name, "err", err)
+			return err
+		}
+
+		a.logger.Info("投票", "from", req.Name, "action", actionTitle(state.Action, state.Position), "votes", len(state.Voters), "needed", state.Needed)
+		if state.Passed {
+			a.apply(state.Action, state.Position)
+		}
+		a.publishVote(state)
+		if !state.Passed {
+			return errVoteOpened
+		}
+		return nil
+
+	default:
+		a.logger.Info("仅房主可控制，忽略请求", "from", req.Name)
+		return errHostOnly
+	}
+}
+
+// refreshLoop 定时广播投票倒计时，并处理过期
+func (a *ControlArbiter) refreshLoop() {
+	ticker := time.NewTicker(voteRefreshRate)
+	defer ticker.Stop()
+
+	for {
+		var now time.Time
+		select {
+		case <-a.stopCh:
+			return
+		case now = <-ticker.C:
+		}
+
+		a.mu.Lock()
+		expired, ok := a.tally.Expire(now)
+		current, active := a.tally.Current()
+		a.mu.Unlock()
+
+		if ok {
+			a.logger.Info("投票过期", "action", actionTitle(expired.Action, expired.Position))
+			a.publishVote(expired)
+		} else if active {
+			a.publishVote(current)
+		}
+	}
+}
+
+// Stop 停止处理请求（不再是房主时）
+func (a *ControlArbiter) Stop() {
+	close(a.stopCh)
+	if err := a.mqttClient.UnsubscribeFrom(TopicRequest); err != nil {
+		a.logger.Warn("取消订阅失败", "err", err)
+	}
+}
+
+// Policy 房间策略
+func (a *ControlArbiter) Policy() model.RoomPolicy {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.policy
+}
+
+// SetTrackSync 修改需要同步的播放属性并重新发布房间策略
+func (a *ControlArbiter) SetTrackSync(tracks model.TrackSync) error {
+	a.mu.Lock()
+	a.policy.Sync = tracks
+	policy := a.policy
+	a.mu.Unlock()
+
+	return a.mqttClient.PublishTo(TopicPolicy, policy, true)
+}
+
+// publishVote 广播投票状态
+func (a *ControlArbiter) publishVote(state model.VoteState) {
+	if err := a.mqttClient.PublishTo(TopicVote, state, false); err != nil {
+		a.logger.Warn("投票广播失败", "err", err)
+	}
+}
+
+// apply 在房主的播放器上执行动作（之后由 Controller 广播给所有人）
+func (a *ControlArbiter) apply(action model.ControlAction, position float64) {
+	var err error
+	switch action {
+	case model.ActionPause:
+		err = a.mpvCtrl.Pause()
+	case model.ActionResume:
+		err = a.mpvCtrl.Play()
+	case model.ActionSeek:
+		err = a.mpvCtrl.Seek(position, "absolute")
+	case model.ActionSkip:
+		err = a.mpvCtrl.Seek(position, "relative")
+	default:
+		err = fmt.Errorf("未知动作: %s", action)
+	}
+
+	if err != nil {
+		a.logger.Error("执行失败", "err", err)
+	}
+}
+
+// ActionReporter 跟随端：检测本地的暂停/跳转操作并按房间策略发送请求
+type ActionReporter struct {
+	mqttClient *MQTTClient
+	syncer     *Syncer
+	statusCh   <-chan model.PlayStatus
+	id         string
+	name       string
+
+	mu     gosync.Mutex
+	policy model.RoomPolicy
+
+	stopCh chan struct{}
+	logger *slog.Logger
+}
+
+// NewActionReporter 创建操作上报器
+// statusCh 提供本地播放状态，一般来自 Monitor.Subscribe()
+func NewActionReporter(mqttClient *MQTTClient, syncer *Syncer, statusCh <-chan model.PlayStatus, id, name string, logger *slog.Logger) *ActionReporter {
+	return &ActionReporter{
+		logger:     logging.Component(logger, "Room"),
+		mqttClient: mqttClient,
+		syncer:     syncer,
+		statusCh:   statusCh,
+		id:         id,
+		name:       name,
+		policy:     model.RoomPolicy{Mode: model.ControlHostOnly},
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Start 订阅房间策略并开始检测本地操作
+func (r *ActionReporter) Start() error {
+	err := r.mqttClient.SubscribeTo(TopicPolicy, func(payload []byte) {
+		var policy model.RoomPolicy
+		if err := json.Unmarshal(payload, &policy); err != nil || !policy.Mode.IsValid() {
+			return
+		}
+
+		r.mu.Lock()
+		r.policy = policy
+		r.mu.Unlock()
+		r.logger.Info("控制模式", "mode", policy.Mode)
+	})
+	if err != nil {
+		return err
+	}
+
+	go r.detectLoop()
+	return nil
+}
+
+// Stop 停止检测（成为房主时）
+func (r *ActionReporter) Stop() {
+	close(r.stopCh)
+	if err := r.mqttClient.UnsubscribeFrom(TopicPolicy); err != nil {
+		r.logger.Warn("取消订阅失败", "err", err)
+	}
+}
+
+// Policy 当前房间策略
+func (r *ActionReporter) Policy() model.RoomPolicy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.policy
+}
+
+// Request 发送控制请求（仅房主模式下不发送）
+func (r *ActionReporter) Request(action model.ControlAction, position float64) error {
+	return r.send(model.ControlRequest{Action: action, Position: position, From: r.id, Name: r.name})
+}
+
+// send 发送控制请求，From/Name 为发起者（仅房主模式下不发送）
+func (r *ActionReporter) send(req model.ControlRequest) error {
+	if r.Policy().Mode == model.ControlHostOnly {
+		return errHostOnly
+	}
+
+	req.SentAt = time.Now().UnixMilli()
+	return r.mqttClient.PublishTo(TopicRequest, req, false)
+}
+
+// detectLoop 对比相邻两次本地状态，识别用户的暂停/跳转
+func (r *ActionReporter) detectLoop() {
+	var prev model.PlayStatus
+	var prevAt time.Time
+
+	for {
+		var status model.PlayStatus
+		select {
+		case <-r.stopCh:
+			return
+		case status = <-r.statusCh:
+		}
+
+		now := time.Now()
+		first := prevAt.IsZero()
+		elapsed := now.Sub(prevAt).Seconds()
+		last := prev
+		prev, prevAt = status, now
+
+		if first || r.syncer.Settling(settleWindow) {
+			continue
+		}
+
+		var action model.ControlAction
+		var position float64
+
+		expected := last.Timestamp
+		if !last.Paused {
+			expected += elapsed
+		}
+
+		switch {
+		case status.Paused && !last.Paused:
+			action = model.ActionPause
+		case !status.Paused && last.Paused:
+			action = model.ActionResume
+		case math.Abs(status.Timestamp-expected) > localSeekJump:
+			action, position = model.ActionSeek, status.Timestamp
+		default:
+			continue
+		}
+
+		policy := r.Policy()
+		if policy.Mode == model.ControlHostOnly {
+			continue
+		}
+
+		r.logger.Info("请求", "action", actionTitle(action, position))
+		if err := r.Request(action, position); err != nil {
+			r.logger.Warn("请求发送失败", "err", err)
+		}
+
+		// 投票模式下本地操作只算投票，先回到房主的状态
+		if policy.Mode == model.ControlVote {
+			r.syncer.Resync()
+		}
+	}
+}
+
+// VoteDisplay 在 MPV 中显示进行中的投票
+type VoteDisplay struct {
+	mqttClient *MQTTClient
+	mpvCtrl    *mpv.Controller
+}
+
+// NewVoteDisplay 创建投票显示
+func NewVoteDisplay(mqttClient *MQTTClient, mpvCtrl *mpv.Controller) *VoteDisplay {
+	return &VoteDisplay{
+		mqttClient: mqttClient,
+		mpvCtrl:    mpvCtrl,
+	}
+}
+
+// Start 订阅投票状态
+func (d *VoteDisplay) Start() error {
+	return d.mqttClient.SubscribeTo(TopicVote, d.handlePayload)
+}
+
+// Stop 取消订阅投票状态并清除投票面板
+func (d *VoteDisplay) Stop() {
+	d.mqttClient.UnsubscribeFrom(TopicVote)
+	d.mpvCtrl.ClearVoteOverlay()
+}
+
+// handlePayload 绘制或清除投票面板
+func (d *VoteDisplay) handlePayload(payload []byte) {
+	var state model.VoteState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return
+	}
+
+	title := actionTitle(state.Action, state.Position)
+	if state.Resolved {
+		d.mpvCtrl.ClearVoteOverlay()
+		result := "❌ 投票未通过"
+		if state.Passed {
+			result = "✅ 投票通过"
+		}
+		d.mpvCtrl.ShowText(fmt.Sprintf("%s: %s", result, title), resultShowTime)
+		return
+	}
+
+	remaining := int(math.Ceil(float64(state.Deadline-time.Now().UnixMilli()) / 1000))
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	d.mpvCtrl.DrawVoteOverlay(mpv.VoteInfo{
+		Title:     title,
+		Proposer:  state.Proposer,
+		Voters:    state.Voters,
+		Needed:    state.Needed,
+		Remaining: remaining,
+	})
+}
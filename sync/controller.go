@@ -1,69 +1,143 @@
 package sync
 
 import (
-	"encoding/json"
-	"fmt"
+	"log/slog"
+	"math"
+	gosync "sync"
 	"time"
 
 	"movie-night/model"
-	"movie-night/pkg/mpv"
-
-	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"movie-night/pkg/logging"
 )
 
 // Controller 控制端
 type Controller struct {
-	mqttClient mqtt.Client // ← 改为原始 client
-	topic      string      // ← 添加 topic
-	monitor    *mpv.Monitor
+	mqttClient *MQTTClient
+	monitor    StatusSource
 	interval   time.Duration
+	stopCh     chan struct{}
+
+	hostID string // 写入广播的房主 ID
+	term   int    // 写入广播的房主任期
+
+	mu     gosync.Mutex
+	tracks model.TrackSync // 需要同步的播放属性
+
+	clock     Clock
+	current   model.PlayStatus // 最近的本地状态（只在广播循环中访问）
+	sampledAt time.Time        // current 的采样时间
+
+	logger *slog.Logger
 }
 
 // NewController 创建控制端
-func NewController(client mqtt.Client, topic string, monitor *mpv.Monitor, interval time.Duration) *Controller {
+func NewController(mqttClient *MQTTClient, monitor StatusSource, interval time.Duration, logger *slog.Logger) *Controller {
 	return &Controller{
-		mqttClient: client,
-		topic:      topic,
+		logger:     logging.Component(logger, "Controller"),
+		mqttClient: mqttClient,
+		clock:      realClock{},
 		monitor:    monitor,
 		interval:   interval,
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// SetHost 设置广播中携带的房主身份（需在 Start 之前调用）
+func (c *Controller) SetHost(id string, term int) {
+	c.hostID = id
+	c.term = term
+}
+
+// SetTrackSync 设置需要同步的播放属性（可随时修改）
+func (c *Controller) SetTrackSync(tracks model.TrackSync) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tracks = tracks
+}
+
+// filterTracks 去掉房间没有开启同步的属性
+func (c *Controller) filterTracks(status model.PlayStatus) model.PlayStatus {
+	c.mu.Lock()
+	tracks := c.tracks
+	c.mu.Unlock()
+
+	if !tracks.Speed {
+		status.Speed = 0
+	}
+	if !tracks.Audio {
+		status.Aid = ""
 	}
+	if !tracks.Subtitle {
+		status.Sid = ""
+	}
+	return status
 }
 
+// 状态突变判定：位置跳变超过该值视为跳转
+const seekJumpThreshold = 2.0
+
 // Start 开始广播
+// 定时广播当前状态；暂停/继续、跳转和同步属性的变化会立即广播，让跟随端尽快跟上
 func (c *Controller) Start() {
-	fmt.Printf("🎮 [Controller] 启动 (每 %v 广播一次)\n", c.interval)
+	c.logger.Info("启动", "interval", c.interval)
 
 	ticker := time.NewTicker(c.interval)
 	defer ticker.Stop()
 
 	statusCh := c.monitor.GetStatusChannel()
-	var currentStatus model.PlayStatus
-
 	for {
 		select {
+		case <-c.stopCh:
+			return
+
 		case <-ticker.C:
-			// ===== 使用原始方式发布 =====
-			jsonData, err := json.Marshal(currentStatus)
-			if err != nil {
-				fmt.Printf("❌ [Controller] 序列化失败: %v\n", err)
-				continue
-			}
-
-			token := c.mqttClient.Publish(c.topic, 1, true, jsonData)
-			token.Wait()
-
-			if token.Error() != nil {
-				fmt.Printf("❌ [Controller] 广播失败: %v\n", token.Error())
-			} else {
-				emoji := "▶️"
-				if currentStatus.Paused {
-					emoji = "⏸️"
-				}
-				fmt.Printf("📤 [Controller] 广播: %.2f秒 %s\n", currentStatus.Timestamp, emoji)
-			}
+			c.broadcast(c.current)
 
 		case status := <-statusCh:
-			currentStatus = status
+			c.sample(status)
+		}
+	}
+}
+
+// sample 记录本地状态，有突变时立即广播
+func (c *Controller) sample(status model.PlayStatus) {
+	now := c.clock.Now()
+
+	// 第一次拿到状态立即广播（换房主后跟随端能马上跟上）
+	changed := c.sampledAt.IsZero()
+	if !c.sampledAt.IsZero() {
+		expected := c.current.Timestamp
+		if !c.current.Paused {
+			expected += now.Sub(c.sampledAt).Seconds() * c.current.Rate()
 		}
+		changed = status.Paused != c.current.Paused ||
+			math.Abs(status.Timestamp-expected) > seekJumpThreshold ||
+			c.filterTracks(status) != c.filterTracks(c.current)
+	}
+
+	c.current = status
+	c.sampledAt = now
+
+	if changed {
+		c.broadcast(c.current)
+	}
+}
+
+// broadcast 发布状态（保留消息，新加入的跟随端能立即收到）
+func (c *Controller) broadcast(status model.PlayStatus) {
+	status = c.filterTracks(status)
+	status.Host = c.hostID
+	status.Term = c.term
+	status.SentAt = c.clock.Now().UnixMilli()
+
+	if err := c.mqttClient.publishStatus(status); err != nil {
+		c.logger.Error("广播失败", "err", err)
+	} else {
+		c.logger.Debug("广播", "pos", status.Timestamp, "paused", status.Paused)
 	}
 }
+
+// Stop 停止广播
+func (c *Controller) Stop() {
+	close(c.stopCh)
+}
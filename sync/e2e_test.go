@@ -0,0 +1,156 @@
+package sync
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"movie-night/pkg/mpv"
+	"movie-night/pkg/mpv/mpvtest"
+)
+
+// peer 一端：模拟的 MPV 加上连到内存 Broker 的客户端
+type peer struct {
+	server  *mpvtest.Server
+	ctrl    *mpv.Controller
+	monitor *mpv.Monitor
+	client  *MQTTClient
+}
+
+func newPeer(t *testing.T, broker *MemoryBroker, name string, duration float64) *peer {
+	t.Helper()
+
+	server, err := mpvtest.NewServer(filepath.Join(t.TempDir(), name+".sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	server.Set("duration", duration)
+
+	ctrl, err := mpv.NewController(server.SocketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ctrl.Close() })
+
+	monitor, err := mpv.NewMonitor(server.SocketPath, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(monitor.Stop)
+	monitor.Start()
+
+	client := NewMQTTClientWithTransport(broker.Connect(), "video/control", nil)
+	t.Cleanup(client.Close)
+
+	return &peer{server: server, ctrl: ctrl, monitor: monitor, client: client}
+}
+
+// waitSeek 等待跳转到 target 附近
+func waitSeek(t *testing.T, server *mpvtest.Server, target float64) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		cmd, err := server.WaitCommand("seek", time.Until(deadline))
+		if err != nil {
+			break
+		}
+		if pos, _ := cmd[1].(float64); pos >= target-0.5 && pos <= target+1.5 {
+			return
+		}
+	}
+	t.Fatalf("follower never sought to %.1f", target)
+}
+
+func TestControllerToFollower(t *testing.T) {
+	broker := NewMemoryBroker()
+	host := newPeer(t, broker, "host", 600)
+	viewer := newPeer(t, broker, "viewer", 600)
+
+	controller := NewController(host.client, host.monitor, time.Hour, nil)
+	controller.SetHost("host", 1)
+	go controller.Start()
+	defer controller.Stop()
+
+	follower := NewFollower(viewer.ctrl, viewer.client, 600, nil)
+	if err := follower.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer follower.Stop()
+
+	// 房主跳转：立即广播，跟随端跟着跳
+	host.server.Set("time-pos", 120.0)
+	waitSeek(t, viewer.server, 120)
+
+	// 房主暂停：跟随端也暂停
+	host.server.Set("pause", true)
+	deadline := time.Now().Add(3 * time.Second)
+	for viewer.server.Get("pause") != true {
+		if time.Now().After(deadline) {
+			t.Fatal("follower did not pause")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// 广播是保留消息：之后加入的跟随端也能跟上
+	late := newPeer(t, broker, "late", 600)
+	lateFollower := NewFollower(late.ctrl, late.client, 600, nil)
+	if err := lateFollower.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer lateFollower.Stop()
+	waitSeek(t, late.server, 120)
+}
+
+func TestMemoryBrokerRetainedAndWill(t *testing.T) {
+	broker := NewMemoryBroker()
+	alice := broker.Connect()
+	alice.SetWill("room/presence/alice", []byte(`{"left":true}`))
+	alice.Publish("room/presence/alice", []byte(`{"left":false}`), true)
+
+	bob := broker.Connect()
+	got := make(chan string, 4)
+	bob.Subscribe("room/presence/+", func(topic string, payload []byte) {
+		got <- string(payload)
+	})
+
+	expect := func(want string) {
+		t.Helper()
+		select {
+		case payload := <-got:
+			if payload != want {
+				t.Errorf("got %s, want %s", payload, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("no message, want %s", want)
+		}
+	}
+
+	// 订阅时收到保留消息，异常断开时收到遗嘱
+	expect(`{"left":false}`)
+	alice.Drop()
+	expect(`{"left":true}`)
+
+	if err := alice.Publish("room/presence/alice", nil, false); err == nil {
+		t.Error("publish on a dropped connection should fail")
+	}
+}
+
+func TestTopicMatches(t *testing.T) {
+	cases := []struct {
+		filter, topic string
+		want          bool
+	}{
+		{"video/control", "video/control", true},
+		{"video/control", "video/control/vote", false},
+		{"video/control/presence/+", "video/control/presence/a", true},
+		{"video/control/presence/+", "video/control/presence", false},
+		{"video/control/#", "video/control/presence/a", true},
+		{"video/+/vote", "video/control/vote", true},
+	}
+	for _, c := range cases {
+		if got := topicMatches(c.filter, c.topic); got != c.want {
+			t.Errorf("topicMatches(%q, %q) = %v, want %v", c.filter, c.topic, got, c.want)
+		}
+	}
+}
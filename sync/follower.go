@@ -1,42 +1,208 @@
 package sync
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	gosync "sync"
+	"time"
 
-	"movie-night/pkg/mpv"
+	"movie-night/model"
+	"movie-night/pkg/logging"
 )
 
 // Follower 跟随端（观众）
 type Follower struct {
-	syncer     *Syncer
-	mqttClient *MQTTClient
+	syncer       *Syncer
+	mqttClient   *MQTTClient
+	intermission *Intermission
+
+	mu   gosync.Mutex
+	term int // 当前房主任期，更早任期的状态（旧房主的残留消息）会被忽略
+
+	drift   *DriftMeter   // 测量与房主的偏差（可为空）
+	onDrift func(float64) // 测得偏差后的回调（如写入在线状态）
+
+	catchUp    *CatchUp         // 中途加入时的追赶流程，完成后置空
+	catchingUp bool             // 追赶进行中
+	latest     model.PlayStatus // 追赶期间收到的最新状态
+
+	ctx    context.Context // Stop 时取消，中止进行中的追赶
+	cancel context.CancelFunc
+	logger *slog.Logger
 }
 
 // NewFollower 创建跟随端
-func NewFollower(mpvCtrl *mpv.Controller, mqttClient *MQTTClient, maxDuration float64) *Follower {
+func NewFollower(mpvCtrl Player, mqttClient *MQTTClient, maxDuration float64, logger *slog.Logger) *Follower {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Follower{
-		syncer:     NewSyncer(mpvCtrl, maxDuration),
-		mqttClient: mqttClient,
+		logger:       logging.Component(logger, "Follower"),
+		syncer:       NewSyncer(mpvCtrl, maxDuration, logger),
+		mqttClient:   mqttClient,
+		intermission: NewIntermission(mpvCtrl),
+		ctx:          ctx,
+		cancel:       cancel,
 	}
 }
 
 // Start 启动跟随端
 func (f *Follower) Start() error {
-	fmt.Println("📺 跟随端启动")
+	f.logger.Info("跟随端启动")
 
 	// 启动同步器
 	f.syncer.Start()
 
 	// 订阅 MQTT
-	if err := f.mqttClient.Subscribe(f.syncer.HandleStatus); err != nil {
+	if err := f.mqttClient.Subscribe(f.handleStatus); err != nil {
 		return fmt.Errorf("订阅失败: %w", err)
 	}
+	if err := f.mqttClient.SubscribeTo(TopicPlayback, f.handlePlayback); err != nil {
+		return fmt.Errorf("订阅播放事件失败: %w", err)
+	}
 
-	fmt.Println("✅ 已订阅，等待同步命令")
+	f.logger.Info("已订阅，等待同步命令")
 	return nil
 }
 
+// handleStatus 过滤旧房主的状态后交给同步器
+func (f *Follower) handleStatus(status model.PlayStatus) {
+	f.mu.Lock()
+	meter, onDrift := f.drift, f.onDrift
+	f.mu.Unlock()
+	if drift, ok := observeStatus(meter, status); ok && onDrift != nil {
+		onDrift(drift)
+	}
+
+	f.mu.Lock()
+	term := f.term
+	if status.Term > f.term {
+		f.term = status.Term
+	}
+	f.mu.Unlock()
+
+	if status.Term < term {
+		f.logger.Warn("忽略旧房主的状态", "term", status.Term, "current", term)
+		return
+	}
+
+	// 追赶期间只记录最新状态，完成后再同步
+	f.mu.Lock()
+	if f.catchUp != nil {
+		f.latest = status
+		if !f.catchingUp {
+			f.catchingUp = true
+			go f.runCatchUp()
+		}
+		f.mu.Unlock()
+		return
+	}
+	f.mu.Unlock()
+
+	f.syncer.HandleStatus(status)
+}
+
+// handlePlayback 跟随房主的章节跳转、播放结束和播放列表切换
+func (f *Follower) handlePlayback(payload []byte) {
+	var event model.PlaybackEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		f.logger.Error("播放事件解析失败", "err", err)
+		return
+	}
+
+	f.mu.Lock()
+	term := f.term
+	f.mu.Unlock()
+	if event.Term < term {
+		return
+	}
+
+	f.logger.Info("播放事件", "kind", event.Kind, "item", event.Item+1)
+
+	switch event.Kind {
+	case model.EventChapter:
+		if err := f.syncer.JumpChapter(event); err != nil {
+			// 位置仍会由下一次状态同步
+			f.logger.Warn("章节跳转无效", "err", err)
+		}
+
+	case model.EventEOF:
+		f.intermission.Show(event.HasNext, time.UnixMilli(event.Deadline))
+
+	case model.EventIntermissionCancel:
+		f.intermission.Hide()
+
+	case model.EventNext:
+		f.intermission.Hide()
+		go f.syncer.LoadItem(event.Item)
+	}
+}
+
+// SetDriftMeter 启用偏差测量（需在 Start 之前调用），onDrift 可为空
+func (f *Follower) SetDriftMeter(meter *DriftMeter, onDrift func(float64)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.drift, f.onDrift = meter, onDrift
+}
+
+// SetCatchUp 启用中途加入的追赶流程（需在 Start 之前调用）
+func (f *Follower) SetCatchUp(catchUp *CatchUp) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.catchUp = catchUp
+}
+
+// runCatchUp 预缓冲推算出的位置，完成后按最新状态跳转并继续
+func (f *Follower) runCatchUp() {
+	f.mu.Lock()
+	catchUp, status := f.catchUp, f.latest
+	f.mu.Unlock()
+
+	f.syncer.SetBusy(true)
+	defer f.syncer.SetBusy(false)
+
+	catchUp.Run(f.ctx, Extrapolate(status, time.Now()))
+
+	f.mu.Lock()
+	status = f.latest
+	f.catchUp = nil
+	f.catchingUp = false
+	f.mu.Unlock()
+
+	// 已经停止（退出或成为房主）：不再跳转和继续播放
+	if f.ctx.Err() != nil {
+		return
+	}
+
+	// 预缓冲期间房主还在继续播放，重新推算位置
+	status.Timestamp = Extrapolate(status, time.Now())
+	status.SentAt = 0
+	f.syncer.HandleStatus(status)
+}
+
+// SetTerm 更新当前房主任期
+func (f *Follower) SetTerm(term int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if term > f.term {
+		f.term = term
+	}
+}
+
+// GetSyncer 获取同步器
+func (f *Follower) GetSyncer() *Syncer {
+	return f.syncer
+}
+
 // Stop 停止跟随端
 func (f *Follower) Stop() {
+	f.cancel()
+	if err := f.mqttClient.Unsubscribe(); err != nil {
+		f.logger.Warn("取消订阅失败", "err", err)
+	}
+	if err := f.mqttClient.UnsubscribeFrom(TopicPlayback); err != nil {
+		f.logger.Warn("取消订阅失败", "err", err)
+	}
+	f.intermission.Hide()
 	f.syncer.Stop()
 }
@@ -0,0 +1,276 @@
+package sync
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+	"movie-night/pkg/mpv"
+)
+
+// MPV 按键触发的房间动作
+const (
+	KeyActionRequestPause  = "request-pause"  // 请求暂停/继续（按房间策略）
+	KeyActionToggleOverlay = "toggle-overlay" // 开关房间成员面板
+	KeyActionToggleStats   = "toggle-stats"   // 开关种子统计面板
+	KeyActionReady         = "ready"          // 切换准备状态
+	KeyActionReactPrefix   = "react-"         // 发送表情反应，如 react-laugh
+)
+
+// roomOverlayRefresh 房间成员面板的刷新间隔
+const roomOverlayRefresh = 2 * time.Second
+
+// statsOverlayRefresh 种子统计面板的刷新间隔
+const statsOverlayRefresh = time.Second
+
+// StatsSource 根据当前播放位置（秒）生成种子统计面板的内容
+type StatsSource func(position float64) mpv.TorrentStats
+
+// KeyActions 处理 MPV 按键发来的 client-message，驱动房间动作
+type KeyActions struct {
+	room     *Room
+	reactor  *Reactor
+	presence *Presence
+	mpvCtrl  *mpv.Controller
+	statusCh <-chan model.PlayStatus
+
+	mu          gosync.Mutex
+	paused      bool          // 本地是否暂停
+	position    float64       // 本地播放位置
+	overlayStop chan struct{} // 房间成员面板显示中时不为空
+	stats       StatsSource
+	statsStop   chan struct{} // 种子统计面板显示中时不为空
+
+	stopCh chan struct{}
+	logger *slog.Logger
+}
+
+// NewKeyActions 创建按键动作处理器
+// statusCh 提供本地播放状态，一般来自 Monitor.Subscribe()
+func NewKeyActions(room *Room, reactor *Reactor, presence *Presence, mpvCtrl *mpv.Controller, statusCh <-chan model.PlayStatus, logger *slog.Logger) *KeyActions {
+	return &KeyActions{
+		logger:   logging.Component(logger, "Keys"),
+		room:     room,
+		reactor:  reactor,
+		presence: presence,
+		mpvCtrl:  mpvCtrl,
+		statusCh: statusCh,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// SetStatsSource 设置种子统计面板的数据来源（未设置时 toggle-stats 不可用）
+func (k *KeyActions) SetStatsSource(source StatsSource) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.stats = source
+}
+
+// Start 处理按键消息，messages 一般来自 Monitor.SubscribeMessages()
+func (k *KeyActions) Start(messages <-chan []string) {
+	go func() {
+		for {
+			select {
+			case <-k.stopCh:
+				return
+			case status := <-k.statusCh:
+				k.mu.Lock()
+				k.paused = status.Paused
+				k.position = status.Timestamp
+				k.mu.Unlock()
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-k.stopCh:
+				return
+			case args := <-messages:
+				// 内置脚本的事件由 ScriptBridge 处理
+				if args[0] == mpv.ScriptEvent {
+					continue
+				}
+				k.Handle(args[0])
+			}
+		}
+	}()
+}
+
+// Stop 停止处理按键，并停止刷新显示中的面板
+func (k *KeyActions) Stop() {
+	close(k.stopCh)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, stop := range []*chan struct{}{&k.overlayStop, &k.statsStop} {
+		if *stop != nil {
+			close(*stop)
+			*stop = nil
+		}
+	}
+}
+
+// Handle 执行一个按键动作
+func (k *KeyActions) Handle(action string) {
+	k.logger.Debug("按键", "action", action)
+
+	var err error
+	switch {
+	case action == KeyActionRequestPause:
+		k.mu.Lock()
+		paused := k.paused
+		k.mu.Unlock()
+
+		request := model.ActionPause
+		if paused {
+			request = model.ActionResume
+		}
+		err = k.room.Request(request, 0)
+		if err == nil {
+			k.mpvCtrl.ShowText(fmt.Sprintf("🙋 已请求: %s", actionTitle(request, 0)), 1500)
+		}
+
+	case action == KeyActionToggleOverlay:
+		k.toggleOverlay()
+
+	case action == KeyActionToggleStats:
+		err = k.toggleStats()
+
+	case action == KeyActionReady:
+		if k.presence.ToggleReady() {
+			k.mpvCtrl.ShowText("✅ 已准备好", 1500)
+		} else {
+			k.mpvCtrl.ShowText("⏳ 取消准备", 1500)
+		}
+
+	case strings.HasPrefix(action, KeyActionReactPrefix):
+		err = k.reactor.Send(model.ReactionKind(strings.TrimPrefix(action, KeyActionReactPrefix)))
+
+	default:
+		err = fmt.Errorf("未知的按键动作: %s", action)
+	}
+
+	if err != nil {
+		k.logger.Warn("按键动作失败", "err", err)
+		k.mpvCtrl.ShowText(fmt.Sprintf("⚠️ %v", err), 2000)
+	}
+}
+
+// toggleOverlay 开关房间成员面板，显示期间定时刷新
+func (k *KeyActions) toggleOverlay() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	toggleRefresh(&k.mu, &k.overlayStop, roomOverlayRefresh, k.drawOverlay, func() { k.mpvCtrl.ClearRoomOverlay() })
+}
+
+// toggleStats 开关种子统计面板，显示期间定时刷新
+func (k *KeyActions) toggleStats() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.stats == nil {
+		return fmt.Errorf("种子统计不可用")
+	}
+	source := k.stats
+	draw := func() { k.mpvCtrl.DrawStatsOverlay(source(k.position)) }
+	toggleRefresh(&k.mu, &k.statsStop, statsOverlayRefresh, draw, func() { k.mpvCtrl.ClearStatsOverlay() })
+	return nil
+}
+
+// toggleRefresh 开关一个定时刷新的面板：*stop 为空时开始按 interval 调用 draw，否则停止刷新并调用 clear
+// 调用方需持有 mu；draw 也在持有 mu 时调用，并且停止之后不会再调用，不会在 clear 之后重新画出面板
+func toggleRefresh(mu *gosync.Mutex, stop *chan struct{}, interval time.Duration, draw, clear func()) {
+	if *stop != nil {
+		close(*stop)
+		*stop = nil
+		clear()
+		return
+	}
+
+	done := make(chan struct{})
+	*stop = done
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			mu.Lock()
+			select {
+			case <-done:
+				mu.Unlock()
+				return
+			default:
+			}
+			draw()
+			mu.Unlock()
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// drawOverlay 绘制房间成员面板（带同步质量汇总）
+func (k *KeyActions) drawOverlay() {
+	hostID := k.room.Host().ID
+	participants := k.presence.Participants()
+
+	states := make(map[string]mpv.PeerSyncState)
+	for _, p := range participants {
+		name := p.Name
+		if p.ID == hostID {
+			name += " (host)"
+		}
+
+		state := mpv.PeerSyncState{
+			Name:       name,
+			IsReady:    p.Ready,
+			Buffering:  -1,
+			StatusText: "Not ready",
+		}
+		if p.Drift != nil {
+			state.Detail = fmt.Sprintf("%+.1fs", *p.Drift)
+		}
+		states[p.ID] = state
+	}
+
+	k.mpvCtrl.DrawRoomOverlay(states, syncSummary(k.room.IsHost(), participants))
+}
+
+// syncSummary 同步质量汇总：房主汇总各跟随端上报的偏差，跟随端显示自己的统计
+func syncSummary(isHost bool, participants []model.Participant) string {
+	if !isHost {
+		s := metricDrift.Summary()
+		if s.Count == 0 {
+			return ""
+		}
+		return fmt.Sprintf("Drift avg %.2fs · max %.2fs · %d seeks", s.Mean, s.Max, int(metricSeeks.Value()))
+	}
+
+	var n int
+	var sum, worst float64
+	for _, p := range participants {
+		if p.Drift == nil {
+			continue
+		}
+		d := math.Abs(*p.Drift)
+		sum += d
+		worst = math.Max(worst, d)
+		n++
+	}
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("Followers drift avg %.2fs · max %.2fs", sum/float64(n), worst)
+}
@@ -0,0 +1,39 @@
+package sync
+
+import (
+	gosync "sync"
+	"testing"
+	"time"
+)
+
+func TestToggleRefreshNoDrawAfterClear(t *testing.T) {
+	var mu gosync.Mutex
+	var stop chan struct{}
+	draws, cleared, late := 0, false, false
+	draw := func() {
+		draws++
+		late = late || cleared
+	}
+	clear := func() { cleared = true }
+
+	mu.Lock()
+	toggleRefresh(&mu, &stop, 5*time.Millisecond, draw, clear)
+	mu.Unlock()
+
+	// 刷新等待锁的时候关闭面板：释放锁之后不能再画出来
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	time.Sleep(20 * time.Millisecond)
+	toggleRefresh(&mu, &stop, 5*time.Millisecond, draw, clear)
+	mu.Unlock()
+
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if draws == 0 {
+		t.Fatal("panel never drawn")
+	}
+	if late {
+		t.Error("panel drawn again after it was cleared")
+	}
+}
@@ -0,0 +1,222 @@
+package sync
+
+import (
+	gosync "sync"
+)
+
+// MemoryBroker 进程内的消息 Broker（测试用），行为与 MQTT Broker 一致：
+// 保留消息、通配符订阅、连接异常断开时发布遗嘱
+type MemoryBroker struct {
+	mu         gosync.Mutex
+	retained   map[string][]byte
+	transports map[*MemoryTransport]bool
+}
+
+// NewMemoryBroker 创建内存 Broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		retained:   make(map[string][]byte),
+		transports: make(map[*MemoryTransport]bool),
+	}
+}
+
+// memoryMessage 投递给连接的消息
+type memoryMessage struct {
+	topic   string
+	payload []byte
+}
+
+// Connect 创建一个连接
+func (b *MemoryBroker) Connect() *MemoryTransport {
+	t := &MemoryTransport{
+		broker: b,
+		subs:   make(map[string]func(topic string, payload []byte)),
+	}
+	t.cond = gosync.NewCond(&t.mu)
+
+	b.mu.Lock()
+	b.transports[t] = true
+	b.mu.Unlock()
+
+	go t.dispatch()
+	return t
+}
+
+// publish 保存保留消息并投递给所有订阅了该主题的连接
+func (b *MemoryBroker) publish(topic string, payload []byte, retained bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if retained {
+		// 空的保留消息表示清除
+		if len(payload) == 0 {
+			delete(b.retained, topic)
+		} else {
+			b.retained[topic] = payload
+		}
+	}
+
+	for t := range b.transports {
+		if t.subscribed(topic) {
+			t.enqueue(memoryMessage{topic: topic, payload: payload})
+		}
+	}
+}
+
+// subscribe 把匹配的保留消息投递给新的订阅
+func (b *MemoryBroker) subscribe(t *MemoryTransport, filter string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for topic, payload := range b.retained {
+		if topicMatches(filter, topic) {
+			t.enqueue(memoryMessage{topic: topic, payload: payload})
+		}
+	}
+}
+
+// disconnect 移除连接
+func (b *MemoryBroker) disconnect(t *MemoryTransport) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.transports, t)
+}
+
+// Retained 获取主题的保留消息
+func (b *MemoryBroker) Retained(topic string) ([]byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	payload, ok := b.retained[topic]
+	return payload, ok
+}
+
+// MemoryTransport MemoryBroker 上的连接（实现 Transport）
+// 消息按 Broker 收到的顺序依次交给订阅的 handler
+type MemoryTransport struct {
+	broker *MemoryBroker
+
+	mu     gosync.Mutex
+	cond   *gosync.Cond
+	subs   map[string]func(topic string, payload []byte)
+	queue  []memoryMessage
+	will   *memoryMessage
+	closed bool
+}
+
+// SetWill 设置遗嘱（Drop 时以保留消息发布）
+func (t *MemoryTransport) SetWill(topic string, payload []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.will = &memoryMessage{topic: topic, payload: payload}
+}
+
+// Publish 发布消息
+func (t *MemoryTransport) Publish(topic string, payload []byte, retained bool) error {
+	t.mu.Lock()
+	closed := t.closed
+	t.mu.Unlock()
+	if closed {
+		return errTransportClosed
+	}
+
+	t.broker.publish(topic, append([]byte(nil), payload...), retained)
+	return nil
+}
+
+// Subscribe 订阅主题
+func (t *MemoryTransport) Subscribe(topic string, handler func(topic string, payload []byte)) error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return errTransportClosed
+	}
+	t.subs[topic] = handler
+	t.mu.Unlock()
+
+	t.broker.subscribe(t, topic)
+	return nil
+}
+
+// Unsubscribe 取消订阅
+func (t *MemoryTransport) Unsubscribe(topic string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.subs, topic)
+	return nil
+}
+
+// Close 正常断开
+func (t *MemoryTransport) Close() {
+	t.close()
+}
+
+// Drop 模拟连接异常断开：Broker 发布遗嘱
+func (t *MemoryTransport) Drop() {
+	if will := t.close(); will != nil {
+		t.broker.publish(will.topic, will.payload, true)
+	}
+}
+
+// close 断开连接，返回遗嘱
+func (t *MemoryTransport) close() *memoryMessage {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	will := t.will
+	t.cond.Broadcast()
+	t.mu.Unlock()
+
+	t.broker.disconnect(t)
+	return will
+}
+
+// subscribed 是否有订阅匹配主题
+func (t *MemoryTransport) subscribed(topic string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for filter := range t.subs {
+		if topicMatches(filter, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueue 加入待投递队列
+func (t *MemoryTransport) enqueue(msg memoryMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queue = append(t.queue, msg)
+	t.cond.Signal()
+}
+
+// dispatch 依次把消息交给匹配的 handler
+func (t *MemoryTransport) dispatch() {
+	for {
+		t.mu.Lock()
+		for len(t.queue) == 0 && !t.closed {
+			t.cond.Wait()
+		}
+		if t.closed {
+			t.mu.Unlock()
+			return
+		}
+		msg := t.queue[0]
+		t.queue = t.queue[1:]
+
+		var handlers []func(topic string, payload []byte)
+		for filter, handler := range t.subs {
+			if topicMatches(filter, msg.topic) {
+				handlers = append(handlers, handler)
+			}
+		}
+		t.mu.Unlock()
+
+		for _, handler := range handlers {
+			handler(msg.topic, msg.payload)
+		}
+	}
+}
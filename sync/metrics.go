@@ -0,0 +1,90 @@
+package sync
+
+import (
+	"math"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/metrics"
+)
+
+// 同步质量指标（通过流服务的 /metrics 输出）
+var (
+	metricDrift = metrics.NewHistogram("movienight_sync_drift_seconds",
+		"收到房主状态时本地与房主位置偏差的绝对值", []float64{0.1, 0.25, 0.5, 1, 2, 5, 10})
+	metricDriftLast = metrics.NewGauge("movienight_sync_drift_last_seconds",
+		"最近一次测得的偏差（本地 - 房主），正数表示超前")
+	metricSeeks = metrics.NewCounter("movienight_sync_seeks_total",
+		"跟随端为同步执行的跳转次数")
+	metricLatency = metrics.NewHistogram("movienight_sync_message_latency_seconds",
+		"房主发出状态到收到的延迟（依赖双方时钟）", []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5})
+	metricSent = metrics.NewCounter("movienight_mqtt_messages_sent_total",
+		"发出的 MQTT 消息数")
+	metricReceived = metrics.NewCounter("movienight_mqtt_messages_received_total",
+		"收到的 MQTT 消息数")
+	metricReconnects = metrics.NewCounter("movienight_mqtt_reconnects_total",
+		"MQTT 断线重连次数")
+)
+
+// DriftMeter 根据最近的本地播放采样测量与房主的偏差
+type DriftMeter struct {
+	mu      gosync.Mutex
+	local   model.PlayStatus
+	localAt time.Time
+}
+
+// Observe 记录本地播放采样
+func (d *DriftMeter) Observe(status model.PlayStatus) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.local, d.localAt = status, time.Now()
+}
+
+// Run 持续记录本地播放采样，statusCh 一般来自 Monitor.Subscribe()
+func (d *DriftMeter) Run(statusCh <-chan model.PlayStatus, stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case status := <-statusCh:
+			d.Observe(status)
+		}
+	}
+}
+
+// Measure 计算 now 时刻本地位置与房主位置的偏差（本地 - 房主），还没有本地采样时返回 false
+func (d *DriftMeter) Measure(host model.PlayStatus, now time.Time) (float64, bool) {
+	d.mu.Lock()
+	local, localAt := d.local, d.localAt
+	d.mu.Unlock()
+
+	if localAt.IsZero() {
+		return 0, false
+	}
+	position := local.Timestamp
+	if !local.Paused {
+		position += now.Sub(localAt).Seconds() * local.Rate()
+	}
+	return position - Extrapolate(host, now), true
+}
+
+// observeStatus 记录收到房主状态时的延迟和偏差指标，返回测得的偏差
+func observeStatus(meter *DriftMeter, status model.PlayStatus) (float64, bool) {
+	now := time.Now()
+	if status.SentAt > 0 {
+		if latency := now.Sub(time.UnixMilli(status.SentAt)).Seconds(); latency >= 0 {
+			metricLatency.Observe(latency)
+		}
+	}
+	if meter == nil {
+		return 0, false
+	}
+
+	drift, ok := meter.Measure(status, now)
+	if ok {
+		metricDrift.Observe(math.Abs(drift))
+		metricDriftLast.Set(drift)
+	}
+	return drift, ok
+}
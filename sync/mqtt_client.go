@@ -3,91 +3,162 @@ package sync
 import (
 	"encoding/json"
 	"fmt"
-	"time"
+	"log/slog"
 
 	"movie-night/model"
-
-	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"movie-night/pkg/logging"
 )
 
 // MQTTClient MQTT 客户端封装
 type MQTTClient struct {
-	client mqtt.Client
-	topic  string
+	transport Transport
+	topic     string
+	recorder  *Recorder // 会话记录（可为空）
+	logger    *slog.Logger
 }
 
+// 子主题（挂在主 topic 下，如 video/control/reaction）
+const (
+	TopicReaction = "reaction" // 表情反应
+	TopicPresence = "presence" // 在线状态（presence/<id>）
+	TopicPolicy   = "policy"   // 房间控制策略
+	TopicRequest  = "request"  // 控制请求（参与者 -> 房主）
+	TopicVote     = "vote"     // 投票状态（房主 -> 所有人）
+	TopicHost     = "host"     // 房主声明
+	TopicCatchUp  = "catchup"  // 中途加入者的追赶进度（跟随端 -> 房主）
+	TopicSubtitle = "subtitle" // 房间字幕选择（房主 -> 所有人）
+	TopicPlayback = "playback" // 章节跳转、播放结束和切换播放列表项（房主 -> 所有人）
+	TopicChat     = "chat"     // 聊天消息
+)
+
 // MQTTConfig MQTT 配置
 type MQTTConfig struct {
 	Broker   string // MQTT Broker 地址
 	ClientID string // 客户端 ID
 	Topic    string // 订阅主题
-}
-
-// NewMQTTClient 创建 MQTT 客户端
-func NewMQTTClient(config MQTTConfig) (*MQTTClient, error) {
-	opts := mqtt.NewClientOptions()
-	opts.AddBroker(config.Broker)
-	opts.SetClientID(config.ClientID)
-	opts.SetCleanSession(false)
-	opts.SetKeepAlive(30 * time.Second)
-	opts.SetAutoReconnect(true)
-
-	opts.OnConnect = func(c mqtt.Client) {
-		fmt.Println("✅ MQTT 已连接")
-	}
 
-	opts.OnConnectionLost = func(c mqtt.Client, err error) {
-		fmt.Printf("❌ MQTT 连接丢失: %v\n", err)
-	}
+	// 遗嘱消息：连接异常断开时由 Broker 代发（保留消息）
+	WillSubtopic string
+	WillPayload  []byte
 
-	client := mqtt.NewClient(opts)
-	token := client.Connect()
-
-	if !token.WaitTimeout(5 * time.Second) {
-		return nil, fmt.Errorf("MQTT 连接超时")
-	}
+	Logger *slog.Logger // 日志（为空时使用 slog.Default()）
+}
 
-	if token.Error() != nil {
-		return nil, fmt.Errorf("MQTT 连接失败: %w", token.Error())
+// NewMQTTClient 连接 MQTT Broker 并创建客户端
+func NewMQTTClient(config MQTTConfig) (*MQTTClient, error) {
+	logger := logging.Component(config.Logger, "MQTT")
+	transport, err := dialMQTT(config, logger)
+	if err != nil {
+		return nil, err
 	}
 
 	return &MQTTClient{
-		client: client,
-		topic:  config.Topic,
+		transport: transport,
+		topic:     config.Topic,
+		logger:    logger,
 	}, nil
 }
 
+// NewMQTTClientWithTransport 在已有的连接上创建客户端（如测试用的 MemoryTransport）
+func NewMQTTClientWithTransport(transport Transport, topic string, logger *slog.Logger) *MQTTClient {
+	return &MQTTClient{
+		transport: transport,
+		topic:     topic,
+		logger:    logging.Component(logger, "MQTT"),
+	}
+}
+
 // Subscribe 订阅主题
 func (m *MQTTClient) Subscribe(handler func(model.PlayStatus)) error {
-	token := m.client.Subscribe(m.topic, 1, func(c mqtt.Client, msg mqtt.Message) {
+	err := m.transport.Subscribe(m.topic, func(topic string, payload []byte) {
 		var status model.PlayStatus
-		if err := json.Unmarshal(msg.Payload(), &status); err != nil {
-			fmt.Printf("❌ JSON 解析失败: %v\n", err)
+		if err := json.Unmarshal(payload, &status); err != nil {
+			m.logger.Error("JSON 解析失败", "err", err)
+			m.recorder.Received(topic, payload)
 			return
 		}
+		metricReceived.Inc()
+		m.recorder.ReceivedStatus(topic, payload, status)
 
 		// 调用处理函数
 		handler(status)
 	})
+	if err != nil {
+		return fmt.Errorf("订阅失败: %w", err)
+	}
+
+	m.logger.Info("已订阅", "topic", m.topic)
+	return nil
+}
 
-	token.Wait()
-	if token.Error() != nil {
-		return fmt.Errorf("订阅失败: %w", token.Error())
+// Unsubscribe 取消订阅播放状态
+func (m *MQTTClient) Unsubscribe() error {
+	return m.transport.Unsubscribe(m.topic)
+}
+
+// publishStatus 发布播放状态（保留消息，新加入的跟随端能立即收到）
+func (m *MQTTClient) publishStatus(status model.PlayStatus) error {
+	jsonData, err := json.Marshal(status)
+	if err != nil {
+		return fmt.Errorf("序列化失败: %w", err)
+	}
+	return m.publish(m.topic, jsonData, true)
+}
+
+// PublishTo 以 JSON 发布到子主题（<topic>/<subtopic>）
+func (m *MQTTClient) PublishTo(subtopic string, v interface{}, retained bool) error {
+	jsonData, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化失败: %w", err)
 	}
 
-	fmt.Printf("📡 已订阅: %s\n", m.topic)
+	return m.publish(m.subtopic(subtopic), jsonData, retained)
+}
+
+// publish 记录并发布
+func (m *MQTTClient) publish(topic string, payload []byte, retained bool) error {
+	m.recorder.Sent(topic, payload)
+	metricSent.Inc()
+	if err := m.transport.Publish(topic, payload, retained); err != nil {
+		return fmt.Errorf("发布失败: %w", err)
+	}
 	return nil
 }
 
+// SubscribeTo 订阅子主题，原始 payload 交给 handler 解析
+func (m *MQTTClient) SubscribeTo(subtopic string, handler func(payload []byte)) error {
+	topic := m.subtopic(subtopic)
+	err := m.transport.Subscribe(topic, func(topic string, payload []byte) {
+		m.recorder.Received(topic, payload)
+		metricReceived.Inc()
+		handler(payload)
+	})
+	if err != nil {
+		return fmt.Errorf("订阅失败: %w", err)
+	}
+
+	m.logger.Info("已订阅", "topic", topic)
+	return nil
+}
+
+// UnsubscribeFrom 取消订阅子主题
+func (m *MQTTClient) UnsubscribeFrom(subtopic string) error {
+	return m.transport.Unsubscribe(m.subtopic(subtopic))
+}
+
+// subtopic 拼接完整的子主题
+func (m *MQTTClient) subtopic(name string) string {
+	return m.topic + "/" + name
+}
+
 // Close 关闭连接
 func (m *MQTTClient) Close() {
-	if m.client != nil && m.client.IsConnected() {
-		m.client.Disconnect(250)
-	}
+	m.transport.Close()
 }
 
-func (m *MQTTClient) GetClient() mqtt.Client {
-	return m.client
+// SetRecorder 开启会话记录（需在订阅之前调用）
+func (m *MQTTClient) SetRecorder(recorder *Recorder) {
+	m.recorder = recorder
 }
 
 // GetTopic 获取主题
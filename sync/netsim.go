@@ -0,0 +1,569 @@
+package sync
+
+import (
+	"container/heap"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"time"
+
+	"movie-night/model"
+)
+
+// 网络模拟：虚拟时钟上的房主、N 个跟随端和一个有延迟、抖动、丢包和乱序的网络。
+// 房主和跟随端运行真实的 Controller / Follower / Syncer，只把 MPV 和 MQTT 换成模拟实现，
+// 用来在发布前比较不同网络条件和同步参数下的收敛时间与偏差
+
+// NetworkProfile 网络条件（每条消息、每个接收者独立抽样）
+type NetworkProfile struct {
+	Latency time.Duration // 单程延迟
+	Jitter  time.Duration // 延迟在 ±Jitter 内均匀抖动
+	Loss    float64       // 丢包率 (0-1)
+	Reorder float64       // 额外延迟一段时间的概率 (0-1)，让消息乱序到达
+}
+
+// PlayerProfile 播放器行为
+type PlayerProfile struct {
+	SeekStall  time.Duration // 跳转后缓冲的时间
+	StallEvery time.Duration // 平均每隔多久卡顿一次（0 表示不卡顿）
+	StallFor   time.Duration // 每次卡顿的时间
+}
+
+// SimAction 房主在某一时刻的操作
+type SimAction struct {
+	At       time.Duration
+	Action   model.ControlAction // pause / resume / seek / skip
+	Position float64             // seek 的目标位置或 skip 的秒数
+}
+
+// Scenario 一个模拟场景
+type Scenario struct {
+	Name      string
+	Followers int
+	Length    time.Duration // 模拟的时长
+	Interval  time.Duration // 房主定时广播的间隔
+	Tolerance float64       // 偏差不超过该值（秒）视为已同步
+	Network   NetworkProfile
+	Player    PlayerProfile
+	Script    []SimAction
+	Seed      int64
+	Logger    *slog.Logger // 模拟中各组件的日志（为空时使用 slog.Default()）
+}
+
+// SimResult 模拟结果
+type SimResult struct {
+	Scenario     string
+	ConvergeTime time.Duration // 开始和每次房主操作后，所有跟随端追上房主的最长时间
+	Missed       int           // 直到下一次操作都没有追上的次数
+	WorstDrift   float64       // 追上之后的最大偏差（秒）
+	Seeks        int           // 跟随端的跳转总次数
+	Sent         int           // 投递的消息数（每个接收者算一次）
+	Lost         int           // 丢掉的消息数
+}
+
+// String 一行摘要
+func (r SimResult) String() string {
+	return fmt.Sprintf("%-14s converge=%-6s missed=%d worst=%.2fs seeks=%d lost=%d/%d",
+		r.Scenario, r.ConvergeTime.Round(10*time.Millisecond), r.Missed, r.WorstDrift, r.Seeks, r.Lost, r.Sent)
+}
+
+// DefaultScenarios 常见网络条件（房主在中途跳转和暂停）
+func DefaultScenarios(followers int) []Scenario {
+	script := []SimAction{
+		{At: 30 * time.Second, Action: model.ActionSeek, Position: 600},
+		{At: 60 * time.Second, Action: model.ActionPause},
+		{At: 70 * time.Second, Action: model.ActionResume},
+		{At: 90 * time.Second, Action: model.ActionSkip, Position: 85},
+	}
+	base := Scenario{
+		Followers: followers,
+		Length:    2 * time.Minute,
+		Interval:  10 * time.Second,
+		Tolerance: 0.5,
+		Player:    PlayerProfile{SeekStall: 300 * time.Millisecond},
+		Script:    script,
+		Seed:      1,
+	}
+
+	lan := base
+	lan.Name = "lan"
+	lan.Network = NetworkProfile{Latency: 5 * time.Millisecond, Jitter: 2 * time.Millisecond}
+
+	wifi := base
+	wifi.Name = "wifi"
+	wifi.Network = NetworkProfile{Latency: 40 * time.Millisecond, Jitter: 30 * time.Millisecond}
+
+	far := base
+	far.Name = "intercontinent"
+	far.Network = NetworkProfile{Latency: 250 * time.Millisecond, Jitter: 50 * time.Millisecond}
+
+	lossy := base
+	lossy.Name = "lossy"
+	lossy.Network = NetworkProfile{Latency: 60 * time.Millisecond, Jitter: 40 * time.Millisecond, Loss: 0.2, Reorder: 0.1}
+
+	stalls := base
+	stalls.Name = "stalling"
+	stalls.Network = wifi.Network
+	stalls.Player = PlayerProfile{SeekStall: 1500 * time.Millisecond, StallEvery: 20 * time.Second, StallFor: 2 * time.Second}
+
+	return []Scenario{lan, wifi, far, lossy, stalls}
+}
+
+// 模拟器的采样间隔
+const (
+	simSampleInterval  = 100 * time.Millisecond // 房主 Monitor 上报和偏差测量的间隔
+	simConvergeHold    = 1 * time.Second        // 偏差需要保持在容差内的时间
+	simDuration        = 7200.0                 // 模拟视频的时长（秒）
+	simInitialMaxDrift = 5.0                    // 跟随端初始位置与房主的最大差距（秒）
+)
+
+// RunScenario 运行一个场景
+func RunScenario(sc Scenario) SimResult {
+	sim := newSimulation(sc)
+	return sim.run()
+}
+
+// simEvent 在某个虚拟时刻执行的动作
+type simEvent struct {
+	at  time.Time
+	seq int // 同一时刻按加入顺序执行
+	fn  func()
+}
+
+// simQueue 按时间排序的事件队列
+type simQueue []*simEvent
+
+func (q simQueue) Len() int { return len(q) }
+func (q simQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+func (q simQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *simQueue) Push(x interface{}) { *q = append(*q, x.(*simEvent)) }
+func (q *simQueue) Pop() interface{} {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+// simulation 一次模拟的状态
+type simulation struct {
+	sc    Scenario
+	clock *VirtualClock
+	rng   *rand.Rand
+	start time.Time
+	queue simQueue
+	seq   int
+
+	network   *simNetwork
+	host      *simPlayer
+	followers []*simPlayer
+
+	disturbances []time.Time  // 开始和每次房主操作的时间
+	samples      [][]simDrift // 每段扰动之后的偏差采样
+}
+
+// simDrift 一次偏差采样
+type simDrift struct {
+	at    time.Time
+	drift float64 // 所有跟随端中最大的偏差
+}
+
+func newSimulation(sc Scenario) *simulation {
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	sim := &simulation{
+		sc:    sc,
+		clock: NewVirtualClock(start),
+		rng:   rand.New(rand.NewSource(sc.Seed)),
+		start: start,
+	}
+	sim.network = &simNetwork{sim: sim, profile: sc.Network, retained: make(map[string][]byte)}
+	return sim
+}
+
+// at 在 start 之后 d 执行 fn
+func (s *simulation) at(d time.Duration, fn func()) {
+	s.schedule(s.start.Add(d), fn)
+}
+
+// after 在当前时间之后 d 执行 fn
+func (s *simulation) after(d time.Duration, fn func()) {
+	s.schedule(s.clock.Now().Add(d), fn)
+}
+
+func (s *simulation) schedule(t time.Time, fn func()) {
+	s.seq++
+	heap.Push(&s.queue, &simEvent{at: t, seq: s.seq, fn: fn})
+}
+
+// every 从 start 开始每隔 d 执行 fn
+func (s *simulation) every(d time.Duration, fn func()) {
+	var tick func()
+	tick = func() {
+		fn()
+		s.after(d, tick)
+	}
+	s.at(0, tick)
+}
+
+// run 搭建房主和跟随端，处理事件直到场景结束
+func (s *simulation) run() SimResult {
+	const topic = "sim/control"
+
+	// 房主：模拟播放器 + 真实的 Controller
+	s.host = newSimPlayer(s, 0)
+	controller := NewController(NewMQTTClientWithTransport(s.network.connect(), topic, s.sc.Logger), nil, s.sc.Interval, s.sc.Logger)
+	controller.clock = s.clock
+	controller.SetHost("host", 1)
+
+	// 跟随端：模拟播放器 + 真实的 Follower / Syncer，收到状态后立即处理（代替 processLoop）
+	for i := 0; i < s.sc.Followers; i++ {
+		player := newSimPlayer(s, s.rng.Float64()*simInitialMaxDrift)
+		s.followers = append(s.followers, player)
+
+		follower := NewFollower(player, NewMQTTClientWithTransport(s.network.connect(), topic, s.sc.Logger), simDuration, s.sc.Logger)
+		follower.syncer.clock = s.clock
+		follower.mqttClient.Subscribe(func(status model.PlayStatus) {
+			follower.handleStatus(status)
+			follower.syncer.flush()
+		})
+	}
+
+	// 房主的 Monitor 上报和定时广播
+	s.every(simSampleInterval, func() { controller.sample(s.host.status()) })
+	s.every(s.sc.Interval, func() {
+		if !controller.sampledAt.IsZero() {
+			controller.broadcast(controller.current)
+		}
+	})
+
+	// 房主操作
+	s.disturb()
+	for _, action := range s.sc.Script {
+		action := action
+		s.at(action.At, func() {
+			s.host.apply(action)
+			s.disturb()
+		})
+	}
+
+	// 卡顿
+	if s.sc.Player.StallEvery > 0 {
+		for _, p := range append([]*simPlayer{s.host}, s.followers...) {
+			s.scheduleStall(p)
+		}
+	}
+
+	// 偏差测量
+	s.every(simSampleInterval, s.measure)
+
+	end := s.start.Add(s.sc.Length)
+	for s.queue.Len() > 0 {
+		e := heap.Pop(&s.queue).(*simEvent)
+		if e.at.After(end) {
+			break
+		}
+		s.clock.AdvanceTo(e.at)
+		e.fn()
+	}
+
+	return s.result()
+}
+
+// disturb 开始新的一段扰动
+func (s *simulation) disturb() {
+	s.disturbances = append(s.disturbances, s.clock.Now())
+	s.samples = append(s.samples, nil)
+}
+
+// scheduleStall 按指数分布安排下一次卡顿
+func (s *simulation) scheduleStall(p *simPlayer) {
+	wait := time.Duration(s.rng.ExpFloat64() * float64(s.sc.Player.StallEvery))
+	s.after(wait, func() {
+		p.stall(s.sc.Player.StallFor)
+		s.scheduleStall(p)
+	})
+}
+
+// measure 记录所有跟随端与房主的最大偏差
+func (s *simulation) measure() {
+	host := s.host.position()
+	var worst float64
+	for _, p := range s.followers {
+		worst = math.Max(worst, math.Abs(p.position()-host))
+	}
+	last := len(s.samples) - 1
+	s.samples[last] = append(s.samples[last], simDrift{at: s.clock.Now(), drift: worst})
+}
+
+// result 计算每段扰动的收敛时间和收敛后的最大偏差
+func (s *simulation) result() SimResult {
+	r := SimResult{Scenario: s.sc.Name, Sent: s.network.sent, Lost: s.network.lost}
+	for _, p := range s.followers {
+		r.Seeks += p.seeks
+	}
+
+	hold := int(simConvergeHold / simSampleInterval)
+	for k, samples := range s.samples {
+		converged := -1
+		for i := 0; i+hold <= len(samples); i++ {
+			ok := true
+			for _, sample := range samples[i : i+hold] {
+				if sample.drift > s.sc.Tolerance {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				converged = i
+				break
+			}
+		}
+
+		if converged < 0 {
+			r.Missed++
+			continue
+		}
+		if d := samples[converged].at.Sub(s.disturbances[k]); d > r.ConvergeTime {
+			r.ConvergeTime = d
+		}
+		for _, sample := range samples[converged:] {
+			r.WorstDrift = math.Max(r.WorstDrift, sample.drift)
+		}
+	}
+	return r
+}
+
+// simNetwork 有延迟、抖动、丢包和乱序的网络，行为与 MemoryBroker 相同
+type simNetwork struct {
+	sim      *simulation
+	profile  NetworkProfile
+	retained map[string][]byte
+	peers    []*simTransport
+
+	sent, lost int
+}
+
+// connect 创建一个连接
+func (n *simNetwork) connect() *simTransport {
+	t := &simTransport{network: n, subs: make(map[string]func(topic string, payload []byte))}
+	n.peers = append(n.peers, t)
+	return t
+}
+
+// deliver 按网络条件把消息延迟投递给 t（或丢掉）
+func (n *simNetwork) deliver(t *simTransport, topic string, payload []byte) {
+	n.sent++
+	rng := n.sim.rng
+	if rng.Float64() < n.profile.Loss {
+		n.lost++
+		return
+	}
+
+	delay := n.profile.Latency
+	if n.profile.Jitter > 0 {
+		delay += time.Duration((rng.Float64()*2 - 1) * float64(n.profile.Jitter))
+	}
+	if rng.Float64() < n.profile.Reorder {
+		delay += n.profile.Latency + 2*n.profile.Jitter
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	n.sim.after(delay, func() { t.receive(topic, payload) })
+}
+
+// simTransport simNetwork 上的连接（实现 Transport）
+type simTransport struct {
+	network *simNetwork
+	subs    map[string]func(topic string, payload []byte)
+}
+
+// Publish 发布消息
+func (t *simTransport) Publish(topic string, payload []byte, retained bool) error {
+	payload = append([]byte(nil), payload...)
+	if retained {
+		t.network.retained[topic] = payload
+	}
+	for _, peer := range t.network.peers {
+		if peer.subscribed(topic) {
+			t.network.deliver(peer, topic, payload)
+		}
+	}
+	return nil
+}
+
+// Subscribe 订阅主题（匹配的保留消息同样经过网络投递）
+func (t *simTransport) Subscribe(topic string, handler func(topic string, payload []byte)) error {
+	t.subs[topic] = handler
+	for retainedTopic, payload := range t.network.retained {
+		if topicMatches(topic, retainedTopic) {
+			t.network.deliver(t, retainedTopic, payload)
+		}
+	}
+	return nil
+}
+
+// Unsubscribe 取消订阅
+func (t *simTransport) Unsubscribe(topic string) error {
+	delete(t.subs, topic)
+	return nil
+}
+
+// Close 断开
+func (t *simTransport) Close() {}
+
+func (t *simTransport) subscribed(topic string) bool {
+	for filter := range t.subs {
+		if topicMatches(filter, topic) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *simTransport) receive(topic string, payload []byte) {
+	for filter, handler := range t.subs {
+		if topicMatches(filter, topic) {
+			handler(topic, payload)
+		}
+	}
+}
+
+// simPlayer 模拟的 MPV（实现 Player）：播放时 time-pos 随虚拟时间前进，
+// 跳转后和随机卡顿时停下来缓冲
+type simPlayer struct {
+	sim *simulation
+
+	pos        float64   // at 时刻的位置
+	at         time.Time // pos 的时间
+	paused     bool
+	speed      float64
+	stallUntil time.Time // 缓冲结束的时间
+	seeks      int
+}
+
+func newSimPlayer(sim *simulation, pos float64) *simPlayer {
+	return &simPlayer{sim: sim, pos: pos, at: sim.clock.Now(), speed: 1}
+}
+
+// advance 把位置推进到当前虚拟时间
+func (p *simPlayer) advance() {
+	now := p.sim.clock.Now()
+	if !p.paused {
+		from := p.at
+		if p.stallUntil.After(from) {
+			from = p.stallUntil
+		}
+		if now.After(from) {
+			p.pos = math.Min(p.pos+now.Sub(from).Seconds()*p.speed, simDuration)
+		}
+	}
+	p.at = now
+}
+
+// position 当前位置
+func (p *simPlayer) position() float64 {
+	p.advance()
+	return p.pos
+}
+
+// status 当前播放状态（Monitor 上报的内容）
+func (p *simPlayer) status() model.PlayStatus {
+	p.advance()
+	return model.PlayStatus{Timestamp: p.pos, Paused: p.paused, Speed: p.speed, Chapter: -1}
+}
+
+// stall 缓冲 d
+func (p *simPlayer) stall(d time.Duration) {
+	p.advance()
+	if until := p.sim.clock.Now().Add(d); until.After(p.stallUntil) {
+		p.stallUntil = until
+	}
+}
+
+// apply 执行房主操作
+func (p *simPlayer) apply(action SimAction) {
+	switch action.Action {
+	case model.ActionPause:
+		p.Pause()
+	case model.ActionResume:
+		p.Play()
+	case model.ActionSeek:
+		p.Seek(action.Position, "absolute")
+	case model.ActionSkip:
+		p.Seek(action.Position, "relative")
+	}
+}
+
+func (p *simPlayer) Seek(seconds float64, mode string) error {
+	p.advance()
+	if mode != "absolute" {
+		seconds += p.pos
+	}
+	p.pos = math.Max(0, math.Min(seconds, simDuration))
+	p.seeks++
+	p.stall(p.sim.sc.Player.SeekStall)
+	return nil
+}
+
+func (p *simPlayer) Pause() error {
+	p.advance()
+	p.paused = true
+	return nil
+}
+
+func (p *simPlayer) Play() error {
+	p.advance()
+	p.paused = false
+	return nil
+}
+
+func (p *simPlayer) SetSpeed(speed float64) error {
+	p.advance()
+	p.speed = speed
+	return nil
+}
+
+func (p *simPlayer) SetProperty(name string, value interface{}) error {
+	switch name {
+	case "pause":
+		if paused, _ := value.(bool); paused {
+			return p.Pause()
+		}
+		return p.Play()
+	case "speed":
+		if speed, ok := value.(float64); ok {
+			return p.SetSpeed(speed)
+		}
+	}
+	return nil
+}
+
+func (p *simPlayer) GetProperty(name string) (interface{}, error) {
+	switch name {
+	case "time-pos":
+		return p.position(), nil
+	case "playlist-pos":
+		return 0.0, nil
+	case "duration":
+		return simDuration, nil
+	}
+	return nil, fmt.Errorf("property unavailable")
+}
+
+func (p *simPlayer) GetDuration() (float64, error)             { return simDuration, nil }
+func (p *simPlayer) GetChapters() ([]model.Chapter, error)     { return nil, nil }
+func (p *simPlayer) GetPlaylistCount() (int, error)            { return 1, nil }
+func (p *simPlayer) SetChapter(index int) error                { return nil }
+func (p *simPlayer) SetPlaylistPos(index int) error            { return nil }
+func (p *simPlayer) PlaylistNext() error                       { return nil }
+func (p *simPlayer) SetAudioTrack(id string) error             { return nil }
+func (p *simPlayer) ShowText(text string, duration int) error  { return nil }
+func (p *simPlayer) DrawIntermissionOverlay(string, int) error { return nil }
+func (p *simPlayer) ClearIntermissionOverlay() error           { return nil }
@@ -0,0 +1,28 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSimulationConverges(t *testing.T) {
+	for _, sc := range DefaultScenarios(3) {
+		if sc.Name != "lan" && sc.Name != "wifi" {
+			continue
+		}
+		r := RunScenario(sc)
+		if r.Missed > 0 || r.ConvergeTime > 3*time.Second {
+			t.Errorf("%s: did not converge in time: %v", sc.Name, r)
+		}
+		if r.WorstDrift > 1 {
+			t.Errorf("%s: drift after converging too large: %v", sc.Name, r)
+		}
+	}
+}
+
+func TestSimulationDeterministic(t *testing.T) {
+	sc := DefaultScenarios(3)[3] // lossy
+	if a, b := RunScenario(sc), RunScenario(sc); a != b {
+		t.Errorf("same seed, different results:\n%v\n%v", a, b)
+	}
+}
@@ -0,0 +1,300 @@
+package sync
+
+import (
+	"log/slog"
+	"math"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+)
+
+// chapterJumpWindow 章节变化和位置跳变相隔不超过该时间时视为跳到了章节（MPV 分别通知两个属性）
+const chapterJumpWindow = 1 * time.Second
+
+// intermissionLinger 倒计时结束后等待切换的最长时间，超时后清除提示
+const intermissionLinger = 10 * time.Second
+
+// Intermission 幕间倒计时提示（房主和跟随端按同一截止时间显示）
+type Intermission struct {
+	mpvCtrl Player
+
+	mu   gosync.Mutex
+	stop chan struct{}
+	gen  int // 每次 Show/Hide 加一，只有当前这一次可以绘制和清除提示
+}
+
+// NewIntermission 创建幕间提示
+func NewIntermission(mpvCtrl Player) *Intermission {
+	return &Intermission{mpvCtrl: mpvCtrl}
+}
+
+// Show 显示幕间倒计时；没有下一项时显示结束
+func (i *Intermission) Show(hasNext bool, deadline time.Time) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.stop != nil {
+		close(i.stop)
+	}
+	i.stop = make(chan struct{})
+	i.gen++
+	stop, gen := i.stop, i.gen
+
+	go func() {
+		// 被新的 Show 取代时不清除，避免擦掉新的提示
+		defer i.clear(gen)
+
+		if !hasNext {
+			i.draw(gen, "The End", 0)
+			select {
+			case <-stop:
+			case <-time.After(intermissionLinger):
+			}
+			return
+		}
+
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		giveUp := deadline.Add(intermissionLinger)
+
+		for {
+			remaining := int(math.Ceil(time.Until(deadline).Seconds()))
+			if remaining > 0 {
+				i.draw(gen, "Intermission", remaining)
+			} else {
+				i.draw(gen, "Up next…", 0)
+			}
+
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if now.After(giveUp) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Hide 清除幕间提示
+func (i *Intermission) Hide() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.stop != nil {
+		close(i.stop)
+		i.stop = nil
+		i.gen++
+		i.mpvCtrl.ClearIntermissionOverlay()
+	}
+}
+
+// draw 第 gen 次 Show 仍然有效时绘制提示
+func (i *Intermission) draw(gen int, title string, remaining int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if gen == i.gen {
+		i.mpvCtrl.DrawIntermissionOverlay(title, remaining)
+	}
+}
+
+// clear 第 gen 次 Show 仍然有效时清除提示
+func (i *Intermission) clear(gen int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if gen == i.gen {
+		i.mpvCtrl.ClearIntermissionOverlay()
+	}
+}
+
+// PlaybackHost 房主端：把章节跳转、播放结束和切换播放列表项广播给所有人，并在幕间结束后切到下一项
+type PlaybackHost struct {
+	mqttClient   *MQTTClient
+	mpvCtrl      Player
+	statusCh     <-chan model.PlayStatus
+	intermission time.Duration
+	overlay      *Intermission
+	stopCh       chan struct{}
+
+	hostID string
+	term   int
+
+	mu     gosync.Mutex
+	timer  *time.Timer // 幕间结束后切到下一项
+	logger *slog.Logger
+}
+
+// NewPlaybackHost 创建播放事件广播
+// statusCh 提供本地播放状态，一般来自 Monitor.Subscribe()
+func NewPlaybackHost(mqttClient *MQTTClient, mpvCtrl Player, statusCh <-chan model.PlayStatus, intermission time.Duration, logger *slog.Logger) *PlaybackHost {
+	return &PlaybackHost{
+		logger:       logging.Component(logger, "Playback"),
+		mqttClient:   mqttClient,
+		mpvCtrl:      mpvCtrl,
+		statusCh:     statusCh,
+		intermission: intermission,
+		overlay:      NewIntermission(mpvCtrl),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// SetHost 设置事件中携带的房主身份（需在 Start 之前调用）
+func (p *PlaybackHost) SetHost(id string, term int) {
+	p.hostID = id
+	p.term = term
+}
+
+// Start 开始监听本地播放状态
+func (p *PlaybackHost) Start() {
+	go p.loop()
+}
+
+// loop 比较前后两次状态，找出需要广播的事件
+func (p *PlaybackHost) loop() {
+	var prev model.PlayStatus
+	var sampledAt, jumpAt, chapterAt time.Time
+
+	for {
+		var status model.PlayStatus
+		select {
+		case <-p.stopCh:
+			return
+		case status = <-p.statusCh:
+		}
+		now := time.Now()
+
+		if sampledAt.IsZero() {
+			prev, sampledAt = status, now
+			continue
+		}
+
+		switch {
+		case status.Item != prev.Item:
+			p.cancelIntermission(false)
+			jumpAt, chapterAt = time.Time{}, time.Time{}
+			p.publish(model.PlaybackEvent{Kind: model.EventNext, Item: status.Item})
+
+		default:
+			expected := prev.Timestamp
+			if !prev.Paused {
+				expected += now.Sub(sampledAt).Seconds() * prev.Rate()
+			}
+			if math.Abs(status.Timestamp-expected) > seekJumpThreshold {
+				jumpAt = now
+			}
+			if status.Chapter != prev.Chapter && status.Chapter >= 0 {
+				chapterAt = now
+			}
+
+			// 章节变化伴随位置跳变才是跳章节，正常播放跨过章节边界不算
+			if !jumpAt.IsZero() && !chapterAt.IsZero() {
+				if d := jumpAt.Sub(chapterAt); d < chapterJumpWindow && d > -chapterJumpWindow {
+					p.publish(model.PlaybackEvent{
+						Kind:     model.EventChapter,
+						Item:     status.Item,
+						Chapter:  status.Chapter,
+						Position: status.Timestamp,
+					})
+					jumpAt, chapterAt = time.Time{}, time.Time{}
+				}
+			}
+			if !jumpAt.IsZero() && now.Sub(jumpAt) > chapterJumpWindow {
+				jumpAt = time.Time{}
+			}
+			if !chapterAt.IsZero() && now.Sub(chapterAt) > chapterJumpWindow {
+				chapterAt = time.Time{}
+			}
+
+			if status.EOF && !prev.EOF {
+				p.startIntermission(status.Item)
+			} else if !status.EOF && prev.EOF {
+				p.cancelIntermission(true)
+			}
+		}
+
+		prev, sampledAt = status, now
+	}
+}
+
+// startIntermission 播放结束：有下一项时开始幕间倒计时
+func (p *PlaybackHost) startIntermission(item int) {
+	count, err := p.mpvCtrl.GetPlaylistCount()
+	if err != nil {
+		p.logger.Warn("无法获取播放列表", "err", err)
+	}
+	hasNext := item+1 < count
+	deadline := time.Now().Add(p.intermission)
+
+	event := model.PlaybackEvent{Kind: model.EventEOF, Item: item, HasNext: hasNext}
+	if hasNext {
+		event.Deadline = deadline.UnixMilli()
+		p.logger.Info("播放结束，幕间后播放下一项", "item", item+1, "intermission", p.intermission)
+
+		p.mu.Lock()
+		if p.timer != nil {
+			p.timer.Stop()
+		}
+		p.timer = time.AfterFunc(p.intermission, p.next)
+		p.mu.Unlock()
+	} else {
+		p.logger.Info("播放列表已结束")
+	}
+
+	p.overlay.Show(hasNext, deadline)
+	p.publish(event)
+}
+
+// cancelIntermission 取消幕间倒计时；announce 为 true 时通知所有人（房主跳回了视频）
+func (p *PlaybackHost) cancelIntermission(announce bool) {
+	p.mu.Lock()
+	active := p.timer != nil
+	if active {
+		p.timer.Stop()
+		p.timer = nil
+	}
+	p.mu.Unlock()
+
+	p.overlay.Hide()
+	if announce && active {
+		p.logger.Info("幕间已取消")
+		p.publish(model.PlaybackEvent{Kind: model.EventIntermissionCancel})
+	}
+}
+
+// next 幕间结束，切到下一项并继续播放
+func (p *PlaybackHost) next() {
+	p.mu.Lock()
+	p.timer = nil
+	p.mu.Unlock()
+
+	if err := p.mpvCtrl.PlaylistNext(); err != nil {
+		p.logger.Error("切换失败", "err", err)
+		return
+	}
+	// 播放结束时 MPV 会暂停，暂停状态会带到下一项
+	p.mpvCtrl.Play()
+}
+
+// publish 广播播放事件
+func (p *PlaybackHost) publish(event model.PlaybackEvent) {
+	event.Host = p.hostID
+	event.Term = p.term
+	event.SentAt = time.Now().UnixMilli()
+
+	if err := p.mqttClient.PublishTo(TopicPlayback, event, false); err != nil {
+		p.logger.Error("广播失败", "err", err)
+		return
+	}
+	p.logger.Debug("广播播放事件", "kind", event.Kind, "item", event.Item+1)
+}
+
+// Stop 停止广播（不再是房主时）
+func (p *PlaybackHost) Stop() {
+	close(p.stopCh)
+	p.cancelIntermission(false)
+}
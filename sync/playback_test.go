@@ -0,0 +1,36 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntermissionKeepsNewerOverlay(t *testing.T) {
+	viewer := newPeer(t, NewMemoryBroker(), "viewer", 600)
+	overlay := NewIntermission(viewer.ctrl)
+
+	// 上一项的结束提示被下一次倒计时取代时，不能擦掉新的提示
+	overlay.Show(false, time.Now())
+	if _, err := viewer.server.WaitCommand("osd-overlay", time.Second); err != nil {
+		t.Fatal(err)
+	}
+	overlay.Show(true, time.Now().Add(time.Minute))
+
+	var last []interface{}
+	for {
+		cmd, err := viewer.server.WaitCommand("osd-overlay", 300*time.Millisecond)
+		if err != nil {
+			break
+		}
+		last = cmd
+	}
+	if last == nil || last[3] == "" {
+		t.Fatalf("countdown overlay was cleared by the previous show: %v", last)
+	}
+
+	overlay.Hide()
+	cmd, err := viewer.server.WaitCommand("osd-overlay", time.Second)
+	if err != nil || cmd[3] != "" {
+		t.Errorf("Hide should clear the overlay, got %v (%v)", cmd, err)
+	}
+}
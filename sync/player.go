@@ -0,0 +1,38 @@
+package sync
+
+import (
+	"movie-night/model"
+)
+
+// Player 同步需要的播放器控制（*mpv.Controller 实现，测试中可连接 mpvtest.Server）
+type Player interface {
+	Seek(seconds float64, mode string) error
+	Pause() error
+	Play() error
+	ShowText(text string, duration int) error
+
+	GetProperty(name string) (interface{}, error)
+	SetProperty(name string, value interface{}) error
+	SetSpeed(speed float64) error
+	SetAudioTrack(id string) error
+
+	GetDuration() (float64, error)
+	GetChapters() ([]model.Chapter, error)
+	SetChapter(index int) error
+	GetPlaylistCount() (int, error)
+	SetPlaylistPos(index int) error
+	PlaylistNext() error
+
+	DrawIntermissionOverlay(title string, remaining int) error
+	ClearIntermissionOverlay() error
+}
+
+// StatusSource 本地播放状态来源（*mpv.Monitor 实现）
+type StatusSource interface {
+	// GetStatusChannel 主状态 channel（只有一个消费者）
+	GetStatusChannel() <-chan model.PlayStatus
+	// Subscribe 额外订阅一份状态（只保留最新）
+	Subscribe() <-chan model.PlayStatus
+	// Unsubscribe 取消额外的订阅
+	Unsubscribe(ch <-chan model.PlayStatus)
+}
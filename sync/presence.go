@@ -0,0 +1,264 @@
+package sync
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sort"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+)
+
+const (
+	presenceInterval = 5 * time.Second  // 心跳间隔
+	presenceTimeout  = 15 * time.Second // 超过该时间没有心跳视为离线
+)
+
+// Presence 房间在线状态：定期发布自己的心跳，并维护参与者列表
+type Presence struct {
+	mqttClient *MQTTClient
+	self       model.Participant
+
+	mu           gosync.Mutex
+	participants map[string]model.Participant
+	seenAt       map[string]time.Time // 本地收到心跳的时间
+	heard        map[string]bool      // 收到过消息的参与者
+	stopCh       chan struct{}
+	onPeers      func(addrs []string) // 参与者的 BitTorrent 地址出现或变化时的回调
+	logger       *slog.Logger
+}
+
+// NewPresence 创建在线状态
+func NewPresence(mqttClient *MQTTClient, id, name, role string, logger *slog.Logger) *Presence {
+	return &Presence{
+		logger:     logging.Component(logger, "Presence"),
+		mqttClient: mqttClient,
+		self: model.Participant{
+			ID:       id,
+			Name:     name,
+			Role:     role,
+			JoinedAt: time.Now().UnixMilli(),
+		},
+		participants: make(map[string]model.Participant),
+		seenAt:       make(map[string]time.Time),
+		heard:        make(map[string]bool),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Start 订阅在线状态并开始心跳
+func (p *Presence) Start() error {
+	if err := p.mqttClient.SubscribeTo(TopicPresence+"/+", p.handlePayload); err != nil {
+		return err
+	}
+
+	p.heartbeat()
+	go func() {
+		ticker := time.NewTicker(presenceInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-p.stopCh:
+				return
+			case <-ticker.C:
+				p.heartbeat()
+			}
+		}
+	}()
+	return nil
+}
+
+// LeaveMessage 离开通知的内容，用作 MQTT 遗嘱（进程崩溃时其他人能立刻知道）
+func LeaveMessage(id, name string) (subtopic string, payload []byte) {
+	payload, _ = json.Marshal(model.Participant{ID: id, Name: name, Left: true})
+	return TopicPresence + "/" + id, payload
+}
+
+// SetRole 更新自己的角色（换房主时）并立即发送心跳
+func (p *Presence) SetRole(role string) {
+	p.mu.Lock()
+	p.self.Role = role
+	p.mu.Unlock()
+
+	p.heartbeat()
+}
+
+// ToggleReady 切换自己的准备状态并立即发送心跳，返回新的状态
+func (p *Presence) ToggleReady() bool {
+	p.mu.Lock()
+	p.self.Ready = !p.self.Ready
+	ready := p.self.Ready
+	p.mu.Unlock()
+
+	p.heartbeat()
+	return ready
+}
+
+// Contains 参与者是否在线
+func (p *Presence) Contains(id string) bool {
+	for _, participant := range p.Participants() {
+		if participant.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// SetDrift 更新自己最近测得的偏差（随下一次心跳发布）
+func (p *Presence) SetDrift(drift float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.self.Drift = &drift
+}
+
+// SetHealth 分享种子健康检查的结果并立即发送心跳
+func (p *Presence) SetHealth(health model.SwarmHealth) {
+	p.mu.Lock()
+	p.self.Health = &health
+	p.mu.Unlock()
+
+	p.heartbeat()
+}
+
+// ClearDrift 不再报告偏差（成为房主时）
+func (p *Presence) ClearDrift() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.self.Drift = nil
+}
+
+// SetPeerExchange 在心跳中发布自己的 BitTorrent 地址，并在其他参与者的地址出现或变化时回调 onPeers
+// （需在 Start 之前调用）
+func (p *Presence) SetPeerExchange(addrs []string, onPeers func(addrs []string)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.self.PeerAddrs = addrs
+	p.onPeers = onPeers
+}
+
+// Self 返回自己的参与者信息
+func (p *Presence) Self() model.Participant {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.self
+}
+
+// heartbeat 发布心跳（保留消息，新加入的人能立即看到）
+func (p *Presence) heartbeat() {
+	p.mu.Lock()
+	p.self.LastSeen = time.Now().UnixMilli()
+	self := p.self
+	p.mu.Unlock()
+
+	if err := p.mqttClient.PublishTo(TopicPresence+"/"+self.ID, self, true); err != nil {
+		p.logger.Warn("心跳发送失败", "err", err)
+	}
+}
+
+// handlePayload 处理参与者心跳
+func (p *Presence) handlePayload(payload []byte) {
+	var participant model.Participant
+	if err := json.Unmarshal(payload, &participant); err != nil || participant.ID == "" {
+		return
+	}
+
+	p.mu.Lock()
+	previous, known := p.participants[participant.ID]
+	online := p.updateLocked(participant, known)
+	onPeers, self := p.onPeers, p.self.ID
+	p.mu.Unlock()
+
+	// 新参与者或地址有变化时交给 BitTorrent 客户端直连
+	if online && onPeers != nil && participant.ID != self && len(participant.PeerAddrs) > 0 &&
+		(!known || !slices.Equal(previous.PeerAddrs, participant.PeerAddrs)) {
+		onPeers(participant.PeerAddrs)
+	}
+}
+
+// updateLocked 记录心跳或离开通知，返回参与者是否在线（调用方需持有锁）
+func (p *Presence) updateLocked(participant model.Participant, known bool) bool {
+	// 订阅时 Broker 先送来每个参与者的保留消息，只有每人的第一条可能是早已失效的旧心跳，用发送方的时间戳过滤
+	// （离开通知可能来自遗嘱，时间戳是连接时的，不过滤）。之后的心跳按本地收到的时间计算是否在线，不受对方时钟偏差影响
+	first := !p.heard[participant.ID]
+	p.heard[participant.ID] = true
+	if first && !participant.Left && time.Since(time.UnixMilli(participant.LastSeen)) > presenceTimeout {
+		return false
+	}
+
+	if participant.Left {
+		if known {
+			delete(p.participants, participant.ID)
+			delete(p.seenAt, participant.ID)
+			p.logger.Info("离开了房间", "name", participant.Name)
+		}
+		return false
+	}
+
+	if !known {
+		p.logger.Info("加入了房间", "name", participant.Name)
+	}
+	p.participants[participant.ID] = participant
+	p.seenAt[participant.ID] = time.Now()
+	return true
+}
+
+// Participants 当前在线的参与者（按加入顺序）
+func (p *Presence) Participants() []model.Participant {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pruneLocked()
+
+	list := make([]model.Participant, 0, len(p.participants))
+	for _, participant := range p.participants {
+		list = append(list, participant)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].JoinedAt != list[j].JoinedAt {
+			return list[i].JoinedAt < list[j].JoinedAt
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+// Count 在线人数（至少包含自己）
+func (p *Presence) Count() int {
+	if n := len(p.Participants()); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// pruneLocked 移除心跳超时的参与者（调用方需持有锁）
+func (p *Presence) pruneLocked() {
+	for id, seen := range p.seenAt {
+		if id == p.self.ID {
+			continue
+		}
+		if time.Since(seen) > presenceTimeout {
+			p.logger.Info("心跳超时", "name", p.participants[id].Name)
+			delete(p.participants, id)
+			delete(p.seenAt, id)
+		}
+	}
+}
+
+// Leave 停止心跳并通知其他人自己已离开
+func (p *Presence) Leave() {
+	close(p.stopCh)
+
+	p.mu.Lock()
+	p.self.Left = true
+	p.self.LastSeen = time.Now().UnixMilli()
+	self := p.self
+	p.mu.Unlock()
+
+	if err := p.mqttClient.PublishTo(TopicPresence+"/"+self.ID, self, true); err != nil {
+		p.logger.Warn("离开通知发送失败", "err", err)
+	}
+}
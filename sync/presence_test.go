@@ -0,0 +1,68 @@
+package sync
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"movie-night/model"
+)
+
+func TestPresencePeerExchange(t *testing.T) {
+	broker := NewMemoryBroker()
+	got := make(chan []string, 4)
+
+	alice := NewPresence(NewMQTTClientWithTransport(broker.Connect(), "video/control", nil), "alice", "Alice", model.RoleHost, nil)
+	alice.SetPeerExchange([]string{"10.0.0.1:42069"}, func(addrs []string) {
+		t.Errorf("alice should not be told about herself or address-less peers: %v", addrs)
+	})
+	if err := alice.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer alice.Leave()
+
+	bob := NewPresence(NewMQTTClientWithTransport(broker.Connect(), "video/control", nil), "bob", "Bob", model.RoleFollower, nil)
+	bob.SetPeerExchange(nil, func(addrs []string) { got <- addrs })
+	if err := bob.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer bob.Leave()
+
+	select {
+	case addrs := <-got:
+		if want := []string{"10.0.0.1:42069"}; !reflect.DeepEqual(addrs, want) {
+			t.Errorf("got %v, want %v", addrs, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("bob never learned alice's address")
+	}
+
+	// 地址不变的心跳不再回调
+	alice.heartbeat()
+	select {
+	case addrs := <-got:
+		t.Errorf("unexpected callback for unchanged addresses: %v", addrs)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPresenceClockSkew(t *testing.T) {
+	presence := NewPresence(nil, "alice", "Alice", model.RoleFollower, nil)
+	heartbeat := func(id string, lastSeen time.Time) {
+		payload, _ := json.Marshal(model.Participant{ID: id, Name: id, LastSeen: lastSeen.UnixMilli()})
+		presence.handlePayload(payload)
+	}
+
+	// 订阅时收到的第一条是早已失效的保留消息：忽略
+	heartbeat("carol", time.Now().Add(-time.Hour))
+	if presence.Contains("carol") {
+		t.Error("stale retained heartbeat should be ignored")
+	}
+
+	// 时钟慢了一小时的参与者：之后的实时心跳按本地收到的时间算在线
+	heartbeat("carol", time.Now().Add(-time.Hour))
+	if !presence.Contains("carol") {
+		t.Error("live heartbeat from a peer with a slow clock should count as online")
+	}
+}
@@ -0,0 +1,304 @@
+package sync
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+	"movie-night/pkg/mpv"
+)
+
+const (
+	reactionLiveWindow = 3.0 // 落后发送位置多少秒以内仍立即显示
+	reactionSeekJump   = 5.0 // 位置跳变超过该值视为跳转，不回放中间的反应
+)
+
+// reactionEmoji 反应类型对应的表情
+var reactionEmoji = map[model.ReactionKind]string{
+	model.ReactionLaugh:    "😂",
+	model.ReactionShock:    "😱",
+	model.ReactionApplause: "👏",
+}
+
+// ReactionTimeline 会话内的反应时间轴
+// 按播放位置排序，并追加写入 JSONL 文件，重看时可以回放
+type ReactionTimeline struct {
+	mu        gosync.Mutex
+	reactions []model.Reaction
+	file      *os.File
+}
+
+// NewReactionTimeline 创建仅在内存中的时间轴
+func NewReactionTimeline() *ReactionTimeline {
+	return &ReactionTimeline{}
+}
+
+// OpenReactionTimeline 打开会话时间轴文件
+// replay 为 true 时载入已有记录用于回放，新的反应总是追加写入
+func OpenReactionTimeline(path string, replay bool, logger *slog.Logger) (*ReactionTimeline, error) {
+	t := NewReactionTimeline()
+
+	if replay {
+		reactions, err := readReactions(path)
+		if err != nil {
+			return nil, err
+		}
+		t.reactions = reactions
+		sortReactions(t.reactions)
+		logging.Component(logger, "Reaction").Info("载入历史反应", "count", len(reactions))
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开时间轴文件失败: %w", err)
+	}
+	t.file = file
+
+	return t, nil
+}
+
+// readReactions 读取 JSONL 文件，文件不存在时返回空
+func readReactions(path string) ([]model.Reaction, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取时间轴失败: %w", err)
+	}
+	defer file.Close()
+
+	var reactions []model.Reaction
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r model.Reaction
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// 跳过损坏的行（如崩溃时写了一半）
+			continue
+		}
+		reactions = append(reactions, r)
+	}
+	return reactions, scanner.Err()
+}
+
+func sortReactions(reactions []model.Reaction) {
+	sort.SliceStable(reactions, func(i, j int) bool {
+		return reactions[i].Position < reactions[j].Position
+	})
+}
+
+// Add 记录一条反应
+func (t *ReactionTimeline) Add(r model.Reaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// 插入到有序位置
+	i := sort.Search(len(t.reactions), func(i int) bool {
+		return t.reactions[i].Position > r.Position
+	})
+	t.reactions = append(t.reactions, model.Reaction{})
+	copy(t.reactions[i+1:], t.reactions[i:])
+	t.reactions[i] = r
+
+	if t.file == nil {
+		return nil
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("序列化失败: %w", err)
+	}
+	if _, err := t.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("写入时间轴失败: %w", err)
+	}
+	return nil
+}
+
+// Between 返回播放位置在 (from, to] 区间内的反应
+func (t *ReactionTimeline) Between(from, to float64) []model.Reaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := sort.Search(len(t.reactions), func(i int) bool {
+		return t.reactions[i].Position > from
+	})
+	var result []model.Reaction
+	for i := start; i < len(t.reactions) && t.reactions[i].Position <= to; i++ {
+		result = append(result, t.reactions[i])
+	}
+	return result
+}
+
+// All 返回全部反应（按播放位置排序）
+func (t *ReactionTimeline) All() []model.Reaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make([]model.Reaction, len(t.reactions))
+	copy(result, t.reactions)
+	return result
+}
+
+// Close 关闭时间轴文件
+func (t *ReactionTimeline) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.file != nil {
+		err := t.file.Close()
+		t.file = nil
+		return err
+	}
+	return nil
+}
+
+// Reactor 表情反应：发送、接收，并在对应播放位置显示
+type Reactor struct {
+	mqttClient *MQTTClient
+	renderer   *mpv.ReactionRenderer
+	timeline   *ReactionTimeline
+	statusCh   <-chan model.PlayStatus
+	sender     string
+
+	mu       gosync.Mutex
+	position float64         // 本地播放位置
+	shown    map[string]bool // 已显示过的反应
+
+	stopCh chan struct{}
+	logger *slog.Logger
+}
+
+// NewReactor 创建反应处理器
+// statusCh 提供本地播放位置，一般来自 Monitor.Subscribe()
+func NewReactor(mqttClient *MQTTClient, renderer *mpv.ReactionRenderer, timeline *ReactionTimeline, statusCh <-chan model.PlayStatus, sender string, logger *slog.Logger) *Reactor {
+	return &Reactor{
+		logger:     logging.Component(logger, "Reaction"),
+		mqttClient: mqttClient,
+		renderer:   renderer,
+		timeline:   timeline,
+		statusCh:   statusCh,
+		sender:     sender,
+		shown:      make(map[string]bool),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// Start 订阅反应并跟踪播放位置
+func (r *Reactor) Start() error {
+	if err := r.mqttClient.SubscribeTo(TopicReaction, r.handlePayload); err != nil {
+		return err
+	}
+	go r.trackLoop()
+	return nil
+}
+
+// Stop 取消订阅并停止跟踪播放位置
+func (r *Reactor) Stop() {
+	close(r.stopCh)
+	if err := r.mqttClient.UnsubscribeFrom(TopicReaction); err != nil {
+		r.logger.Warn("取消订阅失败", "err", err)
+	}
+}
+
+// Send 在当前播放位置发送一条反应
+func (r *Reactor) Send(kind model.ReactionKind) error {
+	if !kind.IsValid() {
+		return fmt.Errorf("未知的反应类型: %s", kind)
+	}
+
+	r.mu.Lock()
+	position := r.position
+	r.mu.Unlock()
+
+	reaction := model.Reaction{
+		Kind:     kind,
+		Sender:   r.sender,
+		Position: position,
+		SentAt:   time.Now().UnixMilli(),
+	}
+
+	// 自己发送的反应也会通过订阅收到，统一在 handle 中显示
+	return r.mqttClient.PublishTo(TopicReaction, reaction, false)
+}
+
+// handlePayload 处理收到的反应
+func (r *Reactor) handlePayload(payload []byte) {
+	var reaction model.Reaction
+	if err := json.Unmarshal(payload, &reaction); err != nil {
+		r.logger.Error("JSON 解析失败", "err", err)
+		return
+	}
+	if !reaction.Kind.IsValid() {
+		return
+	}
+
+	if err := r.timeline.Add(reaction); err != nil {
+		r.logger.Warn("记录失败", "err", err)
+	}
+
+	r.mu.Lock()
+	position := r.position
+	r.mu.Unlock()
+
+	// 已经播过发送位置：在实时窗口内立即显示，太久远的只记录
+	// 还没播到：等播放位置越过时由 trackLoop 显示
+	if reaction.Position <= position && position-reaction.Position <= reactionLiveWindow {
+		r.show(reaction)
+	}
+}
+
+// trackLoop 跟踪本地播放位置，越过反应位置时显示
+func (r *Reactor) trackLoop() {
+	for {
+		var status model.PlayStatus
+		select {
+		case <-r.stopCh:
+			return
+		case status = <-r.statusCh:
+		}
+
+		r.mu.Lock()
+		last := r.position
+		r.position = status.Timestamp
+		if status.Timestamp < last {
+			// 向回跳转：允许重看时再次显示
+			r.shown = make(map[string]bool)
+		}
+		r.mu.Unlock()
+
+		delta := status.Timestamp - last
+		if delta <= 0 || delta > reactionSeekJump {
+			continue
+		}
+
+		for _, reaction := range r.timeline.Between(last, status.Timestamp) {
+			r.show(reaction)
+		}
+	}
+}
+
+// show 显示一条反应（同一条只显示一次）
+func (r *Reactor) show(reaction model.Reaction) {
+	key := fmt.Sprintf("%s@%d", reaction.Sender, reaction.SentAt)
+
+	r.mu.Lock()
+	if r.shown[key] {
+		r.mu.Unlock()
+		return
+	}
+	r.shown[key] = true
+	r.mu.Unlock()
+
+	r.logger.Debug("反应", "kind", reaction.Kind, "from", reaction.Sender, "pos", reaction.Position)
+	r.renderer.Burst(reactionEmoji[reaction.Kind], reaction.Sender)
+}
@@ -0,0 +1,162 @@
+package sync
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+)
+
+// localSampleInterval 本地播放状态的采样间隔（time-pos 每帧都会变化，不需要全部记录）
+const localSampleInterval = 200 * time.Millisecond
+
+// Recorder 会话记录器：把收发的同步消息、本地播放采样和偏差写入 JSONL 文件
+// 方法对 nil 接收者安全，未开启记录时传 nil 即可
+type Recorder struct {
+	mu   gosync.Mutex
+	file *os.File
+
+	meter  DriftMeter // 最近的本地采样
+	stopCh chan struct{}
+	logger *slog.Logger
+}
+
+// OpenRecorder 创建记录文件（已存在时追加）
+func OpenRecorder(path string, logger *slog.Logger) (*Recorder, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("创建目录失败: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开记录文件失败: %w", err)
+	}
+
+	logger = logging.Component(logger, "Recorder")
+	logger.Info("记录会话", "path", path)
+	return &Recorder{
+		file:   file,
+		stopCh: make(chan struct{}),
+		logger: logger,
+	}, nil
+}
+
+// ReadRecords 读取记录文件
+func ReadRecords(path string) ([]model.Record, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取记录失败: %w", err)
+	}
+	defer file.Close()
+
+	var records []model.Record
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var r model.Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// 跳过损坏的行（如崩溃时写了一半）
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, scanner.Err()
+}
+
+// Start 记录本地播放状态，statusCh 一般来自 Monitor.Subscribe()
+func (r *Recorder) Start(statusCh <-chan model.PlayStatus) {
+	if r == nil {
+		return
+	}
+
+	go func() {
+		var lastWrite time.Time
+		for {
+			var status model.PlayStatus
+			select {
+			case <-r.stopCh:
+				return
+			case status = <-statusCh:
+			}
+
+			now := time.Now()
+			r.meter.Observe(status)
+
+			if now.Sub(lastWrite) < localSampleInterval {
+				continue
+			}
+			lastWrite = now
+			r.write(model.Record{At: now.UnixMilli(), Kind: model.RecordLocal, Status: &status})
+		}
+	}()
+}
+
+// Sent 记录发出的消息
+func (r *Recorder) Sent(topic string, payload []byte) {
+	if r == nil {
+		return
+	}
+	r.write(model.Record{At: time.Now().UnixMilli(), Kind: model.RecordSend, Topic: topic, Payload: rawJSON(payload)})
+}
+
+// Received 记录收到的消息
+func (r *Recorder) Received(topic string, payload []byte) {
+	if r == nil {
+		return
+	}
+	r.write(model.Record{At: time.Now().UnixMilli(), Kind: model.RecordRecv, Topic: topic, Payload: rawJSON(payload)})
+}
+
+// ReceivedStatus 记录收到的房主状态，并记录此刻本地与房主的偏差
+func (r *Recorder) ReceivedStatus(topic string, payload []byte, status model.PlayStatus) {
+	if r == nil {
+		return
+	}
+	r.Received(topic, payload)
+
+	now := time.Now()
+	if drift, ok := r.meter.Measure(status, now); ok {
+		r.write(model.Record{At: now.UnixMilli(), Kind: model.RecordDrift, Drift: drift})
+	}
+}
+
+// write 追加一行
+func (r *Recorder) write(record model.Record) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Write(append(line, '\n')); err != nil {
+		r.logger.Warn("写入失败", "err", err)
+	}
+}
+
+// rawJSON 不是合法 JSON 的消息按字符串记录
+func rawJSON(payload []byte) json.RawMessage {
+	if json.Valid(payload) {
+		return json.RawMessage(payload)
+	}
+	quoted, _ := json.Marshal(string(payload))
+	return quoted
+}
+
+// Close 停止记录
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	close(r.stopCh)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
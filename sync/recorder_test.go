@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"movie-night/model"
+)
+
+func TestRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	recorder, err := OpenRecorder(path, nil)
+	if err != nil {
+		t.Fatalf("OpenRecorder failed: %v", err)
+	}
+
+	statusCh := make(chan model.PlayStatus, 1)
+	recorder.Start(statusCh)
+	statusCh <- model.PlayStatus{Timestamp: 42, Paused: true}
+	time.Sleep(50 * time.Millisecond)
+
+	recorder.Sent("video/control/reaction", []byte(`{"kind":"laugh"}`))
+	host := model.PlayStatus{Timestamp: 40, Paused: true}
+	recorder.ReceivedStatus("video/control", []byte(`{"timestamp":40,"paused":true}`), host)
+	if err := recorder.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	records, err := ReadRecords(path)
+	if err != nil {
+		t.Fatalf("ReadRecords failed: %v", err)
+	}
+
+	kinds := []model.RecordKind{model.RecordLocal, model.RecordSend, model.RecordRecv, model.RecordDrift}
+	if len(records) != len(kinds) {
+		t.Fatalf("Expected %d records, got %d", len(kinds), len(records))
+	}
+	for i, kind := range kinds {
+		if records[i].Kind != kind {
+			t.Errorf("Record %d: expected %s, got %s", i, kind, records[i].Kind)
+		}
+	}
+
+	// 本地暂停在 42 秒，房主暂停在 40 秒
+	if drift := records[3].Drift; drift != 2 {
+		t.Errorf("Expected drift +2s, got %.2f", drift)
+	}
+
+	// 未开启记录时可以直接用 nil
+	var disabled *Recorder
+	disabled.Sent("topic", []byte("{}"))
+	if err := disabled.Close(); err != nil {
+		t.Errorf("nil recorder Close should be a no-op, got %v", err)
+	}
+}
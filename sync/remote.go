@@ -0,0 +1,317 @@
+package sync
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+	"movie-night/pkg/mpv"
+)
+
+const (
+	remoteWatchInterval = time.Second // 检查参与者和房主变化的间隔
+	remoteSeekThreshold = 2.0         // 播放位置与推算位置相差超过该值（秒）时视为跳转
+	remoteEventBuffer   = 16          // 每个事件订阅者的缓冲
+)
+
+// BufferSource 根据当前播放位置（秒）给出本机的下载和缓冲情况
+type BufferSource func(position float64) model.BufferState
+
+// Remote 遥控后端（手机遥控页面和本地控制 API）：汇总房间状态和事件，并把操作转为房间动作
+type Remote struct {
+	room      *Room
+	presence  *Presence
+	chat      *Chat
+	subtitles *SubtitleShare
+	mpvCtrl   *mpv.Controller
+	items     []string // 播放列表各项的名称
+
+	mu          gosync.Mutex
+	local       model.PlayStatus // 本地播放状态
+	localAt     time.Time
+	buffer      BufferSource
+	subscribers []chan model.RoomEvent
+	stopCh      chan struct{}
+	logger      *slog.Logger
+}
+
+// NewRemote 创建遥控后端，items 为播放列表各项的名称
+func NewRemote(room *Room, presence *Presence, chat *Chat, subtitles *SubtitleShare, mpvCtrl *mpv.Controller, items []string, logger *slog.Logger) *Remote {
+	return &Remote{
+		logger:    logging.Component(logger, "Remote"),
+		room:      room,
+		presence:  presence,
+		chat:      chat,
+		subtitles: subtitles,
+		mpvCtrl:   mpvCtrl,
+		items:     items,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// SetBufferSource 设置下载和缓冲情况的来源（未设置时页面不显示缓冲）
+func (r *Remote) SetBufferSource(source BufferSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.buffer = source
+}
+
+// Start 跟踪本地播放状态并开始产生房间事件，statusCh 一般来自 Monitor.Subscribe()
+func (r *Remote) Start(statusCh <-chan model.PlayStatus) {
+	r.chat.SetOnMessage(func(msg model.ChatMessage) {
+		r.publish(model.RoomEvent{Kind: model.RoomEventChat, Chat: &msg})
+	})
+
+	go func() {
+		for {
+			var status model.PlayStatus
+			select {
+			case <-r.stopCh:
+				return
+			case status = <-statusCh:
+			}
+
+			now := time.Now()
+			r.mu.Lock()
+			last, lastAt := r.local, r.localAt
+			r.local, r.localAt = status, now
+			r.mu.Unlock()
+
+			if changed, seek := statusChanged(last, lastAt, status, now); changed {
+				r.publish(model.RoomEvent{Kind: model.RoomEventStatus, Status: &status, Seek: seek})
+			}
+		}
+	}()
+
+	go r.watchLoop()
+}
+
+// Stop 停止产生房间事件
+func (r *Remote) Stop() {
+	close(r.stopCh)
+}
+
+// Subscribe 订阅房间事件（订阅者处理不过来时丢弃新事件）
+func (r *Remote) Subscribe() <-chan model.RoomEvent {
+	ch := make(chan model.RoomEvent, remoteEventBuffer)
+
+	r.mu.Lock()
+	r.subscribers = append(r.subscribers, ch)
+	r.mu.Unlock()
+
+	return ch
+}
+
+// Unsubscribe 取消订阅
+func (r *Remote) Unsubscribe(ch <-chan model.RoomEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, sub := range r.subscribers {
+		if sub == ch {
+			r.subscribers = append(r.subscribers[:i], r.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// publish 把事件发给所有订阅者（非阻塞）
+func (r *Remote) publish(event model.RoomEvent) {
+	event.At = time.Now().UnixMilli()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, ch := range r.subscribers {
+		select {
+		case ch <- event:
+		default:
+			r.logger.Debug("事件订阅者处理不过来，丢弃事件", "kind", event.Kind)
+		}
+	}
+}
+
+// watchLoop 定期对比参与者和房主，产生加入、离开和房主变化事件
+func (r *Remote) watchLoop() {
+	ticker := time.NewTicker(remoteWatchInterval)
+	defer ticker.Stop()
+
+	known := make(map[string]model.Participant)
+	for _, p := range r.presence.Participants() {
+		known[p.ID] = p
+	}
+	host := r.room.Host()
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		current := make(map[string]model.Participant)
+		for _, p := range r.presence.Participants() {
+			current[p.ID] = p
+			if _, ok := known[p.ID]; !ok {
+				r.publish(model.RoomEvent{Kind: model.RoomEventJoin, Participant: &p})
+			}
+		}
+		for id, p := range known {
+			if _, ok := current[id]; !ok {
+				r.publish(model.RoomEvent{Kind: model.RoomEventLeave, Participant: &p})
+			}
+		}
+		known = current
+
+		if claim := r.room.Host(); claim.ID != host.ID || claim.Term != host.Term {
+			host = claim
+			r.publish(model.RoomEvent{Kind: model.RoomEventHost, Host: &claim})
+		}
+	}
+}
+
+// statusChanged 判断本地播放状态是否有值得通知的变化：暂停/继续、切换视频，或位置偏离按时间推算的位置（跳转）
+// 第二个返回值表示是否为跳转
+func statusChanged(last model.PlayStatus, lastAt time.Time, status model.PlayStatus, now time.Time) (bool, bool) {
+	if lastAt.IsZero() || last.Item != status.Item {
+		return true, false
+	}
+	expected := last.Timestamp
+	if !last.Paused {
+		expected += now.Sub(lastAt).Seconds() * last.Rate()
+	}
+	seek := math.Abs(status.Timestamp-expected) > remoteSeekThreshold
+	return seek || last.Paused != status.Paused, seek
+}
+
+// State 当前的房间概况
+func (r *Remote) State() model.RoomState {
+	r.mu.Lock()
+	local, buffer := r.local, r.buffer
+	r.mu.Unlock()
+
+	state := model.RoomState{
+		Self:         r.presence.Self().ID,
+		Host:         r.room.Host(),
+		IsHost:       r.room.IsHost(),
+		Policy:       r.room.Policy(),
+		Status:       local,
+		Items:        r.items,
+		Participants: r.presence.Participants(),
+		Chat:         r.chat.Messages(),
+		Subtitle:     r.subtitles.Current().Describe(),
+		Subtitles:    r.subtitleOptions(),
+	}
+	if duration, err := r.mpvCtrl.GetDuration(); err == nil {
+		state.Duration = duration
+	}
+	if buffer != nil {
+		b := buffer(local.Timestamp)
+		state.Buffer = &b
+	}
+	return state
+}
+
+// Status 本地播放状态
+func (r *Remote) Status() model.PlayStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.local
+}
+
+// CurrentItem 正在播放的视频名称和时长（时长未知时为 0）
+func (r *Remote) CurrentItem() (string, float64) {
+	item := r.Status().Item
+	if item < 0 || item >= len(r.items) {
+		return "", 0
+	}
+	duration, err := r.mpvCtrl.GetDuration()
+	if err != nil {
+		duration = 0
+	}
+	return r.items[item], duration
+}
+
+// Participants 在线的参与者
+func (r *Remote) Participants() []model.Participant {
+	return r.presence.Participants()
+}
+
+// Control 请求控制动作（按房间策略，房主直接执行）
+func (r *Remote) Control(action model.ControlAction, position float64) error {
+	if err := validAction(action); err != nil {
+		return err
+	}
+	return r.room.Request(action, position)
+}
+
+// ControlAs 以房间外参与者 id/name 的身份请求控制动作（即使本机是房主也按房间策略处理）
+func (r *Remote) ControlAs(id, name string, action model.ControlAction, position float64) error {
+	if err := validAction(action); err != nil {
+		return err
+	}
+	return r.room.RequestAs(id, name, action, position)
+}
+
+// validAction 检查控制动作是否可以遥控
+func validAction(action model.ControlAction) error {
+	switch action {
+	case model.ActionPause, model.ActionResume, model.ActionSeek, model.ActionSkip:
+		return nil
+	}
+	return fmt.Errorf("未知的控制动作: %s", action)
+}
+
+// Say 发送聊天消息
+func (r *Remote) Say(text string) error {
+	return r.chat.Send(text)
+}
+
+// SelectSubtitle 选择字幕，command 同终端 sub 指令的参数（不支持上传本地文件）
+func (r *Remote) SelectSubtitle(command string) error {
+	cmd, _, _ := strings.Cut(strings.TrimSpace(command), " ")
+	if cmd == "" || cmd == "list" || cmd == "load" {
+		return fmt.Errorf("遥控不支持该字幕指令: %s", command)
+	}
+	return r.subtitles.Command(command)
+}
+
+// SelectItem 切换到播放列表中的指定项（只有房主可以切换，跟随端随房主切换）
+func (r *Remote) SelectItem(index int) error {
+	if !r.room.IsHost() {
+		return fmt.Errorf("只有房主可以切换视频")
+	}
+	if index < 0 || index >= len(r.items) {
+		return fmt.Errorf("播放列表没有第 %d 项", index+1)
+	}
+	return r.mpvCtrl.SetPlaylistPos(index)
+}
+
+// subtitleOptions 可选的字幕：内嵌字幕轨道、种子中的字幕文件和关闭
+func (r *Remote) subtitleOptions() []model.SubtitleOption {
+	tracks, err := r.mpvCtrl.GetTracks("sub")
+	if err != nil {
+		r.logger.Debug("获取字幕轨道失败", "err", err)
+	}
+
+	options := make([]model.SubtitleOption, 0, len(tracks)+len(r.subtitles.files)+1)
+	for _, t := range tracks {
+		options = append(options, model.SubtitleOption{
+			Command:  strconv.Itoa(t.ID),
+			Label:    t.Label(),
+			Selected: t.Selected,
+		})
+	}
+	for i, path := range r.subtitles.files {
+		options = append(options, model.SubtitleOption{
+			Command: fmt.Sprintf("file %d", i+1),
+			Label:   path,
+		})
+	}
+	return append(options, model.SubtitleOption{Command: "off", Label: "Off"})
+}
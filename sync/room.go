@@ -0,0 +1,474 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+	"movie-night/pkg/mpv"
+)
+
+const (
+	hostWatchInterval = 2 * time.Second  // 检查房主是否在线的间隔
+	hostClaimWait     = 1 * time.Second  // 启动时等待保留的房主声明
+	noHostGrace       = 20 * time.Second // 启动后一直没有房主时发起选举
+)
+
+// ElectHost 从在线参与者中确定性地选出新房主：最早加入者优先，同时加入按 ID 排序
+// 浏览器参与者不能控制播放，不参与选举
+func ElectHost(participants []model.Participant, excludeID string) (model.Participant, bool) {
+	candidates := make([]model.Participant, 0, len(participants))
+	for _, p := range participants {
+		if p.ID != excludeID && !p.Left && !p.Browser {
+			candidates = append(candidates, p)
+		}
+	}
+	if len(candidates) == 0 {
+		return model.Participant{}, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].JoinedAt != candidates[j].JoinedAt {
+			return candidates[i].JoinedAt < candidates[j].JoinedAt
+		}
+		return candidates[i].ID < candidates[j].ID
+	})
+	return candidates[0], true
+}
+
+// RoomConfig 房间配置
+type RoomConfig struct {
+	MQTTClient   *MQTTClient
+	MPVCtrl      *mpv.Controller
+	Monitor      StatusSource
+	Presence     *Presence
+	Policy       model.RoomPolicy // 以控制端启动时使用的策略
+	Interval     time.Duration    // 房主广播间隔
+	MaxDuration  float64          // 视频时长（跟随端校验用）
+	Prebuffer    PrebufferFunc    // 中途加入时预缓冲（为空则直接跳转）
+	Intermission time.Duration    // 播放结束后切到下一项前的幕间时长
+	Logger       *slog.Logger     // 日志（为空时使用 slog.Default()），房间内的组件共用
+}
+
+// Room 房间：根据房主声明在控制端和跟随端之间切换，并在房主离线时自动选举
+type Room struct {
+	cfg  RoomConfig
+	self model.Participant
+
+	mu        gosync.Mutex
+	host      model.HostClaim // 当前房主声明
+	hostSince time.Time       // 收到当前声明的时间
+	isHost    bool
+	policy    model.RoomPolicy // 最近的房间策略（成为房主时沿用）
+	localSub  bool             // 正在使用自己选择的字幕（跟随时不同步字幕轨道）
+
+	switchMu   gosync.Mutex // 串行化角色切换
+	controller *Controller
+	arbiter    *ControlArbiter
+	board      *CatchUpBoard
+	playback   *PlaybackHost
+	playbackCh <-chan model.PlayStatus
+	follower   *Follower
+	reporter   *ActionReporter
+	reporterCh <-chan model.PlayStatus
+	joined     bool // 是否已经以跟随端同步过（只有第一次需要追赶）
+
+	drift *DriftMeter // 跟随时测量与房主的偏差
+
+	startedAt time.Time
+	stopCh    chan struct{}
+	logger    *slog.Logger
+}
+
+// NewRoom 创建房间
+func NewRoom(cfg RoomConfig) *Room {
+	return &Room{
+		logger: logging.Component(cfg.Logger, "Room"),
+		cfg:    cfg,
+		self:   cfg.Presence.Self(),
+		policy: cfg.Policy,
+		drift:  &DriftMeter{},
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start 加入房间；asHost 为 true 时声明自己为房主
+func (r *Room) Start(asHost bool) error {
+	r.startedAt = time.Now()
+	go r.drift.Run(r.cfg.Monitor.Subscribe(), r.stopCh)
+
+	if err := r.cfg.MQTTClient.SubscribeTo(TopicHost, r.handleClaim); err != nil {
+		return err
+	}
+
+	// 等待保留的房主声明，拿到当前任期
+	time.Sleep(hostClaimWait)
+
+	if asHost {
+		if err := r.claim(r.self.ID, r.self.Name, model.HostReasonStart); err != nil {
+			return err
+		}
+	} else if !r.IsHost() {
+		r.becomeFollower()
+	}
+
+	go r.watchLoop()
+	return nil
+}
+
+// IsHost 自己是否是房主
+func (r *Room) IsHost() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.isHost
+}
+
+// Host 当前房主声明
+func (r *Room) Host() model.HostClaim {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.host
+}
+
+// Policy 当前房间策略
+func (r *Room) Policy() model.RoomPolicy {
+	r.switchMu.Lock()
+	reporter := r.reporter
+	r.switchMu.Unlock()
+
+	if reporter != nil {
+		return reporter.Policy()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.policy
+}
+
+// TransferHost 把房主转让给指定参与者（名称或 ID）
+func (r *Room) TransferHost(target string) error {
+	if !r.IsHost() {
+		return fmt.Errorf("只有房主可以转让")
+	}
+
+	for _, p := range r.cfg.Presence.Participants() {
+		if (p.ID == target || p.Name == target) && p.ID != r.self.ID {
+			return r.claim(p.ID, p.Name, model.HostReasonTransfer)
+		}
+	}
+	return fmt.Errorf("找不到参与者: %s", target)
+}
+
+// SetTrackSync 修改全房间同步的播放属性（速度、音轨、字幕轨道），只有房主可以修改
+func (r *Room) SetTrackSync(tracks model.TrackSync) error {
+	r.switchMu.Lock()
+	defer r.switchMu.Unlock()
+
+	if r.arbiter == nil {
+		return fmt.Errorf("只有房主可以修改同步属性")
+	}
+	if err := r.arbiter.SetTrackSync(tracks); err != nil {
+		return fmt.Errorf("同步属性发布失败: %w", err)
+	}
+	r.controller.SetTrackSync(tracks)
+
+	r.mu.Lock()
+	r.policy.Sync = tracks
+	r.mu.Unlock()
+
+	r.logger.Info("同步属性", "tracks", tracks.String())
+	return nil
+}
+
+// SetLocalSubtitle 标记是否正在使用自己选择的字幕，期间跟随房主时不同步字幕轨道
+func (r *Room) SetLocalSubtitle(local bool) {
+	r.mu.Lock()
+	r.localSub = local
+	r.mu.Unlock()
+
+	r.switchMu.Lock()
+	defer r.switchMu.Unlock()
+	if r.follower != nil {
+		r.follower.GetSyncer().SetLocalSubtitle(local)
+	}
+}
+
+// Request 请求控制动作：房主直接执行，跟随端按房间策略发给房主
+func (r *Room) Request(action model.ControlAction, position float64) error {
+	r.switchMu.Lock()
+	arbiter, reporter := r.arbiter, r.reporter
+	r.switchMu.Unlock()
+
+	if arbiter != nil {
+		arbiter.apply(action, position)
+		return nil
+	}
+	if reporter != nil {
+		return reporter.Request(action, position)
+	}
+	return fmt.Errorf("尚未加入房间")
+}
+
+// RequestAs 代替房间外的参与者（如 Syncplay 用户）请求控制动作：即使本机是房主也按房间策略处理，
+// 仅房主模式下拒绝，投票模式下发起或加入投票
+func (r *Room) RequestAs(id, name string, action model.ControlAction, position float64) error {
+	r.switchMu.Lock()
+	arbiter, reporter := r.arbiter, r.reporter
+	r.switchMu.Unlock()
+
+	req := model.ControlRequest{Action: action, Position: position, From: id, Name: name}
+	if arbiter != nil {
+		req.SentAt = time.Now().UnixMilli()
+		return arbiter.handle(req)
+	}
+	if reporter != nil {
+		return reporter.send(req)
+	}
+	return fmt.Errorf("尚未加入房间")
+}
+
+// claim 发布新的房主声明（任期 +1），并立即在本地生效
+func (r *Room) claim(id, name, reason string) error {
+	r.mu.Lock()
+	claim := model.HostClaim{
+		ID:        id,
+		Name:      name,
+		Term:      r.host.Term + 1,
+		Reason:    reason,
+		ClaimedAt: time.Now().UnixMilli(),
+	}
+	r.mu.Unlock()
+
+	if err := r.cfg.MQTTClient.PublishTo(TopicHost, claim, true); err != nil {
+		return fmt.Errorf("房主声明发送失败: %w", err)
+	}
+	r.applyClaim(claim)
+	return nil
+}
+
+// handleClaim 处理房主声明
+func (r *Room) handleClaim(payload []byte) {
+	var claim model.HostClaim
+	if err := json.Unmarshal(payload, &claim); err != nil || claim.ID == "" {
+		return
+	}
+	r.applyClaim(claim)
+}
+
+// applyClaim 声明比当前更新时切换角色
+func (r *Room) applyClaim(claim model.HostClaim) {
+	r.mu.Lock()
+	if !claim.Supersedes(r.host) {
+		r.mu.Unlock()
+		return
+	}
+	r.host = claim
+	r.hostSince = time.Now()
+	wasHost := r.isHost
+	started := !r.startedAt.IsZero() && time.Since(r.startedAt) >= hostClaimWait
+	r.mu.Unlock()
+
+	r.logger.Info("房主", "name", claim.Name, "term", claim.Term, "reason", claim.Reason)
+	if started {
+		r.cfg.MPVCtrl.ShowText(fmt.Sprintf("👑 房主: %s", claim.Name), 3000)
+	}
+
+	switch {
+	case claim.ID == r.self.ID && !wasHost:
+		r.becomeHost(claim.Term)
+	case claim.ID != r.self.ID && (wasHost || started):
+		r.becomeFollower()
+	}
+}
+
+// becomeHost 切换为控制端
+func (r *Room) becomeHost(term int) {
+	r.switchMu.Lock()
+	defer r.switchMu.Unlock()
+
+	r.mu.Lock()
+	r.isHost = true
+	r.mu.Unlock()
+
+	// 沿用上一任房主的策略
+	if r.reporter != nil {
+		if policy := r.reporter.Policy(); policy.Mode.IsValid() {
+			r.mu.Lock()
+			r.policy = policy
+			r.mu.Unlock()
+		}
+		r.reporter.Stop()
+		r.cfg.Monitor.Unsubscribe(r.reporterCh)
+		r.reporter, r.reporterCh = nil, nil
+	}
+	if r.follower != nil {
+		r.follower.Stop()
+		r.follower = nil
+	}
+
+	r.cfg.Presence.SetRole(model.RoleHost)
+	r.cfg.Presence.ClearDrift()
+
+	r.mu.Lock()
+	policy := r.policy
+	r.mu.Unlock()
+
+	r.controller = NewController(r.cfg.MQTTClient, r.cfg.Monitor, r.cfg.Interval, r.cfg.Logger)
+	r.controller.SetHost(r.self.ID, term)
+	r.controller.SetTrackSync(policy.Sync)
+	go r.controller.Start()
+
+	r.arbiter = NewControlArbiter(r.cfg.MQTTClient, r.cfg.MPVCtrl, r.cfg.Presence, policy, r.cfg.Logger)
+	if err := r.arbiter.Start(); err != nil {
+		r.logger.Warn("房间控制不可用", "err", err)
+	}
+
+	r.board = NewCatchUpBoard(r.cfg.MQTTClient, r.cfg.MPVCtrl, r.cfg.Logger)
+	if err := r.board.Start(); err != nil {
+		r.logger.Warn("追赶进度面板不可用", "err", err)
+	}
+
+	r.playbackCh = r.cfg.Monitor.Subscribe()
+	r.playback = NewPlaybackHost(r.cfg.MQTTClient, r.cfg.MPVCtrl, r.playbackCh, r.cfg.Intermission, r.cfg.Logger)
+	r.playback.SetHost(r.self.ID, term)
+	r.playback.Start()
+
+	// 房主已经在播放，之后再成为跟随端时无需追赶
+	r.joined = true
+}
+
+// becomeFollower 切换为跟随端（已经是跟随端时只更新任期）
+func (r *Room) becomeFollower() {
+	r.switchMu.Lock()
+	defer r.switchMu.Unlock()
+
+	r.mu.Lock()
+	r.isHost = false
+	term := r.host.Term
+	r.mu.Unlock()
+
+	if r.follower != nil {
+		r.follower.SetTerm(term)
+		return
+	}
+
+	if r.controller != nil {
+		r.controller.Stop()
+		r.controller = nil
+	}
+	if r.arbiter != nil {
+		r.mu.Lock()
+		r.policy = r.arbiter.Policy()
+		r.mu.Unlock()
+		r.arbiter.Stop()
+		r.arbiter = nil
+	}
+	if r.board != nil {
+		r.board.Stop()
+		r.board = nil
+	}
+	if r.playback != nil {
+		r.playback.Stop()
+		r.cfg.Monitor.Unsubscribe(r.playbackCh)
+		r.playback, r.playbackCh = nil, nil
+	}
+
+	r.cfg.Presence.SetRole(model.RoleFollower)
+
+	r.follower = NewFollower(r.cfg.MPVCtrl, r.cfg.MQTTClient, r.cfg.MaxDuration, r.cfg.Logger)
+	r.follower.SetTerm(term)
+	r.follower.SetDriftMeter(r.drift, r.cfg.Presence.SetDrift)
+	r.mu.Lock()
+	r.follower.GetSyncer().SetLocalSubtitle(r.localSub)
+	r.mu.Unlock()
+	if !r.joined && r.cfg.Prebuffer != nil {
+		r.follower.SetCatchUp(NewCatchUp(r.cfg.MQTTClient, r.cfg.MPVCtrl, r.cfg.Prebuffer, r.self.ID, r.self.Name, r.cfg.Logger))
+	}
+	r.joined = true
+	if err := r.follower.Start(); err != nil {
+		r.logger.Warn("跟随端启动失败", "err", err)
+	}
+
+	// 本地的暂停/跳转按房间策略上报给房主
+	r.reporterCh = r.cfg.Monitor.Subscribe()
+	r.reporter = NewActionReporter(r.cfg.MQTTClient, r.follower.GetSyncer(), r.reporterCh, r.self.ID, r.self.Name, r.cfg.Logger)
+	if err := r.reporter.Start(); err != nil {
+		r.logger.Warn("房间控制不可用", "err", err)
+	}
+}
+
+// watchLoop 跟随端检查房主是否在线，离线时发起选举
+func (r *Room) watchLoop() {
+	ticker := time.NewTicker(hostWatchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		host, hostSince, isHost := r.host, r.hostSince, r.isHost
+		r.mu.Unlock()
+
+		if isHost {
+			continue
+		}
+
+		var lost bool
+		if host.ID == "" {
+			lost = time.Since(r.startedAt) > noHostGrace
+		} else {
+			// 给新房主一点时间出现在在线列表中
+			lost = time.Since(hostSince) > presenceTimeout && !r.cfg.Presence.Contains(host.ID)
+		}
+		if !lost {
+			continue
+		}
+
+		participants := append(r.cfg.Presence.Participants(), r.cfg.Presence.Self())
+		candidate, ok := ElectHost(participants, host.ID)
+		if !ok || candidate.ID != r.self.ID {
+			continue
+		}
+
+		r.logger.Info("房主已离线，由我接任", "host", host.Name)
+		if err := r.claim(r.self.ID, r.self.Name, model.HostReasonElection); err != nil {
+			r.logger.Warn("接任失败", "err", err)
+		}
+	}
+}
+
+// Stop 退出房间
+func (r *Room) Stop() {
+	close(r.stopCh)
+
+	r.switchMu.Lock()
+	defer r.switchMu.Unlock()
+
+	if r.controller != nil {
+		r.controller.Stop()
+	}
+	if r.arbiter != nil {
+		r.arbiter.Stop()
+	}
+	if r.board != nil {
+		r.board.Stop()
+	}
+	if r.playback != nil {
+		r.playback.Stop()
+	}
+	if r.reporter != nil {
+		r.reporter.Stop()
+	}
+	if r.follower != nil {
+		r.follower.Stop()
+	}
+}
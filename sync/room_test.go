@@ -0,0 +1,72 @@
+package sync
+
+import (
+	"testing"
+
+	"movie-night/model"
+)
+
+func TestElectHost(t *testing.T) {
+	participants := []model.Participant{
+		{ID: "host", Name: "Host", JoinedAt: 100},
+		{ID: "carol", Name: "Carol", JoinedAt: 300},
+		{ID: "bob", Name: "Bob", JoinedAt: 200},
+		{ID: "alice", Name: "Alice", JoinedAt: 200},
+		{ID: "dave", Name: "Dave", JoinedAt: 150, Left: true},
+	}
+
+	// 排除离线的房主和已离开的人，同时加入按 ID 决胜
+	got, ok := ElectHost(participants, "host")
+	if !ok || got.ID != "alice" {
+		t.Errorf("Expected alice to be elected, got %+v (ok=%v)", got, ok)
+	}
+
+	if _, ok := ElectHost([]model.Participant{{ID: "host"}}, "host"); ok {
+		t.Error("Expected no candidate when only the lost host remains")
+	}
+}
+
+func TestHostClaimSupersedes(t *testing.T) {
+	current := model.HostClaim{ID: "bob", Term: 2}
+
+	cases := []struct {
+		claim model.HostClaim
+		want  bool
+	}{
+		{model.HostClaim{ID: "carol", Term: 3}, true},
+		{model.HostClaim{ID: "alice", Term: 1}, false},
+		{model.HostClaim{ID: "alice", Term: 2}, true},  // 同任期 ID 更小者胜出
+		{model.HostClaim{ID: "carol", Term: 2}, false}, // 同任期 ID 更大
+		{model.HostClaim{ID: "bob", Term: 2}, false},   // 重复的声明
+	}
+
+	for _, c := range cases {
+		if got := c.claim.Supersedes(current); got != c.want {
+			t.Errorf("%+v.Supersedes(%+v) = %v, want %v", c.claim, current, got, c.want)
+		}
+	}
+
+	if !(model.HostClaim{ID: "bob", Term: 0}).Supersedes(model.HostClaim{}) {
+		t.Error("Any claim should supersede the empty claim")
+	}
+}
+
+func TestParseTrackSync(t *testing.T) {
+	tracks, err := model.ParseTrackSync("speed, sid")
+	if err != nil {
+		t.Fatalf("ParseTrackSync failed: %v", err)
+	}
+	if !tracks.Speed || tracks.Audio || !tracks.Subtitle {
+		t.Errorf("Unexpected tracks: %+v", tracks)
+	}
+	if tracks.String() != "speed,sid" {
+		t.Errorf("Expected speed,sid, got %s", tracks)
+	}
+
+	if none, err := model.ParseTrackSync("none"); err != nil || none.String() != "none" {
+		t.Errorf("Expected none, got %v (%v)", none, err)
+	}
+	if _, err := model.ParseTrackSync("volume"); err == nil {
+		t.Error("Expected unknown property to be rejected")
+	}
+}
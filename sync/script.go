@@ -0,0 +1,123 @@
+package sync
+
+import (
+	"log/slog"
+	"time"
+
+	"movie-night/pkg/logging"
+	"movie-night/pkg/mpv"
+)
+
+// scriptStateRefresh 向内置脚本推送房间状态的间隔
+const scriptStateRefresh = 5 * time.Second
+
+// DefaultMenu 内置脚本的房间菜单（动作与按键绑定相同，由 KeyActions 执行）
+var DefaultMenu = []mpv.MenuItem{
+	{Title: "Request pause / resume", Action: KeyActionRequestPause},
+	{Title: "Toggle ready", Action: KeyActionReady},
+	{Title: "Show room", Action: KeyActionToggleOverlay},
+	{Title: "Show torrent stats", Action: KeyActionToggleStats},
+	{Title: "😂 Laugh", Action: KeyActionReactPrefix + "laugh"},
+	{Title: "😱 Shock", Action: KeyActionReactPrefix + "shock"},
+	{Title: "👏 Applause", Action: KeyActionReactPrefix + "applause"},
+}
+
+// ScriptBridge 与 MPV 内置脚本通信：下发菜单和房间状态，处理脚本事件
+type ScriptBridge struct {
+	room     *Room
+	presence *Presence
+	mpvCtrl  *mpv.Controller
+	stopCh   chan struct{}
+	logger   *slog.Logger
+}
+
+// NewScriptBridge 创建脚本桥接
+func NewScriptBridge(room *Room, presence *Presence, mpvCtrl *mpv.Controller, logger *slog.Logger) *ScriptBridge {
+	return &ScriptBridge{
+		logger:   logging.Component(logger, "Script"),
+		room:     room,
+		presence: presence,
+		mpvCtrl:  mpvCtrl,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start 处理脚本事件，messages 一般来自 Monitor.SubscribeMessages()
+func (b *ScriptBridge) Start(messages <-chan []string) {
+	go func() {
+		for {
+			select {
+			case <-b.stopCh:
+				return
+			case args := <-messages:
+				if len(args) < 2 || args[0] != mpv.ScriptEvent {
+					continue
+				}
+				b.handleEvent(args[1], args[2:])
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(scriptStateRefresh)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-b.stopCh:
+				return
+			case <-ticker.C:
+				b.pushState()
+			}
+		}
+	}()
+
+	// 脚本可能在我们订阅之前就已经加载完成
+	b.setup()
+}
+
+// Stop 停止处理脚本事件和推送房间状态
+func (b *ScriptBridge) Stop() {
+	close(b.stopCh)
+}
+
+// handleEvent 处理脚本事件
+func (b *ScriptBridge) handleEvent(name string, args []string) {
+	switch name {
+	case mpv.ScriptEventReady:
+		version := ""
+		if len(args) > 0 {
+			version = args[0]
+		}
+		if version != mpv.ScriptVersion {
+			b.logger.Warn("脚本版本不匹配", "version", version, "want", mpv.ScriptVersion)
+		}
+		b.logger.Info("内置脚本已加载")
+		b.setup()
+
+	case mpv.ScriptEventOnLoad:
+		if len(args) > 0 {
+			b.logger.Debug("开始加载", "path", args[0])
+		}
+
+	default:
+		b.logger.Debug("未知事件", "name", name)
+	}
+}
+
+// setup 下发菜单和当前状态
+func (b *ScriptBridge) setup() {
+	if err := b.mpvCtrl.SetMenu(DefaultMenu); err != nil {
+		b.logger.Warn("菜单下发失败", "err", err)
+	}
+	b.pushState()
+}
+
+// pushState 推送房间状态
+func (b *ScriptBridge) pushState() {
+	b.mpvCtrl.SetScriptState(mpv.ScriptState{
+		Host:         b.room.Host().Name,
+		Mode:         string(b.room.Policy().Mode),
+		Participants: b.presence.Count(),
+	})
+}
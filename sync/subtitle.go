@@ -0,0 +1,246 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+	"movie-night/pkg/mpv"
+)
+
+// SubtitleResolver 把种子内的字幕路径解析为 MPV 可加载的地址
+type SubtitleResolver func(path string) (string, error)
+
+// SubtitleShare 共享字幕：房主选择的字幕分发给所有人，参与者可以改用自己的选择
+type SubtitleShare struct {
+	room      *Room
+	mqtt      *MQTTClient
+	mpvCtrl   *mpv.Controller
+	resolve   SubtitleResolver
+	files     []string // 种子中的字幕文件
+	uploadDir string   // 收到的上传字幕保存目录
+
+	mu       gosync.Mutex
+	current  model.SubtitleChoice // 房间的字幕选择
+	override bool                 // 是否使用自己的选择
+	logger   *slog.Logger
+}
+
+// NewSubtitleShare 创建共享字幕
+// files 是种子中字幕文件的路径，resolve 负责把它们解析为本地流服务地址
+func NewSubtitleShare(room *Room, mqtt *MQTTClient, mpvCtrl *mpv.Controller, files []string, resolve SubtitleResolver, uploadDir string, logger *slog.Logger) *SubtitleShare {
+	return &SubtitleShare{
+		logger:    logging.Component(logger, "Subtitle"),
+		room:      room,
+		mqtt:      mqtt,
+		mpvCtrl:   mpvCtrl,
+		resolve:   resolve,
+		files:     files,
+		uploadDir: uploadDir,
+	}
+}
+
+// Start 订阅房间的字幕选择
+func (s *SubtitleShare) Start() error {
+	if err := os.MkdirAll(s.uploadDir, 0755); err != nil {
+		return fmt.Errorf("创建字幕目录失败: %w", err)
+	}
+	return s.mqtt.SubscribeTo(TopicSubtitle, s.handleChoice)
+}
+
+// Stop 取消订阅房间的字幕选择
+func (s *SubtitleShare) Stop() {
+	if err := s.mqtt.UnsubscribeFrom(TopicSubtitle); err != nil {
+		s.logger.Warn("取消订阅失败", "err", err)
+	}
+}
+
+// Current 房间当前的字幕选择
+func (s *SubtitleShare) Current() model.SubtitleChoice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+// Select 选择字幕：房主分发给所有人，其他人只在本地生效（不再跟随房间）
+func (s *SubtitleShare) Select(choice model.SubtitleChoice) error {
+	choice.By = s.room.self.Name
+	choice.SetAt = time.Now().UnixMilli()
+
+	if s.room.IsHost() {
+		s.mu.Lock()
+		s.override = false
+		s.mu.Unlock()
+		s.room.SetLocalSubtitle(false)
+
+		if err := s.mqtt.PublishTo(TopicSubtitle, choice, true); err != nil {
+			return fmt.Errorf("字幕分发失败: %w", err)
+		}
+		s.logger.Info("已分发字幕", "choice", choice.Describe())
+		return nil
+	}
+
+	s.mu.Lock()
+	s.override = true
+	s.mu.Unlock()
+	s.room.SetLocalSubtitle(true)
+
+	s.logger.Info("使用自己的字幕", "choice", choice.Describe())
+	return s.apply(choice)
+}
+
+// FollowRoom 放弃自己的选择，重新使用房间的字幕
+func (s *SubtitleShare) FollowRoom() error {
+	s.mu.Lock()
+	s.override = false
+	current := s.current
+	s.mu.Unlock()
+	s.room.SetLocalSubtitle(false)
+
+	if current.Source == "" {
+		return nil
+	}
+	return s.apply(current)
+}
+
+// Command 执行终端的字幕指令（sub 之后的部分，列表由终端用 Tracks 和 Files 打印）
+//
+//	<id>          选择内嵌字幕轨道
+//	file <序号>   选择种子中的字幕文件
+//	load <路径>   上传本地字幕文件
+//	off           关闭字幕
+//	room          重新使用房间的字幕
+func (s *SubtitleShare) Command(args string) error {
+	cmd, arg, _ := strings.Cut(strings.TrimSpace(args), " ")
+	arg = strings.TrimSpace(arg)
+
+	switch cmd {
+	case "off":
+		return s.Select(model.SubtitleChoice{Source: model.SubtitleOff})
+
+	case "room":
+		return s.FollowRoom()
+
+	case "file":
+		index, err := strconv.Atoi(arg)
+		if err != nil || index < 1 || index > len(s.files) {
+			return fmt.Errorf("字幕文件序号无效: %s", arg)
+		}
+		return s.Select(model.SubtitleChoice{Source: model.SubtitleTorrent, Path: s.files[index-1]})
+
+	case "load":
+		choice, err := loadSubtitle(arg)
+		if err != nil {
+			return err
+		}
+		return s.Select(choice)
+
+	default:
+		id, err := strconv.Atoi(cmd)
+		if err != nil {
+			return fmt.Errorf("未知的字幕指令: %s", cmd)
+		}
+		return s.Select(model.SubtitleChoice{Source: model.SubtitleTrack, TrackID: id})
+	}
+}
+
+// Tracks 可选的内嵌字幕轨道
+func (s *SubtitleShare) Tracks() ([]mpv.Track, error) {
+	return s.mpvCtrl.GetTracks("sub")
+}
+
+// Files 种子中的字幕文件（sub file 的序号从 1 开始）
+func (s *SubtitleShare) Files() []string {
+	return s.files
+}
+
+// loadSubtitle 读取本地字幕文件用于上传
+func loadSubtitle(path string) (model.SubtitleChoice, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return model.SubtitleChoice{}, fmt.Errorf("读取字幕失败: %w", err)
+	}
+	if info.Size() > model.MaxSubtitleUpload {
+		return model.SubtitleChoice{}, fmt.Errorf("字幕文件过大: %d KB (上限 %d KB)", info.Size()/1024, model.MaxSubtitleUpload/1024)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return model.SubtitleChoice{}, fmt.Errorf("读取字幕失败: %w", err)
+	}
+	return model.SubtitleChoice{
+		Source: model.SubtitleUpload,
+		Path:   filepath.Base(path),
+		Data:   data,
+	}, nil
+}
+
+// handleChoice 处理房间的字幕选择
+func (s *SubtitleShare) handleChoice(payload []byte) {
+	var choice model.SubtitleChoice
+	if err := json.Unmarshal(payload, &choice); err != nil || choice.Source == "" {
+		return
+	}
+
+	s.mu.Lock()
+	s.current = choice
+	override := s.override
+	s.mu.Unlock()
+
+	if override {
+		s.logger.Info("房间字幕已改变（正在使用自己的字幕，输入 sub room 跟随）", "choice", choice.Describe())
+		return
+	}
+
+	if err := s.apply(choice); err != nil {
+		s.logger.Warn("应用字幕失败", "err", err)
+		return
+	}
+	if choice.By != "" && choice.By != s.room.self.Name {
+		s.mpvCtrl.ShowText(fmt.Sprintf("💬 %s 选择了字幕: %s", choice.By, choice.Describe()), 2000)
+	}
+}
+
+// apply 在本地 MPV 中加载字幕
+func (s *SubtitleShare) apply(choice model.SubtitleChoice) error {
+	switch choice.Source {
+	case model.SubtitleOff:
+		return s.mpvCtrl.DisableSubtitles()
+
+	case model.SubtitleTrack:
+		return s.mpvCtrl.SetSubtitleTrack(choice.TrackID)
+
+	case model.SubtitleTorrent:
+		url, err := s.resolve(choice.Path)
+		if err != nil {
+			return err
+		}
+		return s.mpvCtrl.AddSubtitle(url, filepath.Base(choice.Path))
+
+	case model.SubtitleUpload:
+		if len(choice.Data) > model.MaxSubtitleUpload {
+			return fmt.Errorf("上传的字幕过大: %d KB", len(choice.Data)/1024)
+		}
+		// 只保留文件名，避免写到目录之外
+		name := filepath.Base(choice.Path)
+		if name == "." || name == ".." || name == string(filepath.Separator) {
+			return fmt.Errorf("上传的字幕缺少文件名")
+		}
+		path := filepath.Join(s.uploadDir, name)
+		if err := os.WriteFile(path, choice.Data, 0644); err != nil {
+			return fmt.Errorf("保存字幕失败: %w", err)
+		}
+		return s.mpvCtrl.AddSubtitle(path, name)
+
+	default:
+		return fmt.Errorf("未知的字幕来源: %s", choice.Source)
+	}
+}
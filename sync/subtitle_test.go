@@ -0,0 +1,119 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/mpv/mpvtest"
+)
+
+// expectSid 等待设置字幕轨道的命令
+func expectSid(t *testing.T, server *mpvtest.Server, want interface{}) {
+	t.Helper()
+	cmd, err := server.WaitCommand("set_property", time.Second)
+	if err != nil {
+		t.Fatalf("sid %v never applied: %v", want, err)
+	}
+	if cmd[1] != "sid" || cmd[2] != want {
+		t.Fatalf("got %v, want sid %v", cmd, want)
+	}
+}
+
+func TestSubtitleShareOverride(t *testing.T) {
+	broker := NewMemoryBroker()
+	host := NewMQTTClientWithTransport(broker.Connect(), "video/control", nil)
+	defer host.Close()
+	viewer := newPeer(t, broker, "viewer", 600)
+
+	room := &Room{self: model.Participant{ID: "bob", Name: "Bob"}}
+	share := NewSubtitleShare(room, viewer.client, viewer.ctrl, nil, nil, t.TempDir(), nil)
+	if err := share.Start(); err != nil {
+		t.Fatal(err)
+	}
+	publish := func(id int) {
+		t.Helper()
+		choice := model.SubtitleChoice{Source: model.SubtitleTrack, TrackID: id, By: "Alice"}
+		if err := host.PublishTo(TopicSubtitle, choice, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 跟随房间的字幕
+	publish(2)
+	expectSid(t, viewer.server, 2.0)
+
+	// 改用自己的字幕后不再跟随
+	if err := share.Command("3"); err != nil {
+		t.Fatal(err)
+	}
+	expectSid(t, viewer.server, 3.0)
+	publish(4)
+	if cmd, err := viewer.server.WaitCommand("set_property", 200*time.Millisecond); err == nil {
+		t.Errorf("override should ignore the room choice, got %v", cmd)
+	}
+	if share.Current().TrackID != 4 {
+		t.Errorf("room choice should still be recorded, got %+v", share.Current())
+	}
+
+	// sub room 重新使用房间的字幕
+	if err := share.Command("room"); err != nil {
+		t.Fatal(err)
+	}
+	expectSid(t, viewer.server, 4.0)
+}
+
+func TestSubtitleOverrideSkipsTrackSync(t *testing.T) {
+	viewer := newPeer(t, NewMemoryBroker(), "viewer", 600)
+	syncer := NewSyncer(viewer.ctrl, 0, nil)
+
+	// 使用自己的字幕时，房主的字幕轨道变化不覆盖它
+	syncer.SetLocalSubtitle(true)
+	syncer.syncTracks(model.PlayStatus{Sid: "2"})
+	if cmd, err := viewer.server.WaitCommand("set_property", 200*time.Millisecond); err == nil {
+		t.Errorf("sid sync should be skipped during an override, got %v", cmd)
+	}
+
+	// 恢复跟随后，房主之后的变化照常同步
+	syncer.SetLocalSubtitle(false)
+	syncer.syncTracks(model.PlayStatus{Sid: "3"})
+	expectSid(t, viewer.server, "3")
+}
+
+func TestSubtitleUploadSanitizesName(t *testing.T) {
+	viewer := newPeer(t, NewMemoryBroker(), "viewer", 600)
+	dir := filepath.Join(t.TempDir(), "subs")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	share := NewSubtitleShare(&Room{}, viewer.client, viewer.ctrl, nil, nil, dir, nil)
+
+	// 只保留文件名，不会写到目录之外
+	err := share.apply(model.SubtitleChoice{Source: model.SubtitleUpload, Path: "../../evil.srt", Data: []byte("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "evil.srt")); err != nil {
+		t.Errorf("upload should be saved inside the upload dir: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "evil.srt")); err == nil {
+		t.Error("upload escaped the upload dir")
+	}
+	cmd, err := viewer.server.WaitCommand("sub-add", time.Second)
+	if err != nil || cmd[1] != filepath.Join(dir, "evil.srt") {
+		t.Errorf("got %v (%v), want sub-add of the saved file", cmd, err)
+	}
+
+	for _, name := range []string{"..", "/", ""} {
+		choice := model.SubtitleChoice{Source: model.SubtitleUpload, Path: name, Data: []byte("1")}
+		if err := share.apply(choice); err == nil {
+			t.Errorf("upload named %q should be rejected", name)
+		}
+	}
+	big := model.SubtitleChoice{Source: model.SubtitleUpload, Path: "big.srt", Data: make([]byte, model.MaxSubtitleUpload+1)}
+	if err := share.apply(big); err == nil {
+		t.Error("oversized upload should be rejected")
+	}
+}
@@ -2,42 +2,68 @@ package sync
 
 import (
 	"fmt"
+	"log/slog"
+	"math"
+	gosync "sync"
 	"time"
 
 	"movie-night/model"
-	"movie-night/pkg/mpv"
+	"movie-night/pkg/logging"
 )
 
 // Syncer 同步器
 type Syncer struct {
-	mpvCtrl   *mpv.Controller
+	mpvCtrl   Player
 	validator *Validator
 	statusCh  chan model.PlayStatus
+	stopCh    chan struct{}
+
+	mu        gosync.Mutex
+	last      model.PlayStatus // 最近收到的状态
+	lastAt    time.Time        // 收到的时间
+	appliedAt time.Time        // 最近一次应用到 MPV 的时间
+	busy      bool             // 其他流程（如中途加入的追赶）正在操作 MPV
+	tracks    model.PlayStatus // 最近应用的同步属性（只在房主改变时才设置，本地可以临时调整）
+	localSub  bool             // 正在使用自己选择的字幕，不同步字幕轨道
+
+	itemMu gosync.Mutex // 串行化播放列表切换
+	item   int          // 本地当前的播放列表项
+	clock  Clock
+	logger *slog.Logger
 }
 
 // NewSyncer 创建同步器
-func NewSyncer(mpvCtrl *mpv.Controller, maxDuration float64) *Syncer {
+func NewSyncer(mpvCtrl Player, maxDuration float64, logger *slog.Logger) *Syncer {
 	return &Syncer{
+		logger:    logging.Component(logger, "Syncer"),
 		mpvCtrl:   mpvCtrl,
+		clock:     realClock{},
 		validator: NewValidator(maxDuration),
 		statusCh:  make(chan model.PlayStatus, 1), // 只保留最新状态
+		stopCh:    make(chan struct{}),
 	}
 }
 
+// SetClock 替换时间来源（回放工具用虚拟时钟，需在开始同步之前调用）
+func (s *Syncer) SetClock(clock Clock) {
+	s.clock = clock
+}
+
 // HandleStatus 处理新的播放状态
 func (s *Syncer) HandleStatus(status model.PlayStatus) {
 	// 1. 验证状态
 	if err := s.validator.Validate(status); err != nil {
-		fmt.Printf("⚠️  状态无效: %v\n", err)
+		s.logger.Warn("状态无效", "err", err)
 		return
 	}
 
-	// 2. 显示接收信息
-	pausedStr := "▶️"
-	if status.Paused {
-		pausedStr = "⏸️"
-	}
-	fmt.Printf("📥 收到: %.2f秒 %s\n", status.Timestamp, pausedStr)
+	// 2. 记录接收信息
+	s.logger.Debug("收到", "pos", status.Timestamp, "paused", status.Paused)
+
+	s.mu.Lock()
+	s.last = status
+	s.lastAt = s.clock.Now()
+	s.mu.Unlock()
 
 	// 3. 发送到处理队列（非阻塞，只保留最新）
 	select {
@@ -48,36 +74,152 @@ func (s *Syncer) HandleStatus(status model.PlayStatus) {
 		select {
 		case <-s.statusCh:
 			s.statusCh <- status
-			fmt.Println("⚠️  更新为最新状态")
+			s.logger.Debug("更新为最新状态")
 		default:
 		}
 	}
 }
 
+// Apply 同步地验证并应用一个状态（不经过队列，回放工具用来得到确定的结果）
+func (s *Syncer) Apply(status model.PlayStatus) error {
+	if err := s.validator.Validate(status); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.last = status
+	s.lastAt = s.clock.Now()
+	s.mu.Unlock()
+
+	s.syncToMPV(status)
+	return nil
+}
+
 // Start 启动同步处理
 func (s *Syncer) Start() {
 	go s.processLoop()
+	go s.refreshMedia()
+}
+
+// mediaLoadTimeout 切换播放列表项后等待加载完成的最长时间
+const mediaLoadTimeout = 10 * time.Second
+
+// refreshMedia 读取本地当前项的时长和章节，供验证器使用
+func (s *Syncer) refreshMedia() {
+	s.itemMu.Lock()
+	defer s.itemMu.Unlock()
+
+	if pos, err := s.mpvCtrl.GetProperty("playlist-pos"); err == nil {
+		if f, ok := pos.(float64); ok && f >= 0 {
+			s.item = int(f)
+		}
+	}
+	s.loadMedia(s.item)
+}
+
+// loadMedia 等待当前项加载完成后更新验证器
+func (s *Syncer) loadMedia(item int) {
+	deadline := s.clock.Now().Add(mediaLoadTimeout)
+	duration, err := s.mpvCtrl.GetDuration()
+	for err != nil && s.clock.Now().Before(deadline) {
+		s.clock.Sleep(200 * time.Millisecond)
+		duration, err = s.mpvCtrl.GetDuration()
+	}
+	if err != nil {
+		s.logger.Warn("无法获取时长", "item", item+1, "err", err)
+	}
+
+	// 没有章节的视频 chapter-list 为空数组
+	chapters, _ := s.mpvCtrl.GetChapters()
+	s.validator.SetMedia(item, duration, chapters)
+	s.logger.Info("载入播放项", "item", item+1, "duration", duration, "chapters", len(chapters))
+}
+
+// LoadItem 切换到播放列表中的指定项并等待加载完成（已经是该项时不做任何事）
+func (s *Syncer) LoadItem(item int) {
+	s.itemMu.Lock()
+	defer s.itemMu.Unlock()
+
+	if item == s.item {
+		return
+	}
+
+	s.logger.Info("切换播放列表项", "item", item+1)
+	s.SetBusy(true)
+	defer s.SetBusy(false)
+
+	if err := s.mpvCtrl.SetPlaylistPos(item); err != nil {
+		s.logger.Error("切换失败", "err", err)
+		return
+	}
+	s.item = item
+
+	// 等旧文件的时长不再可见，避免把上一项的时长当成新项的
+	s.clock.Sleep(500 * time.Millisecond)
+	s.loadMedia(item)
+
+	s.mu.Lock()
+	s.appliedAt = s.clock.Now()
+	s.mu.Unlock()
+}
+
+// JumpChapter 跟随房主跳到指定章节
+func (s *Syncer) JumpChapter(event model.PlaybackEvent) error {
+	if err := s.validator.ValidateChapter(event); err != nil {
+		return err
+	}
+	if err := s.mpvCtrl.SetChapter(event.Chapter); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.appliedAt = s.clock.Now()
+	s.mu.Unlock()
+
+	s.mpvCtrl.ShowText(fmt.Sprintf("📖 %s", s.validator.ChapterTitle(event.Chapter)), 2000)
+	return nil
 }
 
 // processLoop 处理循环
 func (s *Syncer) processLoop() {
-	for status := range s.statusCh {
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case status := <-s.statusCh:
+			s.syncToMPV(status)
+		}
+	}
+}
+
+// flush 立即处理待同步的状态（代替 processLoop，用于单线程的网络模拟）
+func (s *Syncer) flush() {
+	select {
+	case status := <-s.statusCh:
 		s.syncToMPV(status)
+	default:
 	}
 }
 
 // syncToMPV 同步到 MPV
 func (s *Syncer) syncToMPV(status model.PlayStatus) {
-	fmt.Printf("🎬 同步: %.2f秒, 暂停=%v\n", status.Timestamp, status.Paused)
+	s.logger.Debug("同步", "pos", status.Timestamp, "paused", status.Paused)
 
-	// 1. 跳转到指定位置
-	if err := s.mpvCtrl.Seek(status.Timestamp, "absolute"); err != nil {
-		fmt.Printf("❌ 跳转失败: %v\n", err)
-		return
-	}
+	// 0. 房主在播放列表的另一项
+	s.LoadItem(status.Item)
 
-	// 2. 短暂延迟，让跳转完成
-	time.Sleep(50 * time.Millisecond)
+	// 1. 偏差超出容差时跳转到指定位置
+	local, paused, known := s.localPosition()
+	if needsSeek(local, status.Timestamp, known, paused || status.Paused) {
+		if err := s.mpvCtrl.Seek(status.Timestamp, "absolute"); err != nil {
+			s.logger.Error("跳转失败", "err", err)
+			return
+		}
+		metricSeeks.Inc()
+
+		// 2. 短暂延迟，让跳转完成
+		s.clock.Sleep(50 * time.Millisecond)
+	}
 
 	// 3. 设置暂停状态
 	if status.Paused {
@@ -89,9 +231,108 @@ func (s *Syncer) syncToMPV(status model.PlayStatus) {
 		s.mpvCtrl.Play()
 		// s.mpvCtrl.sendCommand("set_property", "pause", false)
 	}
+
+	// 4. 同步播放速度和轨道
+	s.syncTracks(status)
+
+	s.mu.Lock()
+	s.appliedAt = s.clock.Now()
+	s.mu.Unlock()
+}
+
+// driftTolerance 播放中与房主的偏差不超过该值（秒）时不跳转（MPV 和浏览器跟随端相同）
+const driftTolerance = 0.3
+
+// needsSeek 决定跟随端是否要跳转到 target：本地位置未知或暂停时总是跳转（暂停中跳转不影响观看），
+// 播放中偏差不超过 driftTolerance 时不跳转，避免每次同步都打断播放
+func needsSeek(local, target float64, known, paused bool) bool {
+	return !known || paused || math.Abs(local-target) > driftTolerance
+}
+
+// localPosition 读取本地的播放位置和暂停状态，读不到位置时 known 为 false
+func (s *Syncer) localPosition() (position float64, paused, known bool) {
+	pos, err := s.mpvCtrl.GetProperty("time-pos")
+	if err != nil {
+		return 0, false, false
+	}
+	position, known = pos.(float64)
+	if p, err := s.mpvCtrl.GetProperty("pause"); err == nil {
+		paused, _ = p.(bool)
+	}
+	return position, paused, known
+}
+
+// syncTracks 应用房主改变过的播放速度、音轨和字幕轨道
+func (s *Syncer) syncTracks(status model.PlayStatus) {
+	s.mu.Lock()
+	last := s.tracks
+	s.tracks = status
+	localSub := s.localSub
+	s.mu.Unlock()
+
+	if status.Speed > 0 && status.Speed != last.Speed {
+		s.logger.Info("同步速度", "speed", status.Speed)
+		if err := s.mpvCtrl.SetSpeed(status.Speed); err != nil {
+			s.logger.Error("设置速度失败", "err", err)
+		}
+	}
+	if status.Aid != "" && status.Aid != last.Aid {
+		s.logger.Info("同步音轨", "aid", status.Aid)
+		if err := s.mpvCtrl.SetAudioTrack(status.Aid); err != nil {
+			s.logger.Error("设置音轨失败", "err", err)
+		}
+	}
+	if status.Sid != "" && status.Sid != last.Sid && !localSub {
+		s.logger.Info("同步字幕轨道", "sid", status.Sid)
+		if err := s.mpvCtrl.SetProperty("sid", status.Sid); err != nil {
+			s.logger.Error("设置字幕失败", "err", err)
+		}
+	}
+}
+
+// SetLocalSubtitle 标记是否正在使用自己选择的字幕，期间不跟随房主的字幕轨道
+func (s *Syncer) SetLocalSubtitle(local bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.localSub = local
+}
+
+// Settling 是否刚刚应用过同步（这段时间内 MPV 的状态变化来自同步而不是用户）
+func (s *Syncer) Settling(window time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.busy || s.clock.Now().Sub(s.appliedAt) < window
+}
+
+// SetBusy 标记其他流程正在操作 MPV，期间的状态变化同样不算用户操作
+func (s *Syncer) SetBusy(busy bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.busy = busy
+}
+
+// Resync 重新应用最近收到的状态（按经过的时间推算当前位置）
+// 用于撤销本地未被允许的操作
+func (s *Syncer) Resync() {
+	s.mu.Lock()
+	if s.lastAt.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	status := s.last
+	if !status.Paused {
+		status.Timestamp += s.clock.Now().Sub(s.lastAt).Seconds() * status.Rate()
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.statusCh <- status:
+	default:
+	}
 }
 
 // Stop 停止同步
+// 停止后仍可能收到在途的消息，因此不关闭 statusCh
 func (s *Syncer) Stop() {
-	close(s.statusCh)
+	close(s.stopCh)
 }
@@ -0,0 +1,158 @@
+package sync
+
+import (
+	"bufio"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+)
+
+// Syncplay 协议兼容：作为客户端加入已有的 Syncplay 服务器房间，或在房主端内嵌一个最小的 Syncplay 服务器。
+// 两种方式都通过 Remote 执行房间动作（和房主自己的操作走同一流程），Syncplay 一侧的状态映射为 PlayStatus、准备状态和文件信息
+
+const (
+	syncplayVersion     = "1.2.255" // 协议版本
+	syncplayRealVersion = "1.7.0"   // 对外声明的软件版本
+
+	syncplayStateInterval = time.Second      // 服务器发送播放状态的间隔
+	syncplayDriftLimit    = 5.0              // 与 Syncplay 房间相差超过该值（秒）时跳转
+	syncplayCooldown      = 3 * time.Second  // 同一动作的最短间隔（请求被房间策略拒绝时避免反复请求）
+	syncplayTimeout       = 10 * time.Second // 连接和发送的超时
+	syncplayMaxLine       = 64 << 10         // 单条消息的最大长度
+
+	// syncplayChatPrefix 从 Syncplay 转发到房间的聊天消息 From 的前缀，转发回 Syncplay 时跳过
+	syncplayChatPrefix = "syncplay:"
+)
+
+// SyncplayConfig Syncplay 兼容的配置
+type SyncplayConfig struct {
+	Server   string // 客户端模式：要加入的 Syncplay 服务器（host:port）
+	Listen   string // 服务器模式：监听地址（如 :8999）
+	Room     string // Syncplay 房间名
+	Password string // 服务器密码（可为空）
+	Name     string // 自己的名称
+}
+
+// syncplayConn Syncplay 连接：每行一个 JSON 对象，行尾为 \r\n
+type syncplayConn struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	writeMu gosync.Mutex
+}
+
+// newSyncplayConn 包装连接
+func newSyncplayConn(conn net.Conn) *syncplayConn {
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 4096), syncplayMaxLine)
+	return &syncplayConn{conn: conn, scanner: scanner}
+}
+
+// send 发送一条消息
+func (c *syncplayConn) send(kind string, payload any) error {
+	data, err := json.Marshal(map[string]any{kind: payload})
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(syncplayTimeout))
+	_, err = c.conn.Write(append(data, '\r', '\n'))
+	return err
+}
+
+// receive 读取下一条消息（消息类型 -> 内容），跳过空行
+func (c *syncplayConn) receive() (map[string]json.RawMessage, error) {
+	for c.scanner.Scan() {
+		line := strings.TrimSpace(c.scanner.Text())
+		if line == "" {
+			continue
+		}
+		var msg map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			return nil, fmt.Errorf("Syncplay 消息格式错误: %w", err)
+		}
+		return msg, nil
+	}
+	if err := c.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, net.ErrClosed
+}
+
+// close 关闭连接
+func (c *syncplayConn) close() error {
+	return c.conn.Close()
+}
+
+// syncplayPassword Syncplay 传输的密码（MD5 十六进制），空密码不传
+func syncplayPassword(password string) string {
+	if password == "" {
+		return ""
+	}
+	sum := md5.Sum([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+// syncplayNow Syncplay 使用的时间戳（Unix 秒）
+func syncplayNow() float64 {
+	return float64(time.Now().UnixNano()) / float64(time.Second)
+}
+
+// syncplayPlayState 本地播放状态转换为 Syncplay 的播放状态
+func syncplayPlayState(status model.PlayStatus, doSeek bool, setBy string) model.SyncplayPlayState {
+	return model.SyncplayPlayState{
+		Position: status.Timestamp,
+		Paused:   status.Paused,
+		DoSeek:   doSeek,
+		SetBy:    setBy,
+	}
+}
+
+// syncplayAction 对比 Syncplay 一侧的播放状态和本地状态，得到需要执行的房间动作
+// 跳转优先，其次是暂停/继续；follow 为 true 时（跟随 Syncplay 服务器）偏差过大也跳转。self 改变的状态（自己操作的回显）不处理
+func syncplayAction(local model.PlayStatus, state model.SyncplayPlayState, self string, follow bool) (model.ControlAction, float64, bool) {
+	if state.SetBy != "" && state.SetBy == self {
+		return "", 0, false
+	}
+	switch {
+	case state.DoSeek:
+		return model.ActionSeek, state.Position, true
+	case state.Paused != local.Paused:
+		if state.Paused {
+			return model.ActionPause, 0, true
+		}
+		return model.ActionResume, 0, true
+	case follow && math.Abs(state.Position-local.Timestamp) > syncplayDriftLimit:
+		return model.ActionSeek, state.Position, true
+	}
+	return "", 0, false
+}
+
+// syncplayThrottle 限制同一动作的频率
+type syncplayThrottle struct {
+	action model.ControlAction
+	at     time.Time
+}
+
+// allow 距离上次相同动作超过 syncplayCooldown 时放行并记录
+func (t *syncplayThrottle) allow(action model.ControlAction) bool {
+	if action == t.action && time.Since(t.at) < syncplayCooldown {
+		return false
+	}
+	t.action, t.at = action, time.Now()
+	return true
+}
+
+// decodeSyncplay 解析消息内容，失败时返回 false
+func decodeSyncplay(raw json.RawMessage, v any) bool {
+	return json.Unmarshal(raw, v) == nil
+}
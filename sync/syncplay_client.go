@@ -0,0 +1,365 @@
+package sync
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+)
+
+// SyncplayClient 以一个 Syncplay 用户的身份加入 Syncplay 服务器的房间：
+// Syncplay 房间里的暂停、跳转按本房间策略执行，本房间的变化、准备状态、文件信息和聊天也同步给 Syncplay 房间
+type SyncplayClient struct {
+	remote *Remote
+	cfg    SyncplayConfig
+	conn   *syncplayConn
+	events <-chan model.RoomEvent
+	stopCh chan struct{}
+
+	mu             gosync.Mutex
+	name           string                  // 服务器确认后的名称（重名时服务器会改名）
+	server         model.SyncplayPlayState // 服务器最近的播放状态
+	serverAt       time.Time
+	serverIgnoring int     // 服务器的状态变化计数，下次发送时回传
+	clientIgnoring int     // 自己的状态变化计数，服务器确认之前忽略服务器发来的状态
+	latency        float64 // 服务器的时间戳，下次发送时回传
+	rtt            float64
+	ready          *bool // 最近上报的准备状态
+	item           int   // 最近上报文件时的播放列表位置
+	throttle       syncplayThrottle
+	logger         *slog.Logger
+}
+
+// NewSyncplayClient 创建 Syncplay 客户端，cfg.Server 为服务器地址
+func NewSyncplayClient(remote *Remote, cfg SyncplayConfig, logger *slog.Logger) *SyncplayClient {
+	return &SyncplayClient{
+		logger: logging.Component(logger, "Syncplay"),
+		remote: remote,
+		cfg:    cfg,
+		name:   cfg.Name,
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start 连接服务器并加入房间
+func (c *SyncplayClient) Start() error {
+	conn, err := net.DialTimeout("tcp", c.cfg.Server, syncplayTimeout)
+	if err != nil {
+		return fmt.Errorf("连接 Syncplay 服务器失败: %w", err)
+	}
+	c.conn = newSyncplayConn(conn)
+
+	hello := model.SyncplayHello{
+		Username:    c.cfg.Name,
+		Password:    syncplayPassword(c.cfg.Password),
+		Room:        model.SyncplayRoom{Name: c.cfg.Room},
+		Version:     syncplayVersion,
+		RealVersion: syncplayRealVersion,
+		Features: map[string]any{
+			"chat":            true,
+			"readiness":       true,
+			"featureList":     true,
+			"sharedPlaylists": false,
+			"managedRooms":    false,
+		},
+	}
+	if err := c.conn.send("Hello", hello); err != nil {
+		c.conn.close()
+		return fmt.Errorf("Syncplay 握手失败: %w", err)
+	}
+
+	c.events = c.remote.Subscribe()
+	go c.readLoop()
+	go c.forwardLoop()
+	return nil
+}
+
+// Stop 断开服务器
+func (c *SyncplayClient) Stop() {
+	close(c.stopCh)
+	c.remote.Unsubscribe(c.events)
+	c.conn.close()
+}
+
+// readLoop 处理服务器的消息，直到连接断开
+func (c *SyncplayClient) readLoop() {
+	for {
+		msg, err := c.conn.receive()
+		if err != nil {
+			select {
+			case <-c.stopCh:
+			default:
+				c.logger.Warn("Syncplay 连接断开", "err", err)
+				c.remote.mpvCtrl.ShowText("⚠️ Syncplay 连接断开", 3000)
+			}
+			return
+		}
+		for kind, raw := range msg {
+			c.handle(kind, raw)
+		}
+	}
+}
+
+// handle 处理一条消息
+func (c *SyncplayClient) handle(kind string, raw json.RawMessage) {
+	switch kind {
+	case "Hello":
+		var hello model.SyncplayHello
+		if !decodeSyncplay(raw, &hello) {
+			return
+		}
+		c.mu.Lock()
+		c.name = hello.Username
+		c.mu.Unlock()
+
+		c.logger.Info("已加入 Syncplay 房间", "room", hello.Room.Name, "name", hello.Username, "server", hello.RealVersion)
+		c.remote.mpvCtrl.ShowText(fmt.Sprintf("🔗 已加入 Syncplay 房间 %s", hello.Room.Name), 3000)
+		c.sendFile()
+		c.sendReady()
+		c.conn.send("List", nil)
+
+	case "State":
+		var state model.SyncplayState
+		if decodeSyncplay(raw, &state) {
+			c.handleState(state)
+		}
+
+	case "Set":
+		var set model.SyncplaySet
+		if decodeSyncplay(raw, &set) {
+			c.handleSet(set)
+		}
+
+	case "List":
+		var rooms map[string]map[string]model.SyncplayListUser
+		if decodeSyncplay(raw, &rooms) {
+			for room, users := range rooms {
+				names := make([]string, 0, len(users))
+				for name := range users {
+					names = append(names, name)
+				}
+				c.logger.Info("Syncplay 房间", "room", room, "users", names)
+			}
+		}
+
+	case "Chat":
+		var chat model.SyncplayChat
+		if !decodeSyncplay(raw, &chat) || chat.Username == c.selfName() {
+			return
+		}
+		if err := c.remote.chat.Relay(syncplayChatPrefix+chat.Username, chat.Username+" (Syncplay)", chat.Message); err != nil {
+			c.logger.Warn("转发 Syncplay 聊天失败", "err", err)
+		}
+
+	case "Error":
+		var syncErr model.SyncplayError
+		decodeSyncplay(raw, &syncErr)
+		c.logger.Warn("Syncplay 服务器报错", "message", syncErr.Message)
+		c.remote.mpvCtrl.ShowText(fmt.Sprintf("⚠️ Syncplay: %s", syncErr.Message), 4000)
+		c.conn.close()
+	}
+}
+
+// handleState 处理服务器的播放状态：按需执行房间动作，然后回复自己的状态
+func (c *SyncplayClient) handleState(state model.SyncplayState) {
+	now := syncplayNow()
+
+	c.mu.Lock()
+	if ignoring := state.IgnoringOnTheFly; ignoring != nil {
+		if ignoring.Server != 0 {
+			c.serverIgnoring = ignoring.Server
+			c.clientIgnoring = 0
+		} else if ignoring.Client != 0 && ignoring.Client == c.clientIgnoring {
+			c.clientIgnoring = 0
+		}
+	}
+	if ping := state.Ping; ping != nil {
+		c.latency = ping.LatencyCalculation
+		if ping.ClientLatencyCalculation != 0 {
+			c.rtt = now - ping.ClientLatencyCalculation
+		}
+	}
+	apply := state.PlayState != nil && c.clientIgnoring == 0
+	if apply {
+		c.server, c.serverAt = *state.PlayState, time.Now()
+	}
+	name := c.name
+	c.mu.Unlock()
+
+	if apply {
+		action, position, ok := syncplayAction(c.remote.Status(), *state.PlayState, name, true)
+		if ok && c.allow(action) {
+			c.logger.Info("跟随 Syncplay 房间", "action", action, "position", position, "by", state.PlayState.SetBy)
+			if err := c.remote.Control(action, position); err != nil {
+				c.logger.Warn("执行 Syncplay 房间的动作失败", "action", action, "err", err)
+			}
+		}
+	}
+
+	c.sendReady()
+	c.sendState(false, false)
+}
+
+// handleSet 显示 Syncplay 房间里其他人的加入、离开、文件和准备状态
+func (c *SyncplayClient) handleSet(set model.SyncplaySet) {
+	self := c.selfName()
+	for name, user := range set.User {
+		if name == self {
+			continue
+		}
+		switch {
+		case user.Event != nil && user.Event.Joined:
+			c.remote.mpvCtrl.ShowText(fmt.Sprintf("🔗 %s 加入了 Syncplay 房间", name), 3000)
+		case user.Event != nil && user.Event.Left:
+			c.remote.mpvCtrl.ShowText(fmt.Sprintf("👋 %s 离开了 Syncplay 房间", name), 3000)
+		case user.File != nil:
+			current, _ := c.remote.CurrentItem()
+			if current != "" && user.File.Name != filepath.Base(current) {
+				c.remote.mpvCtrl.ShowText(fmt.Sprintf("⚠️ %s 播放的文件不同: %s", name, user.File.Name), 4000)
+			}
+		}
+	}
+
+	if ready := set.Ready; ready != nil && ready.Username != self {
+		if ready.IsReady {
+			c.remote.mpvCtrl.ShowText(fmt.Sprintf("✅ %s 已准备好", ready.Username), 1500)
+		} else {
+			c.remote.mpvCtrl.ShowText(fmt.Sprintf("⏳ %s 取消准备", ready.Username), 1500)
+		}
+	}
+}
+
+// forwardLoop 把本房间的变化和聊天发给 Syncplay 房间
+func (c *SyncplayClient) forwardLoop() {
+	for {
+		select {
+		case <-c.stopCh:
+			return
+		case event := <-c.events:
+			switch event.Kind {
+			case model.RoomEventStatus:
+				c.handleLocal(*event.Status, event.Seek)
+			case model.RoomEventChat:
+				msg := event.Chat
+				if strings.HasPrefix(msg.From, syncplayChatPrefix) {
+					continue
+				}
+				text := msg.Text
+				if msg.From != c.remote.presence.Self().ID {
+					text = msg.Name + ": " + text
+				}
+				c.conn.send("Chat", text)
+			}
+		}
+	}
+}
+
+// handleLocal 本地状态变化：与 Syncplay 房间不一致时（本房间的操作）作为自己的状态变化发送
+func (c *SyncplayClient) handleLocal(status model.PlayStatus, seek bool) {
+	c.mu.Lock()
+	item, server, serverAt := c.item, c.server, c.serverAt
+	c.mu.Unlock()
+
+	if status.Item != item {
+		c.sendFile()
+	}
+	if serverAt.IsZero() {
+		return
+	}
+
+	// 执行 Syncplay 房间的动作之后，本地状态已经和 Syncplay 房间一致，不需要再发
+	expected := server.Position
+	if !server.Paused {
+		expected += time.Since(serverAt).Seconds()
+	}
+	diverged := math.Abs(status.Timestamp-expected) > remoteSeekThreshold
+	if status.Paused == server.Paused && !diverged {
+		return
+	}
+
+	c.mu.Lock()
+	c.server, c.serverAt = syncplayPlayState(status, false, c.name), time.Now()
+	c.mu.Unlock()
+	c.sendState(true, seek)
+}
+
+// sendState 发送自己的播放状态，change 表示这是自己发起的变化
+func (c *SyncplayClient) sendState(change, doSeek bool) {
+	status := c.remote.Status()
+
+	c.mu.Lock()
+	state := model.SyncplayState{
+		Ping: &model.SyncplayPing{
+			LatencyCalculation:       c.latency,
+			ClientLatencyCalculation: syncplayNow(),
+			ClientRtt:                c.rtt,
+		},
+	}
+	if c.clientIgnoring == 0 || c.serverIgnoring != 0 {
+		playState := syncplayPlayState(status, doSeek, "")
+		state.PlayState = &playState
+	}
+	if change {
+		c.clientIgnoring++
+	}
+	if c.serverIgnoring != 0 || c.clientIgnoring != 0 {
+		state.IgnoringOnTheFly = &model.SyncplayIgnoring{Server: c.serverIgnoring, Client: c.clientIgnoring}
+		c.serverIgnoring = 0
+	}
+	c.mu.Unlock()
+
+	if err := c.conn.send("State", state); err != nil {
+		c.logger.Debug("发送播放状态失败", "err", err)
+	}
+}
+
+// sendFile 上报正在播放的文件
+func (c *SyncplayClient) sendFile() {
+	name, duration := c.remote.CurrentItem()
+
+	c.mu.Lock()
+	c.item = c.remote.Status().Item
+	c.mu.Unlock()
+
+	if name == "" {
+		return
+	}
+	file := model.SyncplayFile{Name: filepath.Base(name), Duration: duration, Size: 0}
+	c.conn.send("Set", model.SyncplaySet{File: &file})
+}
+
+// sendReady 准备状态有变化时上报
+func (c *SyncplayClient) sendReady() {
+	ready := c.remote.presence.Self().Ready
+
+	c.mu.Lock()
+	changed := c.ready == nil || *c.ready != ready
+	c.ready = &ready
+	c.mu.Unlock()
+
+	if changed {
+		c.conn.send("Set", model.SyncplaySet{Ready: &model.SyncplayReady{IsReady: ready, ManuallyInitiated: true}})
+	}
+}
+
+// allow 限制同一动作的频率
+func (c *SyncplayClient) allow(action model.ControlAction) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.throttle.allow(action)
+}
+
+// selfName 服务器确认后的名称
+func (c *SyncplayClient) selfName() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.name
+}
@@ -0,0 +1,561 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"time"
+
+	"movie-night/model"
+	"movie-night/pkg/logging"
+)
+
+// syncplayServerName 服务器发出的聊天消息使用的名称
+const syncplayServerName = "Movie Night"
+
+// SyncplayServer 内嵌在房主端的最小 Syncplay 服务器（只有一个房间）：
+// Syncplay 用户连进来后跟随本房间的播放，他们的暂停、跳转按房间策略执行，聊天和准备状态与本房间互通
+// 只在本机是房主时监听：成为房主时开始监听，房主转移后停止并断开所有 Syncplay 用户
+type SyncplayServer struct {
+	remote *Remote
+	cfg    SyncplayConfig
+	events <-chan model.RoomEvent
+	stopCh chan struct{}
+
+	mu       gosync.Mutex
+	listener net.Listener // 本机不是房主时为空
+	watchers map[*syncplayWatcher]struct{}
+	expect   *model.SyncplayPlayState // Syncplay 用户的操作还没在本地生效时，对外报告操作后的状态
+	expectAt time.Time
+	setBy    string // 最近由 Syncplay 用户发起的变化
+	setByAt  time.Time
+	logger   *slog.Logger
+}
+
+// syncplayWatcher 一个连接进来的 Syncplay 用户
+type syncplayWatcher struct {
+	conn *syncplayConn
+	name string
+
+	mu              gosync.Mutex
+	ready           bool
+	file            *model.SyncplayFile
+	position        float64
+	serverIgnoring  int     // 自己发起的状态变化计数，对方回传之前忽略对方的状态
+	clientIgnoring  int     // 对方的状态变化计数，下次发送时回传
+	clientLatency   float64 // 对方的时间戳，下次发送时回传
+	clientLatencyAt time.Time
+	rtt             float64
+	throttle        syncplayThrottle
+}
+
+// NewSyncplayServer 创建 Syncplay 服务器，cfg.Listen 为监听地址，cfg.Room 为房间名
+func NewSyncplayServer(remote *Remote, cfg SyncplayConfig, logger *slog.Logger) *SyncplayServer {
+	return &SyncplayServer{
+		logger:   logging.Component(logger, "Syncplay"),
+		remote:   remote,
+		cfg:      cfg,
+		stopCh:   make(chan struct{}),
+		watchers: make(map[*syncplayWatcher]struct{}),
+	}
+}
+
+// Start 本机是房主时开始监听（后台），之后随房主变化开始或停止监听
+func (s *SyncplayServer) Start() error {
+	if s.remote.room.IsHost() {
+		if err := s.listen(); err != nil {
+			return err
+		}
+	} else {
+		s.logger.Info("本机不是房主，成为房主后再监听", "listen", s.cfg.Listen)
+	}
+
+	s.events = s.remote.Subscribe()
+	go s.broadcastLoop()
+	return nil
+}
+
+// Stop 停止监听并断开所有 Syncplay 用户
+func (s *SyncplayServer) Stop() {
+	s.mu.Lock()
+	close(s.stopCh)
+	s.mu.Unlock()
+
+	s.remote.Unsubscribe(s.events)
+	s.unlisten()
+}
+
+// listen 开始监听并接受连接
+func (s *SyncplayServer) listen() error {
+	listener, err := net.Listen("tcp", s.cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("Syncplay 服务器监听失败: %w", err)
+	}
+	s.mu.Lock()
+	select {
+	case <-s.stopCh:
+		// 监听期间已经停止
+		s.mu.Unlock()
+		listener.Close()
+		return nil
+	default:
+	}
+	s.listener = listener
+	s.mu.Unlock()
+	s.logger.Info("开始监听", "addr", listener.Addr())
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return nil
+}
+
+// unlisten 停止监听并断开所有 Syncplay 用户
+func (s *SyncplayServer) unlisten() {
+	s.mu.Lock()
+	listener := s.listener
+	s.listener = nil
+	s.mu.Unlock()
+
+	if listener != nil {
+		listener.Close()
+	}
+	for _, w := range s.snapshot() {
+		w.conn.close()
+	}
+}
+
+// followHost 房主变化后：本机成为房主时开始监听，不再是房主时停止
+func (s *SyncplayServer) followHost(claim model.HostClaim) {
+	isHost := claim.ID == s.remote.presence.Self().ID
+
+	s.mu.Lock()
+	listening := s.listener != nil
+	s.mu.Unlock()
+
+	switch {
+	case isHost && !listening:
+		if err := s.listen(); err != nil {
+			s.logger.Warn("Syncplay 服务器不可用", "err", err)
+		}
+	case !isHost && listening:
+		s.logger.Info("房主已转移，停止监听", "host", claim.Name)
+		s.broadcast("Chat", model.SyncplayChat{Username: syncplayServerName, Message: fmt.Sprintf("👑 房主已转移给 %s，请连接新房主的 Syncplay 服务器", claim.Name)})
+		s.unlisten()
+	}
+}
+
+// serve 处理一个连接：握手后加入房间，直到断开
+func (s *SyncplayServer) serve(conn net.Conn) {
+	sc := newSyncplayConn(conn)
+	defer sc.close()
+
+	w, err := s.handshake(sc)
+	if err != nil {
+		s.logger.Info("Syncplay 用户握手失败", "addr", conn.RemoteAddr(), "err", err)
+		sc.send("Error", model.SyncplayError{Message: err.Error()})
+		return
+	}
+
+	s.join(w)
+	defer s.leave(w)
+
+	for {
+		msg, err := sc.receive()
+		if err != nil {
+			return
+		}
+		for kind, raw := range msg {
+			s.handle(w, kind, raw)
+		}
+	}
+}
+
+// handshake 处理 Hello（之前可能有 TLS 询问），检查密码并分配不重复的名称
+func (s *SyncplayServer) handshake(sc *syncplayConn) (*syncplayWatcher, error) {
+	for {
+		msg, err := sc.receive()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := msg["TLS"]; ok {
+			// 不支持 TLS，客户端会继续用明文
+			sc.send("TLS", map[string]string{"startTLS": "false"})
+			continue
+		}
+
+		var hello model.SyncplayHello
+		raw, ok := msg["Hello"]
+		if !ok || !decodeSyncplay(raw, &hello) {
+			return nil, errors.New("Not a valid Hello message")
+		}
+		if s.cfg.Password != "" && hello.Password != syncplayPassword(s.cfg.Password) {
+			return nil, errors.New("Wrong password supplied")
+		}
+
+		w := &syncplayWatcher{conn: sc, name: s.uniqueName(strings.TrimSpace(hello.Username))}
+		err = sc.send("Hello", model.SyncplayHello{
+			Username:    w.name,
+			Room:        model.SyncplayRoom{Name: s.cfg.Room},
+			Version:     syncplayVersion,
+			RealVersion: syncplayRealVersion,
+			MOTD:        fmt.Sprintf("Movie Night: %d 人在线", len(s.remote.Participants())),
+			Features: map[string]any{
+				"isolateRooms":         false,
+				"readiness":            true,
+				"managedRooms":         false,
+				"chat":                 true,
+				"maxChatMessageLength": model.MaxChatLength,
+				"maxUsernameLength":    150,
+				"maxRoomNameLength":    35,
+				"maxFilenameLength":    250,
+			},
+		})
+		return w, err
+	}
+}
+
+// uniqueName 与房间参与者和其他 Syncplay 用户重名时在后面加下划线
+func (s *SyncplayServer) uniqueName(name string) string {
+	if name == "" {
+		name = "Syncplay"
+	}
+	taken := make(map[string]bool)
+	for _, p := range s.remote.Participants() {
+		taken[p.Name] = true
+	}
+	for _, w := range s.snapshot() {
+		taken[w.name] = true
+	}
+	for taken[name] {
+		name += "_"
+	}
+	return name
+}
+
+// join 加入房间：通知其他 Syncplay 用户和本房间
+func (s *SyncplayServer) join(w *syncplayWatcher) {
+	s.mu.Lock()
+	s.watchers[w] = struct{}{}
+	s.mu.Unlock()
+
+	s.logger.Info("Syncplay 用户加入", "name", w.name)
+	s.broadcast("Set", model.SyncplaySet{User: map[string]model.SyncplayUser{
+		w.name: {Room: &model.SyncplayRoom{Name: s.cfg.Room}, Event: &model.SyncplayEvent{Joined: true}},
+	}})
+	s.relay(w.name, "🔗 通过 Syncplay 加入了房间")
+	s.sendState(w, false, false)
+}
+
+// leave 离开房间：通知其他 Syncplay 用户和本房间
+func (s *SyncplayServer) leave(w *syncplayWatcher) {
+	s.mu.Lock()
+	delete(s.watchers, w)
+	s.mu.Unlock()
+
+	s.logger.Info("Syncplay 用户离开", "name", w.name)
+	s.broadcast("Set", model.SyncplaySet{User: map[string]model.SyncplayUser{
+		w.name: {Room: &model.SyncplayRoom{Name: s.cfg.Room}, Event: &model.SyncplayEvent{Left: true}},
+	}})
+	s.relay(w.name, "👋 离开了房间")
+}
+
+// handle 处理 Syncplay 用户的一条消息
+func (s *SyncplayServer) handle(w *syncplayWatcher, kind string, raw json.RawMessage) {
+	switch kind {
+	case "State":
+		var state model.SyncplayState
+		if decodeSyncplay(raw, &state) {
+			s.handleState(w, state)
+		}
+
+	case "Set":
+		var set model.SyncplaySet
+		if decodeSyncplay(raw, &set) {
+			s.handleSet(w, set)
+		}
+
+	case "List":
+		s.sendList(w)
+
+	case "Chat":
+		var text string
+		if !decodeSyncplay(raw, &text) || strings.TrimSpace(text) == "" {
+			return
+		}
+		if runes := []rune(text); len(runes) > model.MaxChatLength {
+			text = string(runes[:model.MaxChatLength])
+		}
+		s.broadcast("Chat", model.SyncplayChat{Username: w.name, Message: text})
+		s.relay(w.name, text)
+
+	case "TLS":
+		w.conn.send("TLS", map[string]string{"startTLS": "false"})
+	}
+}
+
+// handleState 处理 Syncplay 用户的播放状态：对方暂停/继续或跳转时按房间策略执行
+func (s *SyncplayServer) handleState(w *syncplayWatcher, state model.SyncplayState) {
+	now := syncplayNow()
+
+	w.mu.Lock()
+	if ignoring := state.IgnoringOnTheFly; ignoring != nil {
+		if ignoring.Server != 0 && ignoring.Server == w.serverIgnoring {
+			w.serverIgnoring = 0
+		}
+		if ignoring.Client != 0 {
+			w.clientIgnoring = ignoring.Client
+		}
+	}
+	if ping := state.Ping; ping != nil {
+		if ping.LatencyCalculation != 0 {
+			w.rtt = now - ping.LatencyCalculation
+		}
+		w.clientLatency, w.clientLatencyAt = ping.ClientLatencyCalculation, time.Now()
+	}
+	// 对方确认自己发出的变化之前，它发来的是旧状态
+	apply := state.PlayState != nil && w.serverIgnoring == 0
+	if state.PlayState != nil {
+		w.position = state.PlayState.Position
+	}
+	w.mu.Unlock()
+
+	if !apply {
+		return
+	}
+	local := s.remote.Status()
+	action, position, ok := syncplayAction(local, *state.PlayState, "", false)
+	if !ok {
+		return
+	}
+	w.mu.Lock()
+	allowed := w.throttle.allow(action)
+	w.mu.Unlock()
+	if !allowed {
+		return
+	}
+
+	s.logger.Info("Syncplay 用户请求", "name", w.name, "action", action, "position", position)
+	if err := s.remote.ControlAs("syncplay:"+w.name, w.name, action, position); err != nil {
+		// 被拒绝或在等待投票：让对方先回到房间的状态
+		s.logger.Info("Syncplay 用户的请求未执行", "name", w.name, "err", err)
+		w.conn.send("Chat", model.SyncplayChat{Username: syncplayServerName, Message: "⚠️ " + err.Error()})
+		s.sendState(w, true, false)
+		return
+	}
+
+	expect := syncplayPlayState(local, false, "")
+	switch action {
+	case model.ActionSeek:
+		expect.Position = position
+	case model.ActionPause:
+		expect.Paused = true
+	case model.ActionResume:
+		expect.Paused = false
+	}
+	s.mu.Lock()
+	s.expect, s.expectAt = &expect, time.Now()
+	s.setBy, s.setByAt = w.name, time.Now()
+	s.mu.Unlock()
+}
+
+// handleSet 处理 Syncplay 用户的准备状态和文件信息
+func (s *SyncplayServer) handleSet(w *syncplayWatcher, set model.SyncplaySet) {
+	if ready := set.Ready; ready != nil {
+		w.mu.Lock()
+		w.ready = ready.IsReady
+		w.mu.Unlock()
+
+		s.broadcast("Set", model.SyncplaySet{Ready: &model.SyncplayReady{
+			Username:          w.name,
+			IsReady:           ready.IsReady,
+			ManuallyInitiated: ready.ManuallyInitiated,
+		}})
+		if ready.IsReady {
+			s.remote.mpvCtrl.ShowText(fmt.Sprintf("✅ %s (Syncplay) 已准备好", w.name), 1500)
+		} else {
+			s.remote.mpvCtrl.ShowText(fmt.Sprintf("⏳ %s (Syncplay) 取消准备", w.name), 1500)
+		}
+	}
+
+	if file := set.File; file != nil {
+		w.mu.Lock()
+		w.file = file
+		w.mu.Unlock()
+
+		s.broadcast("Set", model.SyncplaySet{User: map[string]model.SyncplayUser{
+			w.name: {Room: &model.SyncplayRoom{Name: s.cfg.Room}, File: file},
+		}})
+		if current, _ := s.remote.CurrentItem(); current != "" && file.Name != filepath.Base(current) {
+			s.remote.mpvCtrl.ShowText(fmt.Sprintf("⚠️ %s (Syncplay) 播放的文件不同: %s", w.name, file.Name), 4000)
+		}
+	}
+}
+
+// broadcastLoop 定期发送播放状态，并把本房间的变化、聊天和人员变化推送给 Syncplay 用户
+func (s *SyncplayServer) broadcastLoop() {
+	ticker := time.NewTicker(syncplayStateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+
+		case <-ticker.C:
+			for _, w := range s.snapshot() {
+				s.sendState(w, false, false)
+			}
+
+		case event := <-s.events:
+			switch event.Kind {
+			case model.RoomEventStatus:
+				s.mu.Lock()
+				s.expect = nil
+				s.mu.Unlock()
+				for _, w := range s.snapshot() {
+					s.sendState(w, true, event.Seek)
+				}
+
+			case model.RoomEventChat:
+				if msg := event.Chat; !strings.HasPrefix(msg.From, syncplayChatPrefix) {
+					s.broadcast("Chat", model.SyncplayChat{Username: msg.Name, Message: msg.Text})
+				}
+
+			case model.RoomEventHost:
+				s.followHost(*event.Host)
+
+			case model.RoomEventJoin, model.RoomEventLeave:
+				s.broadcast("Set", model.SyncplaySet{User: map[string]model.SyncplayUser{
+					event.Participant.Name: {
+						Room: &model.SyncplayRoom{Name: s.cfg.Room},
+						Event: &model.SyncplayEvent{
+							Joined: event.Kind == model.RoomEventJoin,
+							Left:   event.Kind == model.RoomEventLeave,
+						},
+					},
+				}})
+			}
+		}
+	}
+}
+
+// playState 要发给 Syncplay 用户的播放状态
+func (s *SyncplayServer) playState() model.SyncplayPlayState {
+	status := s.remote.Status()
+	host := s.remote.room.Host().Name
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	setBy := host
+	if time.Since(s.setByAt) < syncplayCooldown {
+		setBy = s.setBy
+	}
+	if s.expect != nil && time.Since(s.expectAt) < syncplayCooldown {
+		state := *s.expect
+		if !state.Paused {
+			state.Position += time.Since(s.expectAt).Seconds()
+		}
+		state.SetBy = setBy
+		return state
+	}
+	s.expect = nil
+	return syncplayPlayState(status, false, setBy)
+}
+
+// sendState 给一个 Syncplay 用户发送播放状态，forced 表示这是一次变化（对方确认前忽略它的状态）
+func (s *SyncplayServer) sendState(w *syncplayWatcher, forced, doSeek bool) {
+	playState := s.playState()
+	playState.DoSeek = doSeek
+
+	w.mu.Lock()
+	ping := &model.SyncplayPing{LatencyCalculation: syncplayNow(), ServerRtt: w.rtt}
+	if w.clientLatency != 0 {
+		ping.ClientLatencyCalculation = w.clientLatency + time.Since(w.clientLatencyAt).Seconds()
+		w.clientLatency = 0
+	}
+	if forced {
+		w.serverIgnoring++
+	}
+	state := model.SyncplayState{PlayState: &playState, Ping: ping}
+	if w.serverIgnoring != 0 || w.clientIgnoring != 0 {
+		state.IgnoringOnTheFly = &model.SyncplayIgnoring{Server: w.serverIgnoring, Client: w.clientIgnoring}
+		w.clientIgnoring = 0
+	}
+	w.mu.Unlock()
+
+	w.conn.send("State", state)
+}
+
+// sendList 发送用户列表：本房间的参与者（播放同一文件）和 Syncplay 用户
+func (s *SyncplayServer) sendList(w *syncplayWatcher) {
+	status := s.remote.Status()
+	var file any = map[string]any{}
+	if name, duration := s.remote.CurrentItem(); name != "" {
+		file = &model.SyncplayFile{Name: filepath.Base(name), Duration: duration, Size: 0}
+	}
+
+	users := make(map[string]model.SyncplayListUser)
+	for _, p := range s.remote.Participants() {
+		users[p.Name] = model.SyncplayListUser{
+			Position: status.Timestamp,
+			File:     file,
+			IsReady:  p.Ready,
+			Features: map[string]any{},
+		}
+	}
+	for _, other := range s.snapshot() {
+		other.mu.Lock()
+		user := model.SyncplayListUser{
+			Position: other.position,
+			File:     map[string]any{},
+			IsReady:  other.ready,
+			Features: map[string]any{},
+		}
+		if other.file != nil {
+			user.File = other.file
+		}
+		other.mu.Unlock()
+		users[other.name] = user
+	}
+
+	w.conn.send("List", map[string]map[string]model.SyncplayListUser{s.cfg.Room: users})
+}
+
+// broadcast 发给所有 Syncplay 用户
+func (s *SyncplayServer) broadcast(kind string, payload any) {
+	for _, w := range s.snapshot() {
+		w.conn.send(kind, payload)
+	}
+}
+
+// relay 把 Syncplay 用户的消息转发到本房间的聊天
+func (s *SyncplayServer) relay(name, text string) {
+	if err := s.remote.chat.Relay(syncplayChatPrefix+name, name+" (Syncplay)", text); err != nil {
+		s.logger.Warn("转发 Syncplay 聊天失败", "err", err)
+	}
+}
+
+// snapshot 当前的 Syncplay 用户
+func (s *SyncplayServer) snapshot() []*syncplayWatcher {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	watchers := make([]*syncplayWatcher, 0, len(s.watchers))
+	for w := range s.watchers {
+		watchers = append(watchers, w)
+	}
+	return watchers
+}
@@ -0,0 +1,253 @@
+package sync
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"movie-night/model"
+)
+
+func TestSyncplayAction(t *testing.T) {
+	local := model.PlayStatus{Timestamp: 100, Paused: false}
+
+	cases := []struct {
+		name     string
+		state    model.SyncplayPlayState
+		follow   bool
+		action   model.ControlAction
+		position float64
+		ok       bool
+	}{
+		{"一致", model.SyncplayPlayState{Position: 101}, true, "", 0, false},
+		{"暂停", model.SyncplayPlayState{Position: 100, Paused: true}, true, model.ActionPause, 0, true},
+		{"跳转优先", model.SyncplayPlayState{Position: 300, Paused: true, DoSeek: true}, true, model.ActionSeek, 300, true},
+		{"偏差过大时跟随", model.SyncplayPlayState{Position: 120}, true, model.ActionSeek, 120, true},
+		{"服务器模式不跟随偏差", model.SyncplayPlayState{Position: 120}, false, "", 0, false},
+		{"自己的回显", model.SyncplayPlayState{Position: 300, DoSeek: true, SetBy: "me"}, true, "", 0, false},
+	}
+	for _, c := range cases {
+		action, position, ok := syncplayAction(local, c.state, "me", c.follow)
+		if action != c.action || position != c.position || ok != c.ok {
+			t.Errorf("%s: 得到 (%s, %.0f, %v)，期望 (%s, %.0f, %v)", c.name, action, position, ok, c.action, c.position, c.ok)
+		}
+	}
+
+	resume := model.PlayStatus{Timestamp: 100, Paused: true}
+	if action, _, ok := syncplayAction(resume, model.SyncplayPlayState{Position: 100}, "me", true); !ok || action != model.ActionResume {
+		t.Errorf("继续: 得到 (%s, %v)", action, ok)
+	}
+}
+
+func TestSyncplayConn(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	b.SetDeadline(time.Now().Add(5 * time.Second))
+
+	go newSyncplayConn(a).send("State", model.SyncplayState{Ping: &model.SyncplayPing{LatencyCalculation: 1.5}})
+
+	// 每行一条消息，以 \r\n 结尾；serverRtt 总是存在
+	line, err := bufio.NewReader(b).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"State":{"ping":{"latencyCalculation":1.5,"serverRtt":0}}}` + "\r\n"; line != want {
+		t.Errorf("发送 %q，期望 %q", line, want)
+	}
+
+	go b.Write([]byte("\r\n" + `{"Chat":"hi"}` + "\r\n"))
+	msg, err := newSyncplayConn(a).receive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	if !decodeSyncplay(msg["Chat"], &text) || text != "hi" {
+		t.Errorf("收到 %v", msg)
+	}
+}
+
+func TestSyncplayStateFollowsPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		mode model.ControlMode
+		vote bool // 是否发起投票
+	}{
+		{model.ControlHostOnly, false},
+		{model.ControlVote, true},
+	} {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			broker := NewMemoryBroker()
+			host := newPeer(t, broker, "host", 600)
+			viewer := newPeer(t, broker, "viewer", 600)
+
+			presence := NewPresence(host.client, "alice", "Alice", model.RoleHost, nil)
+			if err := presence.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer presence.Leave()
+			other := NewPresence(viewer.client, "carol", "Carol", model.RoleFollower, nil)
+			if err := other.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer other.Leave()
+
+			// 房间里还有一位参与者，投票不会因为只有房主而立即通过
+			for deadline := time.Now().Add(2 * time.Second); presence.Count() < 2; time.Sleep(10 * time.Millisecond) {
+				if time.Now().After(deadline) {
+					t.Fatal("viewer never joined")
+				}
+			}
+
+			room := NewRoom(RoomConfig{
+				MQTTClient:  host.client,
+				MPVCtrl:     host.ctrl,
+				Monitor:     host.monitor,
+				Presence:    presence,
+				Policy:      model.RoomPolicy{Mode: tc.mode, Quorum: 1},
+				Interval:    time.Hour,
+				MaxDuration: 600,
+			})
+			if err := room.Start(true); err != nil {
+				t.Fatal(err)
+			}
+			defer room.Stop()
+
+			votes := make(chan model.VoteState, 4)
+			viewer.client.SubscribeTo(TopicVote, func(payload []byte) {
+				var state model.VoteState
+				if json.Unmarshal(payload, &state) == nil {
+					votes <- state
+				}
+			})
+
+			// Syncplay 用户 Bob 暂停
+			a, b := net.Pipe()
+			defer a.Close()
+			defer b.Close()
+			replies := make(chan string, 4)
+			go func() {
+				r := bufio.NewReader(b)
+				for {
+					line, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					replies <- line
+				}
+			}()
+			s := NewSyncplayServer(NewRemote(room, presence, nil, nil, host.ctrl, nil, nil), SyncplayConfig{Room: "movie"}, nil)
+			w := &syncplayWatcher{conn: newSyncplayConn(a), name: "Bob"}
+			s.handleState(w, model.SyncplayState{PlayState: &model.SyncplayPlayState{Paused: true}})
+
+			// 房主端不执行，Bob 收到提示并回到房间的状态
+			if cmd, err := host.server.WaitCommand("set_property", 500*time.Millisecond); err == nil {
+				t.Errorf("Syncplay request applied as host: %v", cmd)
+			}
+			select {
+			case line := <-replies:
+				if !strings.Contains(line, `"Chat"`) {
+					t.Errorf("got %q, want a chat notice", line)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("no notice sent to the Syncplay user")
+			}
+
+			select {
+			case state := <-votes:
+				if !tc.vote {
+					t.Errorf("unexpected vote %+v", state)
+				} else if state.Action != model.ActionPause || state.Passed || len(state.Voters) != 1 {
+					t.Errorf("got vote %+v, want an open pause vote", state)
+				}
+			case <-time.After(500 * time.Millisecond):
+				if tc.vote {
+					t.Error("no vote opened for the Syncplay request")
+				}
+			}
+		})
+	}
+}
+
+func TestSyncplayServerFollowsHost(t *testing.T) {
+	broker := NewMemoryBroker()
+
+	// member 启动一位参与者的房间和内嵌 Syncplay 服务器，返回房间和监听地址
+	member := func(id, name string, asHost bool) (*Room, string) {
+		p := newPeer(t, broker, id, 600)
+		presence := NewPresence(p.client, id, name, model.RoleFollower, nil)
+		if err := presence.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(presence.Leave)
+		room := NewRoom(RoomConfig{
+			MQTTClient:  p.client,
+			MPVCtrl:     p.ctrl,
+			Monitor:     p.monitor,
+			Presence:    presence,
+			Policy:      model.RoomPolicy{Mode: model.ControlHostOnly},
+			Interval:    time.Hour,
+			MaxDuration: 600,
+		})
+		if err := room.Start(asHost); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(room.Stop)
+
+		chat := NewChat(p.client, p.ctrl, presence.Self(), nil)
+		remote := NewRemote(room, presence, chat, nil, p.ctrl, nil, nil)
+		remote.Start(p.monitor.Subscribe())
+		t.Cleanup(remote.Stop)
+
+		// 先占用一个空闲端口再释放，得到固定的监听地址
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr := l.Addr().String()
+		l.Close()
+
+		server := NewSyncplayServer(remote, SyncplayConfig{Listen: addr, Room: "movie"}, nil)
+		if err := server.Start(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(server.Stop)
+		return room, addr
+	}
+	// listening 等待地址的监听状态变为 want
+	listening := func(addr string, want bool) bool {
+		deadline := time.Now().Add(3 * time.Second)
+		for {
+			conn, err := net.DialTimeout("tcp", addr, 100*time.Millisecond)
+			if conn != nil {
+				conn.Close()
+			}
+			if (err == nil) == want || time.Now().After(deadline) {
+				return (err == nil) == want
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	alice, aliceAddr := member("alice", "Alice", true)
+	_, bobAddr := member("bob", "Bob", false)
+	if !listening(aliceAddr, true) {
+		t.Fatal("host should listen for Syncplay users")
+	}
+	if !listening(bobAddr, false) {
+		t.Fatal("follower should not listen")
+	}
+
+	// 房主转移：旧房主停止监听，新房主开始监听
+	if err := alice.TransferHost("Bob"); err != nil {
+		t.Fatal(err)
+	}
+	if !listening(aliceAddr, false) {
+		t.Error("former host still listening after transfer")
+	}
+	if !listening(bobAddr, true) {
+		t.Error("new host not listening after transfer")
+	}
+}
@@ -0,0 +1,120 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Transport 消息传输（MQTT 连接，测试中可用 MemoryBroker 提供的内存连接）
+type Transport interface {
+	// Publish 发布消息（QoS 1），retained 为 true 时由 Broker 保留给之后的订阅者
+	Publish(topic string, payload []byte, retained bool) error
+	// Subscribe 订阅主题，支持 + 和 # 通配符
+	Subscribe(topic string, handler func(topic string, payload []byte)) error
+	// Unsubscribe 取消订阅
+	Unsubscribe(topic string) error
+	// Close 正常断开（不触发遗嘱）
+	Close()
+}
+
+// errTransportClosed 连接已断开
+var errTransportClosed = errors.New("连接已关闭")
+
+// mqttTransport 基于 paho 的 MQTT 连接
+type mqttTransport struct {
+	client mqtt.Client
+}
+
+// dialMQTT 连接 MQTT Broker
+func dialMQTT(config MQTTConfig, logger *slog.Logger) (*mqttTransport, error) {
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(config.Broker)
+	opts.SetClientID(config.ClientID)
+	opts.SetCleanSession(false)
+	opts.SetKeepAlive(30 * time.Second)
+	opts.SetAutoReconnect(true)
+
+	if config.WillSubtopic != "" {
+		opts.SetBinaryWill(config.Topic+"/"+config.WillSubtopic, config.WillPayload, 1, true)
+	}
+
+	connected := false
+	opts.OnConnect = func(c mqtt.Client) {
+		logger.Info("MQTT 已连接")
+		if connected {
+			metricReconnects.Inc()
+		}
+		connected = true
+	}
+
+	opts.OnConnectionLost = func(c mqtt.Client, err error) {
+		logger.Error("MQTT 连接丢失", "err", err)
+	}
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+
+	if !token.WaitTimeout(5 * time.Second) {
+		return nil, fmt.Errorf("MQTT 连接超时")
+	}
+
+	if token.Error() != nil {
+		return nil, fmt.Errorf("MQTT 连接失败: %w", token.Error())
+	}
+
+	return &mqttTransport{client: client}, nil
+}
+
+// Publish 发布消息
+func (t *mqttTransport) Publish(topic string, payload []byte, retained bool) error {
+	token := t.client.Publish(topic, 1, retained, payload)
+	token.Wait()
+	return token.Error()
+}
+
+// Subscribe 订阅主题
+func (t *mqttTransport) Subscribe(topic string, handler func(topic string, payload []byte)) error {
+	token := t.client.Subscribe(topic, 1, func(c mqtt.Client, msg mqtt.Message) {
+		handler(msg.Topic(), msg.Payload())
+	})
+	token.Wait()
+	return token.Error()
+}
+
+// Unsubscribe 取消订阅
+func (t *mqttTransport) Unsubscribe(topic string) error {
+	token := t.client.Unsubscribe(topic)
+	token.Wait()
+	return token.Error()
+}
+
+// Close 断开连接
+func (t *mqttTransport) Close() {
+	if t.client.IsConnected() {
+		t.client.Disconnect(250)
+	}
+}
+
+// topicMatches 主题是否匹配订阅（+ 匹配一级，# 匹配剩余所有级）
+func topicMatches(filter, topic string) bool {
+	filterParts := strings.Split(filter, "/")
+	topicParts := strings.Split(topic, "/")
+
+	for i, part := range filterParts {
+		if part == "#" {
+			return true
+		}
+		if i >= len(topicParts) {
+			return false
+		}
+		if part != "+" && part != topicParts[i] {
+			return false
+		}
+	}
+	return len(filterParts) == len(topicParts)
+}
@@ -3,6 +3,7 @@ package sync
 import (
 	"fmt"
 	"math"
+	gosync "sync"
 
 	"movie-night/model"
 )
@@ -10,6 +11,10 @@ import (
 // Validator 状态验证器
 type Validator struct {
 	MaxDuration float64 // 视频最大时长
+
+	mu       gosync.Mutex
+	item     int             // 时长和章节所属的播放列表项
+	chapters []model.Chapter // 当前视频的章节（来自 chapter-list）
 }
 
 // NewValidator 创建验证器
@@ -19,26 +24,75 @@ func NewValidator(maxDuration float64) *Validator {
 	}
 }
 
+// SetMedia 切换到播放列表中的另一项时更新时长和章节
+func (v *Validator) SetMedia(item int, duration float64, chapters []model.Chapter) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.item = item
+	v.MaxDuration = duration
+	v.chapters = chapters
+}
+
 // Validate 验证播放状态
 func (v *Validator) Validate(status model.PlayStatus) error {
-	// 1. 检查时间轴范围
+	// 1. 检查特殊浮点数
+	if math.IsNaN(status.Timestamp) {
+		return fmt.Errorf("时间轴是 NaN")
+	}
+
+	if math.IsInf(status.Timestamp, 0) {
+		return fmt.Errorf("时间轴是 Infinity")
+	}
+
+	// 2. 检查时间轴范围
 	if status.Timestamp < 0 {
 		return fmt.Errorf("时间轴不能为负: %.2f", status.Timestamp)
 	}
 
-	if v.MaxDuration > 0 && status.Timestamp > v.MaxDuration {
+	// 房主已经在播放另一项时，时长要切换之后才知道
+	v.mu.Lock()
+	maxDuration, sameItem := v.MaxDuration, status.Item == v.item
+	v.mu.Unlock()
+
+	if sameItem && maxDuration > 0 && status.Timestamp > maxDuration {
 		return fmt.Errorf("时间轴超出范围: %.2f > %.2f",
-			status.Timestamp, v.MaxDuration)
+			status.Timestamp, maxDuration)
 	}
 
-	// 2. 检查特殊浮点数
-	if math.IsNaN(status.Timestamp) {
-		return fmt.Errorf("时间轴是 NaN")
-	}
+	return nil
+}
 
-	if math.IsInf(status.Timestamp, 0) {
-		return fmt.Errorf("时间轴是 Infinity")
+// ValidateChapter 验证章节跳转：章节存在，且跳转后的位置落在该章节内
+func (v *Validator) ValidateChapter(event model.PlaybackEvent) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if event.Item != v.item {
+		return fmt.Errorf("章节属于播放列表第 %d 项，当前是第 %d 项", event.Item+1, v.item+1)
+	}
+	if event.Chapter < 0 || event.Chapter >= len(v.chapters) {
+		return fmt.Errorf("章节不存在: %d (共 %d 章)", event.Chapter, len(v.chapters))
 	}
 
+	start := v.chapters[event.Chapter].Time
+	end := v.MaxDuration
+	if event.Chapter+1 < len(v.chapters) {
+		end = v.chapters[event.Chapter+1].Time
+	}
+	// 关键帧对齐可能让位置略早于章节开始
+	if event.Position < start-1 || (end > 0 && event.Position > end) {
+		return fmt.Errorf("位置 %.2f 不在第 %d 章 [%.2f, %.2f) 内", event.Position, event.Chapter+1, start, end)
+	}
 	return nil
 }
+
+// ChapterTitle 章节标题（没有标题时用序号）
+func (v *Validator) ChapterTitle(index int) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if index >= 0 && index < len(v.chapters) && v.chapters[index].Title != "" {
+		return v.chapters[index].Title
+	}
+	return fmt.Sprintf("Chapter %d", index+1)
+}
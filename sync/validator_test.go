@@ -0,0 +1,59 @@
+package sync
+
+import (
+	"testing"
+
+	"movie-night/model"
+)
+
+func TestValidateChapter(t *testing.T) {
+	v := NewValidator(0)
+	v.SetMedia(1, 3000, []model.Chapter{
+		{Title: "Opening", Time: 0},
+		{Title: "Part 1", Time: 90},
+		{Title: "Credits", Time: 2800},
+	})
+
+	valid := model.PlaybackEvent{Kind: model.EventChapter, Item: 1, Chapter: 1, Position: 90.2}
+	if err := v.ValidateChapter(valid); err != nil {
+		t.Errorf("Expected valid chapter jump, got %v", err)
+	}
+
+	// 最后一章一直到视频结尾
+	last := model.PlaybackEvent{Kind: model.EventChapter, Item: 1, Chapter: 2, Position: 2999}
+	if err := v.ValidateChapter(last); err != nil {
+		t.Errorf("Expected last chapter to extend to the end, got %v", err)
+	}
+
+	cases := map[string]model.PlaybackEvent{
+		"missing chapter":  {Item: 1, Chapter: 3, Position: 2900},
+		"outside chapter":  {Item: 1, Chapter: 1, Position: 2850},
+		"other item":       {Item: 0, Chapter: 1, Position: 90},
+		"negative chapter": {Item: 1, Chapter: -1, Position: 0},
+	}
+	for name, event := range cases {
+		if err := v.ValidateChapter(event); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	if title := v.ChapterTitle(2); title != "Credits" {
+		t.Errorf("Expected Credits, got %s", title)
+	}
+	if title := v.ChapterTitle(5); title != "Chapter 6" {
+		t.Errorf("Expected fallback title, got %s", title)
+	}
+}
+
+func TestValidateOtherItem(t *testing.T) {
+	v := NewValidator(0)
+	v.SetMedia(0, 100, nil)
+
+	if err := v.Validate(model.PlayStatus{Timestamp: 150}); err == nil {
+		t.Error("Expected timestamp beyond duration to be rejected")
+	}
+	// 房主已经在下一项，时长切换后才能校验
+	if err := v.Validate(model.PlayStatus{Timestamp: 150, Item: 1}); err != nil {
+		t.Errorf("Expected status for another item to pass, got %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package sync
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"movie-night/model"
+)
+
+// seekVoteTolerance 跳转目标相差在该范围内视为同一个投票（秒）
+const seekVoteTolerance = 5.0
+
+// VoteTally 投票计数（同一时间只进行一个投票）
+type VoteTally struct {
+	Timeout time.Duration // 投票有效期
+
+	current *model.VoteState
+	voters  map[string]bool // 已投票的参与者 ID
+}
+
+// NewVoteTally 创建投票计数器
+func NewVoteTally(timeout time.Duration) *VoteTally {
+	return &VoteTally{Timeout: timeout}
+}
+
+// RequiredVotes 根据在线人数和比例计算需要的票数（至少 1 票）
+func RequiredVotes(participants int, quorum float64) int {
+	if quorum <= 0 || quorum > 1 {
+		quorum = 0.5
+	}
+	needed := int(math.Ceil(float64(participants) * quorum))
+	if needed < 1 {
+		needed = 1
+	}
+	return needed
+}
+
+// Cast 投出一票：没有进行中的投票时发起新投票，同一动作则累加
+// 返回最新的投票状态；已有其他动作的投票在进行时返回错误
+func (v *VoteTally) Cast(req model.ControlRequest, needed int, now time.Time) (model.VoteState, error) {
+	v.Expire(now)
+
+	if v.current == nil {
+		v.current = &model.VoteState{
+			Action:   req.Action,
+			Position: req.Position,
+			Proposer: req.Name,
+			Deadline: now.Add(v.Timeout).UnixMilli(),
+		}
+		v.voters = make(map[string]bool)
+	} else if !sameProposal(*v.current, req) {
+		return *v.current, fmt.Errorf("已有进行中的投票: %s", v.current.Action)
+	}
+
+	if !v.voters[req.From] {
+		v.voters[req.From] = true
+		v.current.Voters = append(v.current.Voters, req.Name)
+	}
+	v.current.Needed = needed
+
+	state := *v.current
+	if len(state.Voters) >= needed {
+		state.Resolved = true
+		state.Passed = true
+		v.current = nil
+	}
+	return state, nil
+}
+
+// Expire 检查投票是否过期，过期时返回已结束的投票状态
+func (v *VoteTally) Expire(now time.Time) (model.VoteState, bool) {
+	if v.current == nil || now.UnixMilli() < v.current.Deadline {
+		return model.VoteState{}, false
+	}
+
+	state := *v.current
+	state.Resolved = true
+	v.current = nil
+	return state, true
+}
+
+// Current 进行中的投票
+func (v *VoteTally) Current() (model.VoteState, bool) {
+	if v.current == nil {
+		return model.VoteState{}, false
+	}
+	return *v.current, true
+}
+
+// sameProposal 判断请求是否与进行中的投票相同
+func sameProposal(state model.VoteState, req model.ControlRequest) bool {
+	if state.Action != req.Action {
+		return false
+	}
+	switch req.Action {
+	case model.ActionSeek, model.ActionSkip:
+		return math.Abs(state.Position-req.Position) <= seekVoteTolerance
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package sync
+
+import (
+	"testing"
+	"time"
+
+	"movie-night/model"
+)
+
+func TestRequiredVotes(t *testing.T) {
+	cases := []struct {
+		participants int
+		quorum       float64
+		want         int
+	}{
+		{4, 0.5, 2},
+		{5, 0.5, 3},
+		{1, 0.5, 1},
+		{3, 1, 3},
+		{4, 0, 2}, // 非法比例按 0.5 处理
+	}
+
+	for _, c := range cases {
+		if got := RequiredVotes(c.participants, c.quorum); got != c.want {
+			t.Errorf("RequiredVotes(%d, %.2f) = %d, want %d", c.participants, c.quorum, got, c.want)
+		}
+	}
+}
+
+func TestVoteTallyPasses(t *testing.T) {
+	tally := NewVoteTally(30 * time.Second)
+	now := time.Now()
+
+	pause := func(id string) model.ControlRequest {
+		return model.ControlRequest{Action: model.ActionPause, From: id, Name: id}
+	}
+
+	state, err := tally.Cast(pause("alice"), 2, now)
+	if err != nil || state.Resolved {
+		t.Fatalf("First vote should open a pending vote, got %+v, err=%v", state, err)
+	}
+
+	// 同一个人重复投票不计数
+	state, _ = tally.Cast(pause("alice"), 2, now)
+	if len(state.Voters) != 1 {
+		t.Errorf("Duplicate vote counted: %v", state.Voters)
+	}
+
+	// 其他动作在投票进行中被拒绝
+	if _, err := tally.Cast(model.ControlRequest{Action: model.ActionSkip, Position: 60, From: "bob"}, 2, now); err == nil {
+		t.Error("Expected conflicting proposal to be rejected")
+	}
+
+	state, _ = tally.Cast(pause("bob"), 2, now)
+	if !state.Resolved || !state.Passed {
+		t.Errorf("Expected vote to pass, got %+v", state)
+	}
+	if _, active := tally.Current(); active {
+		t.Error("Passed vote should no longer be active")
+	}
+}
+
+func TestVoteTallyExpires(t *testing.T) {
+	tally := NewVoteTally(10 * time.Second)
+	now := time.Now()
+
+	tally.Cast(model.ControlRequest{Action: model.ActionSeek, Position: 120, From: "alice", Name: "alice"}, 3, now)
+
+	if _, ok := tally.Expire(now.Add(5 * time.Second)); ok {
+		t.Error("Vote expired too early")
+	}
+
+	state, ok := tally.Expire(now.Add(11 * time.Second))
+	if !ok || !state.Resolved || state.Passed {
+		t.Errorf("Expected expired, failed vote, got %+v (ok=%v)", state, ok)
+	}
+
+	// 过期后可以发起新的投票
+	state, err := tally.Cast(model.ControlRequest{Action: model.ActionPause, From: "bob", Name: "bob"}, 3, now.Add(12*time.Second))
+	if err != nil || state.Action != model.ActionPause {
+		t.Errorf("Expected new vote after expiry, got %+v, err=%v", state, err)
+	}
+}